@@ -0,0 +1,81 @@
+// cmd/import/main.go
+//
+// Imports historical attendance or first-timer records from a CSV file:
+//
+//	go run ./cmd/import -kind attendance -file attendance-2019.csv -dry-run
+//	go run ./cmd/import -kind first_timers -file cards.csv -mapping '{"visitDate":"Timestamp"}' -by secretariat@rccgsalvationcentre.org
+//	go run ./cmd/import -rollback 12
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/importer"
+)
+
+func main() {
+	kind := flag.String("kind", "", "record type: attendance or first_timers")
+	file := flag.String("file", "", "path to the CSV file")
+	mapping := flag.String("mapping", "", `JSON object of field -> CSV header, e.g. {"visitDate":"Timestamp"}`)
+	dateOrder := flag.String("date-order", "dmy", "order of slash-separated dates: dmy or mdy")
+	dryRun := flag.Bool("dry-run", false, "validate and report without saving")
+	by := flag.String("by", "cli-import", "who the import is recorded as")
+	rollback := flag.Uint("rollback", 0, "roll back the import batch with this id instead of importing")
+	flag.Parse()
+
+	database.Connect()
+	defer database.Close()
+
+	if *rollback != 0 {
+		batch, removed, err := importer.Rollback(database.DB, *rollback, *by)
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		log.Printf("Rolled back %s batch #%d: %d rows removed", batch.Kind, batch.ID, removed)
+		return
+	}
+
+	if *kind == "" || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var fields map[string]string
+	if *mapping != "" {
+		if err := json.Unmarshal([]byte(*mapping), &fields); err != nil {
+			log.Fatalf("Invalid -mapping: %v", err)
+		}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Could not open %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	report, err := importer.Run(database.DB, f, importer.Options{
+		Kind:      *kind,
+		Mapping:   fields,
+		DateOrder: *dateOrder,
+		DryRun:    *dryRun,
+		Filename:  filepath.Base(*file),
+		CreatedBy: *by,
+	})
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+
+	if len(report.Errors) > 0 {
+		log.Printf("%d rows have errors; nothing was imported", len(report.Errors))
+		os.Exit(1)
+	}
+}
@@ -9,11 +9,18 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Program timezones must resolve on hosts without a zoneinfo database
 
 	"rccg-salvation-centre-backend/internal/auth"
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/email"
 	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/publishing"
+	"rccg-salvation-centre-backend/internal/queue"
 	"rccg-salvation-centre-backend/internal/routes"
+	"rccg-salvation-centre-backend/internal/scheduler"
+	"rccg-salvation-centre-backend/internal/sms"
+	"rccg-salvation-centre-backend/internal/welcome"
 
 	"github.com/gin-gonic/gin"
 )
@@ -35,6 +42,9 @@ func main() {
 
 	auth.InitFirebase()
 
+	// Job types must be known before handlers can queue work
+	registerJobs()
+
 	r := gin.Default()
 	r.Use(middleware.CORSMiddleware())
 	r.Use(middleware.RateLimiter())
@@ -66,16 +76,17 @@ func main() {
 		}
 	}()
 
-	// 2. Start the background routine targeting your live domain
-	ctxKeepAlive, cancelKeepAlive := context.WithCancel(context.Background())
-	go startKeepAliveTicker(ctxKeepAlive)
+	// 2. Start background jobs. Each runs on one instance at a time.
+	ctxJobs, cancelJobs := context.WithCancel(context.Background())
+	scheduler.Start(ctxJobs)
+	queue.Start()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	// 3. Stop the keep-alive routine smoothly on server termination
-	cancelKeepAlive()
+	// 3. Stop background jobs smoothly on server termination
+	cancelJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -83,6 +94,13 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
+	// Let queued work in progress finish; anything cut off is retried later
+	if err := queue.Shutdown(ctx); err != nil {
+		log.Printf("Queued jobs did not finish: %v", err)
+	}
+	if err := scheduler.Wait(ctx); err != nil {
+		log.Printf("Background jobs did not finish: %v", err)
+	}
 }
 
 func validateEnv() error {
@@ -102,6 +120,13 @@ func validateEnv() error {
 		return fmt.Errorf("missing required environment variables: %v", missing)
 	}
 
+	if err := email.CheckConfig(); err != nil {
+		return err
+	}
+	if err := sms.CheckConfig(); err != nil {
+		return err
+	}
+
 	if len(os.Getenv("JWT_SECRET")) < 32 {
 		log.Println("Warning: JWT_SECRET should be at least 32 characters for better security")
 	}
@@ -109,37 +134,40 @@ func validateEnv() error {
 	return nil
 }
 
-// 4. Background task to ping the live API every 5 minutes
-func startKeepAliveTicker(ctx context.Context) {
-	// Give the server 10 seconds to fully deploy and start up before firing the first request
-	time.Sleep(10 * time.Second)
-
-	url := "https://api.rccgsalvationcentre.org/health"
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-
-	// Initial ping
-	pingServer(url)
-
-	for {
-		select {
-		case <-ticker.C:
-			pingServer(url)
-		case <-ctx.Done():
-			log.Println("Stopping keep-alive ticker...")
-			return
-		}
+// 4. Register background jobs with the scheduler and the queue
+func registerJobs() {
+	publishing.RegisterJobs()
+	queue.RegisterJobs()
+	middleware.RegisterJobs()
+	email.RegisterJobs()
+	sms.RegisterJobs()
+	welcome.RegisterJobs()
+
+	// Keeps a host that sleeps when idle awake. Only set KEEPALIVE_URL where that is wanted.
+	if url := os.Getenv("KEEPALIVE_URL"); url != "" {
+		scheduler.Register(scheduler.Job{
+			Name:    "keep-alive",
+			Every:   5 * time.Minute,
+			Timeout: 15 * time.Second,
+			Run: func(ctx context.Context) error {
+				return pingServer(ctx, url)
+			},
+		})
 	}
 }
 
-func pingServer(url string) {
-	client := http.Client{
-		Timeout: 10 * time.Second,
+func pingServer(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
 	}
-	resp, err := client.Get(url)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		log.Printf("Keep-alive ping to %s failed: %v", url)
-		return
+		return fmt.Errorf("keep-alive ping to %s failed: %w", url, err)
 	}
 	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("keep-alive ping to %s returned %s", url, resp.Status)
+	}
+	return nil
 }
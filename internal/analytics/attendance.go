@@ -0,0 +1,439 @@
+// internal/analytics/attendance.go
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	PeriodWeek    = "week"
+	PeriodMonth   = "month"
+	PeriodQuarter = "quarter"
+	PeriodYear    = "year"
+)
+
+// ValidPeriod reports whether p is a supported aggregation period
+func ValidPeriod(p string) bool {
+	return p == PeriodWeek || p == PeriodMonth || p == PeriodQuarter || p == PeriodYear
+}
+
+// Point is one attendance record reduced to what the analytics need
+type Point struct {
+	ID            uint           `json:"id"`
+	Date          time.Time      `json:"date"`
+	ServiceTypeID uint           `json:"serviceTypeId"`
+	ServiceType   string         `json:"serviceType"`
+	Adults        int            `json:"adults"`
+	Children      int            `json:"children"`
+	Total         int            `json:"total"`
+	Counts        map[string]int `gorm:"-" json:"counts,omitempty"` // Keyed by headcount category
+}
+
+// Category is a headcount category the report breaks attendance down by
+type Category struct {
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Group string `json:"group"`
+}
+
+// PeriodStat aggregates every service held within one period.
+// Rates are percentages; nil means there was nothing to compare against.
+type PeriodStat struct {
+	Start             time.Time      `json:"start"`
+	End               time.Time      `json:"end"` // Exclusive
+	Label             string         `json:"label"`
+	Services          int            `json:"services"`
+	Total             int            `json:"total"`
+	Adults            int            `json:"adults"`
+	Children          int            `json:"children"`
+	Categories        map[string]int `json:"categories"` // Totals per headcount category key
+	AveragePerService float64        `json:"averagePerService"`
+	MovingAverage     *float64       `json:"movingAverage"`
+	GrowthRate        *float64       `json:"growthRate"`
+	PreviousYearTotal *int           `json:"previousYearTotal"`
+	YearOverYearRate  *float64       `json:"yearOverYearRate"`
+}
+
+type ServiceTypeStat struct {
+	ServiceTypeID     uint    `json:"serviceTypeId"`
+	ServiceType       string  `json:"serviceType"`
+	Services          int     `json:"services"`
+	Total             int     `json:"total"`
+	Adults            int     `json:"adults"`
+	Children          int     `json:"children"`
+	AveragePerService float64 `json:"averagePerService"`
+	Peak              int     `json:"peak"`
+	Share             float64 `json:"share"` // Percentage of all attendance in the range
+}
+
+// CategoryStat totals one headcount category over the range.
+// Share is its percentage of all counts recorded in the same group.
+type CategoryStat struct {
+	Key               string   `json:"key"`
+	Name              string   `json:"name"`
+	Group             string   `json:"group"`
+	Services          int      `json:"services"` // Services where the category was counted
+	Total             int      `json:"total"`
+	AveragePerService float64  `json:"averagePerService"`
+	Share             float64  `json:"share"`
+	PreviousYearTotal int      `json:"previousYearTotal"`
+	YearOverYearRate  *float64 `json:"yearOverYearRate"`
+}
+
+type Split struct {
+	Adults        int     `json:"adults"`
+	Children      int     `json:"children"`
+	AdultShare    float64 `json:"adultShare"`
+	ChildrenShare float64 `json:"childrenShare"`
+}
+
+type Summary struct {
+	Services             int      `json:"services"`
+	Total                int      `json:"total"`
+	AveragePerService    float64  `json:"averagePerService"`
+	PreviousRangeTotal   int      `json:"previousRangeTotal"`
+	GrowthRate           *float64 `json:"growthRate"` // Against the preceding range of equal length
+	PreviousYearTotal    int      `json:"previousYearTotal"`
+	YearOverYearRate     *float64 `json:"yearOverYearRate"`
+	AverageGrowthRate    *float64 `json:"averageGrowthRate"`    // Mean period-over-period growth
+	AveragePerServiceYoY *float64 `json:"averagePerServiceYoY"` // Change in average per service vs a year earlier
+}
+
+// Report is the full analytics response for a date range [From, To)
+type Report struct {
+	Period        string            `json:"period"`
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	MovingWindow  int               `json:"movingWindow"`
+	Summary       Summary           `json:"summary"`
+	Periods       []PeriodStat      `json:"periods"`
+	ByServiceType []ServiceTypeStat `json:"byServiceType"`
+	ByCategory    []CategoryStat    `json:"byCategory"`
+	Split         Split             `json:"split"`
+	PeakServices  []Point           `json:"peakServices"`
+}
+
+// Options for Build. History must include at least a year (and one range length)
+// before From so year-over-year and growth comparisons can be made.
+type Options struct {
+	Period       string
+	From, To     time.Time
+	MovingWindow int
+	TopServices  int
+	Categories   []Category // In display order
+}
+
+// HistoryStart is the earliest date Build needs data from for opts
+func HistoryStart(opts Options) time.Time {
+	yearAgo := opts.From.AddDate(-1, 0, 0)
+	previousRange := opts.From.Add(-opts.To.Sub(opts.From))
+	if previousRange.Before(yearAgo) {
+		return PeriodStart(previousRange, opts.Period)
+	}
+	return PeriodStart(yearAgo, opts.Period)
+}
+
+// Build computes the analytics for opts from points (which may include history before From)
+func Build(points []Point, opts Options) Report {
+	var current []Point
+	for _, p := range points {
+		if !p.Date.Before(opts.From) && p.Date.Before(opts.To) {
+			current = append(current, p)
+		}
+	}
+
+	report := Report{
+		Period:        opts.Period,
+		From:          opts.From,
+		To:            opts.To,
+		MovingWindow:  opts.MovingWindow,
+		Periods:       buildPeriods(points, current, opts),
+		ByServiceType: byServiceType(current),
+		ByCategory:    byCategory(points, current, opts),
+		Split:         split(current),
+		PeakServices:  peaks(current, opts.TopServices),
+	}
+	report.Summary = summarize(points, current, report.Periods, opts)
+	return report
+}
+
+// PeriodStart returns the first day of the period containing t. Weeks start on Monday.
+func PeriodStart(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodQuarter:
+		month := time.Month((int(day.Month())-1)/3*3 + 1)
+		return time.Date(day.Year(), month, 1, 0, 0, 0, 0, t.Location())
+	case PeriodYear:
+		return time.Date(day.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+}
+
+func nextPeriod(start time.Time, period string) time.Time {
+	switch period {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case PeriodQuarter:
+		return start.AddDate(0, 3, 0)
+	case PeriodYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+func periodLabel(start time.Time, period string) string {
+	switch period {
+	case PeriodWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case PeriodQuarter:
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case PeriodYear:
+		return fmt.Sprintf("%d", start.Year())
+	default:
+		return start.Format("2006-01")
+	}
+}
+
+// buildPeriods totals current, the services within [From, To), by period. The
+// first and last periods may be cut short by the range. points, which include the
+// history before From, are used only for the previous-period and last-year figures.
+func buildPeriods(points, current []Point, opts Options) []PeriodStat {
+	shown := tallyPeriods(current, opts.Period)
+	history := tallyPeriods(points, opts.Period)
+
+	var periods []PeriodStat
+	for start := PeriodStart(opts.From, opts.Period); start.Before(opts.To); start = nextPeriod(start, opts.Period) {
+		stat := PeriodStat{Start: start, Categories: map[string]int{}}
+		if found, ok := shown[start]; ok {
+			stat = *found
+		}
+		stat.End = nextPeriod(start, opts.Period)
+		stat.Label = periodLabel(start, opts.Period)
+		if stat.Services > 0 {
+			stat.AveragePerService = round(float64(stat.Total) / float64(stat.Services))
+		}
+
+		if prev, ok := history[PeriodStart(start.AddDate(0, 0, -1), opts.Period)]; ok {
+			stat.GrowthRate = growth(prev.Total, stat.Total)
+		}
+		if lastYear, ok := history[PeriodStart(start.AddDate(-1, 0, 0), opts.Period)]; ok {
+			previous := lastYear.Total
+			stat.PreviousYearTotal = &previous
+			stat.YearOverYearRate = growth(previous, stat.Total)
+		}
+
+		periods = append(periods, stat)
+	}
+
+	// Moving average over the trailing window of periods that have data
+	window := opts.MovingWindow
+	for i := range periods {
+		sum, n := 0, 0
+		for j := i; j >= 0 && j > i-window; j-- {
+			if periods[j].Services > 0 {
+				sum += periods[j].Total
+				n++
+			}
+		}
+		if n > 0 {
+			avg := round(float64(sum) / float64(n))
+			periods[i].MovingAverage = &avg
+		}
+	}
+
+	return periods
+}
+
+// tallyPeriods adds up points by the period they fall in
+func tallyPeriods(points []Point, period string) map[time.Time]*PeriodStat {
+	totals := make(map[time.Time]*PeriodStat)
+	for _, p := range points {
+		start := PeriodStart(p.Date, period)
+		stat, ok := totals[start]
+		if !ok {
+			stat = &PeriodStat{Start: start, Categories: map[string]int{}}
+			totals[start] = stat
+		}
+		for key, n := range p.Counts {
+			stat.Categories[key] += n
+		}
+		stat.Services++
+		stat.Total += p.Total
+		stat.Adults += p.Adults
+		stat.Children += p.Children
+	}
+	return totals
+}
+
+func byServiceType(points []Point) []ServiceTypeStat {
+	stats := make(map[uint]*ServiceTypeStat)
+	grand := 0
+	for _, p := range points {
+		stat, ok := stats[p.ServiceTypeID]
+		if !ok {
+			stat = &ServiceTypeStat{ServiceTypeID: p.ServiceTypeID, ServiceType: p.ServiceType}
+			stats[p.ServiceTypeID] = stat
+		}
+		stat.Services++
+		stat.Total += p.Total
+		stat.Adults += p.Adults
+		stat.Children += p.Children
+		if p.Total > stat.Peak {
+			stat.Peak = p.Total
+		}
+		grand += p.Total
+	}
+
+	result := make([]ServiceTypeStat, 0, len(stats))
+	for _, stat := range stats {
+		stat.AveragePerService = round(float64(stat.Total) / float64(stat.Services))
+		if grand > 0 {
+			stat.Share = round(float64(stat.Total) / float64(grand) * 100)
+		}
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Total > result[j].Total })
+	return result
+}
+
+func byCategory(all, current []Point, opts Options) []CategoryStat {
+	stats := make(map[string]*CategoryStat, len(opts.Categories))
+	result := make([]CategoryStat, 0, len(opts.Categories))
+	for _, cat := range opts.Categories {
+		stats[cat.Key] = &CategoryStat{Key: cat.Key, Name: cat.Name, Group: cat.Group}
+	}
+
+	groupTotals := make(map[string]int)
+	for _, p := range current {
+		for key, n := range p.Counts {
+			stat, ok := stats[key]
+			if !ok || n == 0 {
+				continue
+			}
+			stat.Services++
+			stat.Total += n
+			groupTotals[stat.Group] += n
+		}
+	}
+
+	yearFrom, yearTo := opts.From.AddDate(-1, 0, 0), opts.To.AddDate(-1, 0, 0)
+	for _, p := range all {
+		if p.Date.Before(yearFrom) || !p.Date.Before(yearTo) {
+			continue
+		}
+		for key, n := range p.Counts {
+			if stat, ok := stats[key]; ok {
+				stat.PreviousYearTotal += n
+			}
+		}
+	}
+
+	for _, cat := range opts.Categories {
+		stat := stats[cat.Key]
+		if stat.Services > 0 {
+			stat.AveragePerService = round(float64(stat.Total) / float64(stat.Services))
+		}
+		if group := groupTotals[stat.Group]; group > 0 {
+			stat.Share = round(float64(stat.Total) / float64(group) * 100)
+		}
+		stat.YearOverYearRate = growth(stat.PreviousYearTotal, stat.Total)
+		result = append(result, *stat)
+	}
+	return result
+}
+
+func split(points []Point) Split {
+	var s Split
+	for _, p := range points {
+		s.Adults += p.Adults
+		s.Children += p.Children
+	}
+	if total := s.Adults + s.Children; total > 0 {
+		s.AdultShare = round(float64(s.Adults) / float64(total) * 100)
+		s.ChildrenShare = round(float64(s.Children) / float64(total) * 100)
+	}
+	return s
+}
+
+func peaks(points []Point, n int) []Point {
+	sorted := append([]Point(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Total == sorted[j].Total {
+			return sorted[i].Date.After(sorted[j].Date)
+		}
+		return sorted[i].Total > sorted[j].Total
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+func summarize(all, current []Point, periods []PeriodStat, opts Options) Summary {
+	var s Summary
+	for _, p := range current {
+		s.Services++
+		s.Total += p.Total
+	}
+	if s.Services > 0 {
+		s.AveragePerService = round(float64(s.Total) / float64(s.Services))
+	}
+
+	previousFrom := opts.From.Add(-opts.To.Sub(opts.From))
+	yearFrom, yearTo := opts.From.AddDate(-1, 0, 0), opts.To.AddDate(-1, 0, 0)
+	yearServices := 0
+	for _, p := range all {
+		if !p.Date.Before(previousFrom) && p.Date.Before(opts.From) {
+			s.PreviousRangeTotal += p.Total
+		}
+		if !p.Date.Before(yearFrom) && p.Date.Before(yearTo) {
+			s.PreviousYearTotal += p.Total
+			yearServices++
+		}
+	}
+	s.GrowthRate = growth(s.PreviousRangeTotal, s.Total)
+	s.YearOverYearRate = growth(s.PreviousYearTotal, s.Total)
+	if yearServices > 0 && s.Services > 0 {
+		s.AveragePerServiceYoY = growthFloat(float64(s.PreviousYearTotal)/float64(yearServices), s.AveragePerService)
+	}
+
+	sum, n := 0.0, 0
+	for _, p := range periods {
+		if p.GrowthRate != nil {
+			sum += *p.GrowthRate
+			n++
+		}
+	}
+	if n > 0 {
+		avg := round(sum / float64(n))
+		s.AverageGrowthRate = &avg
+	}
+	return s
+}
+
+// growth is the percentage change from previous to current, nil when previous is zero
+func growth(previous, current int) *float64 {
+	return growthFloat(float64(previous), float64(current))
+}
+
+func growthFloat(previous, current float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	rate := round((current - previous) / previous * 100)
+	return &rate
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,62 @@
+// internal/analytics/attendance_test.go
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildPeriodsFromMidPeriod(t *testing.T) {
+	points := []Point{
+		{ID: 1, Date: day(2024, time.January, 10), Total: 40},
+		{ID: 2, Date: day(2024, time.December, 15), Total: 200},
+		{ID: 3, Date: day(2025, time.January, 5), Total: 100}, // Before From, in the first period
+		{ID: 4, Date: day(2025, time.January, 20), Total: 50},
+		{ID: 5, Date: day(2025, time.February, 2), Total: 80},
+	}
+	opts := Options{
+		Period:       PeriodMonth,
+		From:         day(2025, time.January, 15),
+		To:           day(2025, time.March, 1),
+		MovingWindow: 3,
+		TopServices:  5,
+	}
+
+	report := Build(points, opts)
+	if len(report.Periods) != 2 {
+		t.Fatalf("got %d periods, want 2", len(report.Periods))
+	}
+
+	jan := report.Periods[0]
+	if jan.Label != "2025-01" || jan.Services != 1 || jan.Total != 50 {
+		t.Errorf("January = %s with %d services totalling %d, want 2025-01 with 1 service totalling 50",
+			jan.Label, jan.Services, jan.Total)
+	}
+	if jan.AveragePerService != 50 {
+		t.Errorf("January average = %v, want 50", jan.AveragePerService)
+	}
+	// Compared with the whole of December and of January a year earlier
+	if jan.GrowthRate == nil || *jan.GrowthRate != -75 {
+		t.Errorf("January growth = %v, want -75", jan.GrowthRate)
+	}
+	if jan.PreviousYearTotal == nil || *jan.PreviousYearTotal != 40 {
+		t.Errorf("January previous year total = %v, want 40", jan.PreviousYearTotal)
+	}
+
+	feb := report.Periods[1]
+	if feb.Total != 80 || feb.Services != 1 {
+		t.Errorf("February = %d services totalling %d, want 1 totalling 80", feb.Services, feb.Total)
+	}
+	// The previous period is the whole of January, including the 5th
+	if feb.GrowthRate == nil || *feb.GrowthRate != round((80.0-150.0)/150.0*100) {
+		t.Errorf("February growth = %v, want %v", feb.GrowthRate, round((80.0-150.0)/150.0*100))
+	}
+
+	if report.Summary.Total != 130 || report.Summary.Services != 2 {
+		t.Errorf("summary = %d services totalling %d, want 2 totalling 130", report.Summary.Services, report.Summary.Total)
+	}
+}
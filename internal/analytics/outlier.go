@@ -0,0 +1,53 @@
+// internal/analytics/outlier.go
+package analytics
+
+import "math"
+
+const (
+	// MinOutlierHistory is how many earlier counts are needed before a count can be flagged
+	MinOutlierHistory = 4
+	// OutlierThreshold is how many standard deviations from the mean a count may sit
+	OutlierThreshold = 3.0
+)
+
+// OutlierCheck compares one count against earlier counts of the same thing
+type OutlierCheck struct {
+	Value   int     `json:"value"`
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"stdDev"`
+	ZScore  float64 `json:"zScore"`
+	Samples int     `json:"samples"`
+	Outlier bool    `json:"outlier"`
+}
+
+// CheckOutlier flags value when it is more than OutlierThreshold standard deviations
+// from the mean of history. Returns nil when there is too little history to judge.
+func CheckOutlier(history []int, value int) *OutlierCheck {
+	if len(history) < MinOutlierHistory {
+		return nil
+	}
+
+	sum := 0.0
+	for _, h := range history {
+		sum += float64(h)
+	}
+	mean := sum / float64(len(history))
+
+	variance := 0.0
+	for _, h := range history {
+		variance += (float64(h) - mean) * (float64(h) - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(history)-1))
+
+	check := &OutlierCheck{Value: value, Mean: round(mean), StdDev: round(stdDev), Samples: len(history)}
+	diff := float64(value) - mean
+	switch {
+	case stdDev > 0:
+		check.ZScore = round(diff / stdDev)
+		check.Outlier = math.Abs(diff/stdDev) > OutlierThreshold
+	default:
+		// Every earlier count was identical; anything more than 10% away stands out
+		check.Outlier = math.Abs(diff) > 0.1*math.Max(mean, 1)
+	}
+	return check
+}
@@ -0,0 +1,28 @@
+// internal/auth/device_key.go
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// DeviceKeyPrefix marks device keys so they are recognisable if leaked
+const DeviceKeyPrefix = "dev_"
+
+// GenerateDeviceKey returns a new device key and the hash to store. The key itself is
+// only shown once, when the device is registered.
+func GenerateDeviceKey() (key string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = DeviceKeyPrefix + hex.EncodeToString(buf)
+	return key, HashDeviceKey(key), nil
+}
+
+// HashDeviceKey is the lookup hash for a device key
+func HashDeviceKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
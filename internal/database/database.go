@@ -2,10 +2,12 @@
 package database
 
 import (
+	"errors"
 	"log"
 	"os"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -50,24 +52,60 @@ func Connect() {
 	log.Printf("Connection Pool Settings: MaxIdle=%d, MaxOpen=%d, MaxLifetime=%v, MaxIdleTime=%v",
 		10, 100, time.Hour, 10*time.Minute)
 
+	if err := convertDateColumns(); err != nil {
+		log.Fatal("Failed to convert date columns:", err)
+	}
+	if err := clearDanglingOccurrenceLinks(); err != nil {
+		log.Fatal("Failed to clear links to deleted service occurrences:", err)
+	}
+
 	// Auto-migrate
 	err = DB.AutoMigrate(
 		&models.Admin{},
 		&models.Sermon{},
 		&models.ServiceType{},
+		&models.ServiceOccurrence{},
+		&models.Offering{},
 		&models.Testimony{},
 		&models.RegularProgram{},
+		&models.ProgramException{},
 		&models.SpecialEvent{},
+		&models.SpecialEventSession{},
+		&models.RegistrationField{},
+		&models.EventRegistration{},
+		&models.IdempotencyKey{},
 		&models.FirstTimer{},
+		&models.FirstTimerVisit{},
+		&models.WelcomeStep{},
+		&models.WelcomeMessage{},
+		&models.HeadcountCategory{},
 		&models.Attendance{},
+		&models.AttendanceCount{},
+		&models.AttendanceSection{},
+		&models.CountSheet{},
+		&models.CountSheetCount{},
 		&models.PrayerRequest{},
-		//&models.ActivityLog{},
+		&models.SlugRedirect{},
+		&models.ActivityLog{},
+		&models.ImportBatch{},
+		&models.Device{},
+		&models.SyncOperation{},
+		&models.ScheduledJob{},
+		&models.QueuedJob{},
+		&models.EmailTemplate{},
+		&models.EmailDelivery{},
+		&models.SMSMessage{},
+		&models.SMSOptOut{},
 	)
 	if err != nil {
 		log.Fatal("Failed to auto migrate:", err)
 	}
 
 	log.Println("Auto migration completed successfully for all models")
+
+	if err := runDataMigrations(); err != nil {
+		log.Fatal("Failed to run data migrations:", err)
+	}
 }
 
 // Close closes the database connection
@@ -78,3 +116,10 @@ func Close() error {
 	}
 	return sqlDB.Close()
 }
+
+// IsUniqueViolation reports whether err is Postgres refusing a row that would break
+// a unique index, e.g. when two requests create the same record at once
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,438 @@
+// internal/database/migrations.go
+package database
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/recurrence"
+	"rccg-salvation-centre-backend/internal/slug"
+
+	"gorm.io/gorm"
+)
+
+// dateColumns were timestamps holding midnight UTC and are now date columns
+var dateColumns = []struct{ table, column string }{
+	{"attendances", "date"},
+	{"sermons", "date"},
+	{"first_timers", "visit_date"},
+	{"first_timer_visits", "visit_date"},
+	{"service_occurrences", "date"},
+	{"special_events", "date"},
+	{"program_exceptions", "date"},
+	{"program_exceptions", "new_date"},
+	{"regular_programs", "starts_on"},
+	{"regular_programs", "ends_on"},
+}
+
+// convertDateColumns runs before AutoMigrate, which would convert the columns in the
+// session's timezone and could move every date back a day. Reading the stored
+// instants in UTC keeps each calendar day as entered.
+func convertDateColumns() error {
+	for _, dc := range dateColumns {
+		var dataType string
+		err := DB.Raw(`SELECT data_type FROM information_schema.columns
+			WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_name = ?`, dc.table, dc.column).
+			Scan(&dataType).Error
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(dataType, "timestamp") {
+			continue // Already a date, or a new table AutoMigrate will create
+		}
+		using := dc.column
+		if dataType == "timestamp with time zone" {
+			using += " AT TIME ZONE 'UTC'"
+		}
+		if err := DB.Exec("ALTER TABLE " + dc.table + " ALTER COLUMN " + dc.column +
+			" TYPE date USING (" + using + ")::date").Error; err != nil {
+			return err
+		}
+		log.Printf("Converted %s.%s to a date column", dc.table, dc.column)
+	}
+	return nil
+}
+
+// occurrenceLinks are the columns that point at a service occurrence
+var occurrenceLinks = []string{"attendances", "sermons", "first_timers", "first_timer_visits"}
+
+// clearDanglingOccurrenceLinks runs before AutoMigrate adds the foreign keys, which
+// it cannot do while a record points at an occurrence that no longer exists
+func clearDanglingOccurrenceLinks() error {
+	if !DB.Migrator().HasTable("service_occurrences") {
+		return nil
+	}
+	for _, table := range occurrenceLinks {
+		if !DB.Migrator().HasColumn(table, "service_occurrence_id") {
+			continue // A new table AutoMigrate will create
+		}
+		result := DB.Exec("UPDATE " + table + " SET service_occurrence_id = NULL " +
+			"WHERE service_occurrence_id IS NOT NULL AND NOT EXISTS " +
+			"(SELECT 1 FROM service_occurrences o WHERE o.id = " + table + ".service_occurrence_id)")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Unlinked %d %s from deleted service occurrences", result.RowsAffected, table)
+		}
+	}
+	return nil
+}
+
+// runDataMigrations fills in data that AutoMigrate cannot derive on its own.
+// Every step must be safe to run on each startup.
+func runDataMigrations() error {
+	steps := []struct {
+		name string
+		run  func(tx *gorm.DB) error
+	}{
+		{"backfill sermon slugs", backfillSermonSlugs},
+		{"backfill special event slugs", backfillSpecialEventSlugs},
+		{"link attendance to service types", linkAttendanceServiceTypes},
+		{"unique attendance per service", uniqueAttendancePerService},
+		{"seed headcount categories", seedHeadcountCategories},
+		{"move attendance columns into headcount counts", backfillAttendanceCounts},
+		{"link records to service occurrences", backfillServiceOccurrences},
+		{"order service types", orderServiceTypes},
+		{"structure regular program schedules", parseRegularProgramSchedules},
+		{"set special event start and end times", backfillSpecialEventTimes},
+		{"set special event end dates", backfillSpecialEventEndDates},
+		{"count text message costs in millionths", convertSMSCostsToMicros},
+	}
+
+	for _, step := range steps {
+		if err := DB.Transaction(step.run); err != nil {
+			log.Printf("Data migration %q failed: %v", step.name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func backfillSermonSlugs(tx *gorm.DB) error {
+	var sermons []models.Sermon
+	if err := tx.Where("slug IS NULL OR slug = ''").Order("id ASC").Find(&sermons).Error; err != nil {
+		return err
+	}
+
+	for _, sermon := range sermons {
+		s, err := slug.Assign(tx, &models.Sermon{}, models.SlugEntitySermon, sermon.ID, "", sermon.Title, sermon.Date.Time)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&sermon).UpdateColumn("slug", s).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(sermons) > 0 {
+		log.Printf("Backfilled slugs for %d sermons", len(sermons))
+	}
+	return nil
+}
+
+func backfillSpecialEventSlugs(tx *gorm.DB) error {
+	var events []models.SpecialEvent
+	if err := tx.Where("slug IS NULL OR slug = ''").Order("id ASC").Find(&events).Error; err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		s, err := slug.Assign(tx, &models.SpecialEvent{}, models.SlugEntitySpecialEvent, event.ID, "", event.Title, event.Date.Time)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&event).UpdateColumn("slug", s).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(events) > 0 {
+		log.Printf("Backfilled slugs for %d special events", len(events))
+	}
+	return nil
+}
+
+// Service type for older attendance rows that never named their service
+const unspecifiedServiceType = "Unspecified"
+
+// linkAttendanceServiceTypes points free-text attendance rows at a ServiceType,
+// creating types that only ever existed as text. Rows with no name share one
+// "Unspecified" type.
+func linkAttendanceServiceTypes(tx *gorm.DB) error {
+	// Earlier runs created a type with no name for them
+	var blank models.ServiceType
+	if err := tx.Where("TRIM(name) = ''").First(&blank).Error; err == nil {
+		if err := tx.Model(&blank).UpdateColumn("name", unspecifiedServiceType).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Attendance{}).Where("service_type_id = ?", blank.ID).
+			UpdateColumn("service_type", unspecifiedServiceType).Error; err != nil {
+			return err
+		}
+		log.Printf("Renamed the unnamed service type #%d to %q", blank.ID, unspecifiedServiceType)
+	} else if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	var names []string
+	if err := tx.Model(&models.Attendance{}).
+		Where("service_type_id IS NULL OR service_type_id = 0").
+		Distinct().Pluck("COALESCE(service_type, '')", &names).Error; err != nil {
+		return err
+	}
+
+	linkedBlank := false
+	for _, name := range names {
+		typeName := strings.TrimSpace(name)
+		rows := tx.Model(&models.Attendance{}).Where("service_type_id IS NULL OR service_type_id = 0")
+		if typeName == "" {
+			if linkedBlank {
+				continue // Every blank spelling is linked together
+			}
+			typeName, linkedBlank = unspecifiedServiceType, true
+			rows = rows.Where("TRIM(COALESCE(service_type, '')) = ''")
+		} else {
+			rows = rows.Where("service_type = ?", name)
+		}
+
+		var st models.ServiceType
+		err := tx.Where("LOWER(name) = ?", strings.ToLower(typeName)).First(&st).Error
+		if err == gorm.ErrRecordNotFound {
+			st = models.ServiceType{Name: typeName}
+			err = tx.Create(&st).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := rows.Updates(map[string]interface{}{"service_type_id": st.ID, "service_type": st.Name}).Error; err != nil {
+			return err
+		}
+		log.Printf("Linked attendance for %q to service type #%d", name, st.ID)
+	}
+	return nil
+}
+
+// uniqueAttendancePerService adds the (date, service type) unique index. Records
+// that share a service and date can't be combined here without losing their count
+// sheets or per-category counts, so they are reported and left for an admin to
+// resolve (GET /api/admin/attendance/duplicates). The index is added at the first
+// startup after none are left.
+func uniqueAttendancePerService(tx *gorm.DB) error {
+	if tx.Migrator().HasIndex(&models.Attendance{}, "idx_attendance_date_service_type") {
+		return nil
+	}
+
+	var duplicates []models.Attendance
+	if err := tx.Scopes(models.DuplicateAttendance).Order("date, service_type_id, id").
+		Find(&duplicates).Error; err != nil {
+		return err
+	}
+	if len(duplicates) > 0 {
+		for _, a := range duplicates {
+			log.Printf("Duplicate attendance #%d: %s on %s, total %d, recorded by %s",
+				a.ID, a.ServiceType, a.Date, a.Total, a.RecordedBy)
+		}
+		log.Printf("WARNING: %d attendance records share a service and date with another record. "+
+			"The unique index is not added until an admin merges or deletes them.", len(duplicates))
+		return nil
+	}
+
+	return tx.Exec("CREATE UNIQUE INDEX idx_attendance_date_service_type ON attendances (date, service_type_id)").Error
+}
+
+func seedHeadcountCategories(tx *gorm.DB) error {
+	for _, cat := range models.DefaultHeadcountCategories {
+		if err := tx.Where(models.HeadcountCategory{Key: cat.Key}).FirstOrCreate(&cat).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillAttendanceCounts copies the fixed attendance columns into per-category
+// counts for records that predate headcount categories
+func backfillAttendanceCounts(tx *gorm.DB) error {
+	result := tx.Exec(`
+		INSERT INTO attendance_counts (attendance_id, category_id, count)
+		SELECT a.id, c.id, v.n
+		FROM attendances a
+		CROSS JOIN LATERAL (VALUES
+			(?, a.adults), (?, a.children), (?, a.members), (?, a.visitors), (?, a.first_timers)
+		) AS v(category_key, n)
+		JOIN headcount_categories c ON c.key = v.category_key
+		WHERE v.n > 0
+		AND NOT EXISTS (SELECT 1 FROM attendance_counts ac WHERE ac.attendance_id = a.id)`,
+		models.HeadcountAdults, models.HeadcountChildren, models.HeadcountMembers,
+		models.HeadcountVisitors, models.HeadcountFirstTimers)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Moved %d attendance column values into headcount counts", result.RowsAffected)
+	}
+	return nil
+}
+
+// backfillServiceOccurrences creates an occurrence for every service that has attendance
+// or a sermon naming a known service type, then links those records and any first-timers
+// whose visit date had exactly one service
+func backfillServiceOccurrences(tx *gorm.DB) error {
+	statements := []string{
+		`INSERT INTO service_occurrences (date, service_type_id, service_type, created_at, updated_at)
+		SELECT DISTINCT a.date, st.id, st.name, NOW(), NOW()
+		FROM attendances a JOIN service_types st ON st.id = a.service_type_id
+		WHERE a.service_occurrence_id IS NULL
+		ON CONFLICT (date, service_type_id) DO NOTHING`,
+
+		`UPDATE attendances a SET service_occurrence_id = o.id
+		FROM service_occurrences o
+		WHERE a.service_occurrence_id IS NULL AND o.date = a.date AND o.service_type_id = a.service_type_id`,
+
+		`INSERT INTO service_occurrences (date, service_type_id, service_type, created_at, updated_at)
+		SELECT DISTINCT s.date, st.id, st.name, NOW(), NOW()
+		FROM sermons s JOIN service_types st ON LOWER(st.name) = LOWER(TRIM(s.service))
+		WHERE s.service_occurrence_id IS NULL
+		ON CONFLICT (date, service_type_id) DO NOTHING`,
+
+		`UPDATE sermons s SET service_occurrence_id = o.id
+		FROM service_occurrences o JOIN service_types st ON st.id = o.service_type_id
+		WHERE s.service_occurrence_id IS NULL AND o.date = s.date AND LOWER(st.name) = LOWER(TRIM(s.service))`,
+
+		`UPDATE first_timers f SET service_occurrence_id = o.id
+		FROM service_occurrences o
+		WHERE f.service_occurrence_id IS NULL AND o.date = f.visit_date
+		AND (SELECT COUNT(*) FROM service_occurrences o2 WHERE o2.date = f.visit_date) = 1`,
+
+		`UPDATE first_timer_visits v SET service_occurrence_id = o.id
+		FROM service_occurrences o
+		WHERE v.service_occurrence_id IS NULL AND o.date = v.visit_date AND o.service_type_id = v.service_type_id`,
+	}
+
+	linked := int64(0)
+	for _, statement := range statements {
+		result := tx.Exec(statement)
+		if result.Error != nil {
+			return result.Error
+		}
+		linked += result.RowsAffected
+	}
+	if linked > 0 {
+		log.Printf("Backfilled service occurrences (%d rows created or linked)", linked)
+	}
+	return nil
+}
+
+// orderServiceTypes gives existing service types a display order matching the old
+// alphabetical listing, unless an order has already been set
+func orderServiceTypes(tx *gorm.DB) error {
+	return tx.Exec(`
+		UPDATE service_types SET display_order = r.n
+		FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY name) AS n FROM service_types) r
+		WHERE service_types.id = r.id
+		AND NOT EXISTS (SELECT 1 FROM service_types WHERE display_order <> 0)`).Error
+}
+
+// parseRegularProgramSchedules reads a recurrence rule and start/end times from the
+// free-text day, frequency and time of older programs. Anything that cannot be read
+// with confidence keeps its best guess (if any) and is flagged for an admin to review.
+func parseRegularProgramSchedules(tx *gorm.DB) error {
+	var programs []models.RegularProgram
+	if err := tx.Where("rrule IS NULL OR rrule = ''").Where("recurrence_needs_review = ?", false).
+		Find(&programs).Error; err != nil {
+		return err
+	}
+
+	flagged := 0
+	for _, p := range programs {
+		rule, notes := recurrence.ParseText(p.Day, p.Frequency)
+		updates := map[string]interface{}{}
+		if rule.Freq != "" {
+			updates["rrule"] = rule.String()
+		}
+		if p.StartTime == "" && strings.TrimSpace(p.Time) != "" {
+			start, end, err := recurrence.ParseTimeRange(p.Time)
+			if err != nil {
+				notes = append(notes, "time "+strconv.Quote(p.Time)+": "+err.Error())
+			} else {
+				updates["start_time"], updates["end_time"] = start, end
+			}
+		}
+		if rule.Freq == "" || len(notes) > 0 {
+			updates["recurrence_needs_review"] = true
+			updates["recurrence_note"] = strings.Join(notes, "; ")
+			flagged++
+		}
+		if err := tx.Model(&p).UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(programs) > 0 {
+		log.Printf("Structured schedules for %d regular programs (%d need review)", len(programs), flagged)
+	}
+	return nil
+}
+
+// backfillSpecialEventTimes reads start and end timestamps from the free-text times of
+// older events. A time that cannot be read is moved into the description so nothing
+// is lost, and the event becomes all-day until an admin sets its times.
+func backfillSpecialEventTimes(tx *gorm.DB) error {
+	var events []models.SpecialEvent
+	if err := tx.Where("starts_at IS NULL AND start_time <> ''").Find(&events).Error; err != nil {
+		return err
+	}
+
+	unreadable := 0
+	for _, e := range events {
+		start, end, err := recurrence.ParseTimeRange(e.StartTime)
+		if err == nil && strings.TrimSpace(e.EndTime) != "" {
+			end, _, err = recurrence.ParseTimeRange(e.EndTime)
+		}
+		if err == nil {
+			err = e.SetTimes(start, end)
+		}
+		if err != nil {
+			text := strings.Trim(strings.Join([]string{e.StartTime, e.EndTime}, " - "), " -")
+			e.Description = strings.TrimSpace(e.Description + "\n\nTime: " + text)
+			e.SetTimes("", "")
+			unreadable++
+		}
+		if err := tx.Model(&e).UpdateColumns(map[string]interface{}{
+			"starts_at":   e.StartsAt,
+			"ends_at":     e.EndsAt,
+			"start_time":  e.StartTime,
+			"end_time":    e.EndTime,
+			"description": e.Description,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(events) > 0 {
+		log.Printf("Set times for %d special events (%d unreadable, now all-day)", len(events), unreadable)
+	}
+	return nil
+}
+
+// backfillSpecialEventEndDates makes events from before multi-day support one-day events
+func backfillSpecialEventEndDates(tx *gorm.DB) error {
+	return tx.Model(&models.SpecialEvent{}).Where("end_date IS NULL").
+		UpdateColumn("end_date", gorm.Expr("date")).Error
+}
+
+// convertSMSCostsToMicros moves costs recorded in whole minor units into the finer
+// cost_micros column and drops the old one
+func convertSMSCostsToMicros(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn(&models.SMSMessage{}, "cost_minor") {
+		return nil
+	}
+	if err := tx.Model(&models.SMSMessage{}).Where("cost_micros = 0 AND cost_minor <> 0").
+		UpdateColumn("cost_micros", gorm.Expr("cost_minor * 10000")).Error; err != nil {
+		return err
+	}
+	return tx.Migrator().DropColumn(&models.SMSMessage{}, "cost_minor")
+}
@@ -0,0 +1,162 @@
+// internal/email/email.go
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is one rendered email to one recipient
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Transport delivers messages. SMTP is used in production; the log transport
+// stands in for it during development.
+type Transport interface {
+	Send(ctx context.Context, m Message) error
+}
+
+var (
+	transportOnce sync.Once
+	transport     Transport
+)
+
+// current is the transport chosen by EMAIL_TRANSPORT: "smtp", or "log" (the default)
+func current() Transport {
+	transportOnce.Do(func() {
+		switch os.Getenv("EMAIL_TRANSPORT") {
+		case "smtp":
+			port := os.Getenv("SMTP_PORT")
+			if port == "" {
+				port = "587"
+			}
+			transport = SMTPTransport{
+				Host:     os.Getenv("SMTP_HOST"),
+				Port:     port,
+				Username: os.Getenv("SMTP_USERNAME"),
+				Password: os.Getenv("SMTP_PASSWORD"),
+				From:     from(),
+			}
+		default:
+			transport = LogTransport{Dir: os.Getenv("EMAIL_LOG_DIR")}
+		}
+	})
+	return transport
+}
+
+// CheckConfig refuses a production server on the log transport, where every email
+// would be written to the log and reported as sent without reaching anyone
+func CheckConfig() error {
+	if os.Getenv("ENVIRONMENT") != "production" {
+		return nil
+	}
+	if _, ok := current().(LogTransport); ok {
+		return errors.New("EMAIL_TRANSPORT must be smtp in production; the log transport sends nothing")
+	}
+	return nil
+}
+
+// from is the sender address, EMAIL_FROM
+func from() string {
+	if address := os.Getenv("EMAIL_FROM"); address != "" {
+		return address
+	}
+	return "RCCG Salvation Centre <no-reply@rccgsalvationcentre.org>"
+}
+
+// LogTransport writes each message to Dir as a .eml file that a mail client can
+// open, or to the server log when Dir is empty. Nothing is sent.
+type LogTransport struct {
+	Dir string
+}
+
+func (t LogTransport) Send(ctx context.Context, m Message) error {
+	if t.Dir == "" {
+		log.Printf("[EMAIL] To: %s | Subject: %s\n%s", m.To, m.Subject, m.Text)
+		return nil
+	}
+	raw, err := build(from(), m)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000"), unsafeFileChars.ReplaceAllString(m.To, "_"))
+	return os.WriteFile(filepath.Join(t.Dir, name), raw, 0o644)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]`)
+
+// build renders m as a MIME message with text and HTML parts
+func build(sender string, m Message) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "rccgsalvationcentre.org"
+	if at := strings.LastIndex(sender, "@"); at >= 0 {
+		domain = strings.Trim(sender[at+1:], "> ")
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", sender},
+		{"To", m.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", "<" + hex.EncodeToString(id) + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,146 @@
+// internal/email/notify.go
+package email
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/queue"
+
+	"gorm.io/gorm"
+)
+
+// Queue job type that sends one delivery
+const sendJob = "email.send"
+
+// Notification is an email about something that happened, e.g. a new testimony
+type Notification struct {
+	Type       string // The kind of email, which picks the template
+	EntityType string // What it is about, e.g. "testimony"
+	EntityID   uint
+	Data       map[string]string // Template fields
+}
+
+type sendPayload struct {
+	DeliveryID uint `json:"deliveryId"`
+}
+
+// RegisterJobs adds email sending to the queue
+func RegisterJobs() {
+	queue.Register(sendJob, queue.Options{MaxAttempts: 6, Concurrency: 2, Timeout: 30 * time.Second}, send)
+}
+
+// Send renders n once and queues a copy for each recipient in tx, so the emails go
+// out only if the change they describe is saved
+func Send(tx *gorm.DB, recipients []string, n Notification) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	subject, html, text, err := render(tx, n)
+	if err != nil {
+		return err
+	}
+	for _, to := range recipients {
+		if _, err := queueDelivery(tx, to, n, subject, html, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deliver queues n for one recipient in tx and returns the delivery, for callers
+// that keep track of what was sent to whom
+func Deliver(tx *gorm.DB, to string, n Notification) (models.EmailDelivery, error) {
+	subject, html, text, err := render(tx, n)
+	if err != nil {
+		return models.EmailDelivery{}, err
+	}
+	return queueDelivery(tx, to, n, subject, html, text)
+}
+
+// render fills in the template for n
+func render(tx *gorm.DB, n Notification) (subject, html, text string, err error) {
+	t, err := Template(tx, n.Type)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject, html, text, err = Render(t, n.Data)
+	if err != nil {
+		// An edited template that no longer renders must not stop the email, or the
+		// submission that triggered it
+		log.Printf("[EMAIL] Template %s failed, using the default: %v", n.Type, err)
+		d := defaults[n.Type]
+		return Render(models.EmailTemplate{Subject: d.Subject, HTMLBody: d.HTML, TextBody: d.Text}, n.Data)
+	}
+	return subject, html, text, nil
+}
+
+func queueDelivery(tx *gorm.DB, to string, n Notification, subject, html, text string) (models.EmailDelivery, error) {
+	delivery := models.EmailDelivery{
+		Type:       n.Type,
+		Recipient:  strings.ToLower(strings.TrimSpace(to)),
+		Subject:    subject,
+		HTMLBody:   html,
+		TextBody:   text,
+		EntityType: n.EntityType,
+		EntityID:   n.EntityID,
+		Status:     models.EmailQueued,
+	}
+	if err := tx.Create(&delivery).Error; err != nil {
+		return delivery, err
+	}
+	return delivery, queue.Enqueue(tx, sendJob, sendPayload{DeliveryID: delivery.ID})
+}
+
+// NotifyRole sends n to every admin with role, or to the superadmins if nobody has
+// the role, so a submission is never missed
+func NotifyRole(tx *gorm.DB, role string, n Notification) error {
+	var recipients []string
+	if err := tx.Model(&models.Admin{}).Where("role = ?", role).Order("email").Pluck("email", &recipients).Error; err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		if err := tx.Model(&models.Admin{}).Where("role = ?", "superadmin").Order("email").Pluck("email", &recipients).Error; err != nil {
+			return err
+		}
+	}
+	return Send(tx, recipients, n)
+}
+
+// send delivers one queued email and records the attempt
+func send(ctx context.Context, p sendPayload) error {
+	var delivery models.EmailDelivery
+	err := database.DB.WithContext(ctx).First(&delivery, p.DeliveryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // Deleted since it was queued
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status == models.EmailSent {
+		return nil
+	}
+
+	err = current().Send(ctx, Message{
+		To:      delivery.Recipient,
+		Subject: delivery.Subject,
+		HTML:    delivery.HTMLBody,
+		Text:    delivery.TextBody,
+	})
+
+	changes := map[string]interface{}{"attempts": gorm.Expr("attempts + 1")}
+	if err != nil {
+		changes["status"], changes["last_error"] = models.EmailFailed, err.Error()
+	} else {
+		changes["status"], changes["last_error"], changes["sent_at"] = models.EmailSent, "", time.Now()
+	}
+	if updateErr := database.DB.Model(&models.EmailDelivery{ID: delivery.ID}).Updates(changes).Error; updateErr != nil {
+		log.Printf("[EMAIL] Failed to record delivery %d: %v", delivery.ID, updateErr)
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+// internal/email/templates.go
+package email
+
+import (
+	"bytes"
+	"errors"
+	htmltemplate "html/template"
+	"sort"
+	texttemplate "text/template"
+
+	"rccg-salvation-centre-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of email, each with an editable template
+const (
+	TestimonySubmitted     = "testimony.submitted"
+	PrayerRequestSubmitted = "prayer_request.submitted"
+	FirstTimerSubmitted    = "first_timer.submitted"
+)
+
+// Default is the built-in wording of a kind of email, used until an admin edits it.
+// Fields are what the templates can use, e.g. {{.Name}}, with example values for previews.
+type Default struct {
+	Description string
+	Subject     string
+	HTML        string
+	Text        string
+	Fields      map[string]string
+}
+
+var defaults = map[string]Default{
+	TestimonySubmitted: {
+		Description: "Sent to the secretariat when a testimony is submitted",
+		Subject:     "New testimony: {{.Title}}",
+		HTML: `<p>{{.Name}} has shared a testimony on the website.</p>
+<h3>{{.Title}}</h3>
+<p style="white-space: pre-line">{{.Message}}</p>
+<p>Sign in to the admin panel to approve or reject it.</p>`,
+		Text: `{{.Name}} has shared a testimony on the website.
+
+{{.Title}}
+
+{{.Message}}
+
+Sign in to the admin panel to approve or reject it.`,
+		Fields: map[string]string{"Name": "Grace Okafor", "Title": "Healed after prayer", "Message": "I thank God for..."},
+	},
+	PrayerRequestSubmitted: {
+		Description: "Sent to the secretariat when a prayer request is submitted",
+		Subject:     "New prayer request from {{.Name}}",
+		HTML: `<p>{{.Name}} ({{.Email}}) has sent a prayer request.</p>
+<p style="white-space: pre-line">{{.Request}}</p>`,
+		Text: `{{.Name}} ({{.Email}}) has sent a prayer request.
+
+{{.Request}}`,
+		Fields: map[string]string{"Name": "Samuel Adeyemi", "Email": "samuel@example.com", "Request": "Please pray for my family..."},
+	},
+	FirstTimerSubmitted: {
+		Description: "Sent to visitors welfare when a first-timer is recorded",
+		Subject:     "New first-timer: {{.FirstName}} {{.LastName}}",
+		HTML: `<p>{{.FirstName}} {{.LastName}} visited on {{.VisitDate}}.</p>
+<ul>
+<li>Phone: {{.Phone}}</li>
+<li>Email: {{.Email}}</li>
+<li>Interested in membership: {{.InterestedInMembership}}</li>
+</ul>
+<p>Sign in to the admin panel to follow up.</p>`,
+		Text: `{{.FirstName}} {{.LastName}} visited on {{.VisitDate}}.
+
+Phone: {{.Phone}}
+Email: {{.Email}}
+Interested in membership: {{.InterestedInMembership}}
+
+Sign in to the admin panel to follow up.`,
+		Fields: map[string]string{
+			"FirstName": "Ada", "LastName": "Eze", "VisitDate": "2025-03-02",
+			"Phone": "+2348012345678", "Email": "ada@example.com", "InterestedInMembership": "yes",
+		},
+	},
+}
+
+var ErrUnknownTemplate = errors.New("unknown email type")
+
+// Types lists the kinds of email with their defaults
+func Types() []string {
+	types := make([]string, 0, len(defaults))
+	for t := range defaults {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// DefaultFor returns the built-in wording of a kind of email
+func DefaultFor(emailType string) (Default, bool) {
+	d, ok := defaults[emailType]
+	return d, ok
+}
+
+// RegisterDefault adds a kind of email. Call it at startup.
+func RegisterDefault(emailType string, d Default) {
+	if _, exists := defaults[emailType]; exists {
+		panic("email: template " + emailType + " registered twice")
+	}
+	defaults[emailType] = d
+}
+
+// Template loads the saved template for a kind of email, or its default
+func Template(db *gorm.DB, emailType string) (models.EmailTemplate, error) {
+	d, ok := defaults[emailType]
+	if !ok {
+		return models.EmailTemplate{}, ErrUnknownTemplate
+	}
+	var t models.EmailTemplate
+	err := db.Where("type = ?", emailType).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.EmailTemplate{Type: emailType, Subject: d.Subject, HTMLBody: d.HTML, TextBody: d.Text}, nil
+	}
+	return t, err
+}
+
+// Render fills in a template. Fields not given are left blank.
+func Render(t models.EmailTemplate, data map[string]string) (subject, html, text string, err error) {
+	var buf bytes.Buffer
+	subjectTmpl, err := texttemplate.New("subject").Option("missingkey=zero").Parse(t.Subject)
+	if err != nil {
+		return "", "", "", err
+	}
+	if err := subjectTmpl.Execute(&buf, data); err != nil {
+		return "", "", "", err
+	}
+	subject = buf.String()
+
+	buf.Reset()
+	htmlTmpl, err := htmltemplate.New("html").Option("missingkey=zero").Parse(t.HTMLBody)
+	if err != nil {
+		return "", "", "", err
+	}
+	if err := htmlTmpl.Execute(&buf, data); err != nil {
+		return "", "", "", err
+	}
+	html = buf.String()
+
+	buf.Reset()
+	textTmpl, err := texttemplate.New("text").Option("missingkey=zero").Parse(t.TextBody)
+	if err != nil {
+		return "", "", "", err
+	}
+	if err := textTmpl.Execute(&buf, data); err != nil {
+		return "", "", "", err
+	}
+	return subject, html, buf.String(), nil
+}
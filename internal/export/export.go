@@ -0,0 +1,85 @@
+// internal/export/export.go
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Writer streams a table row by row; the first row written is the header
+type Writer interface {
+	WriteRow(values []string) error
+	Close() error
+}
+
+// NewWriter returns a streaming writer for format ("csv" or "xlsx")
+func NewWriter(format string, w io.Writer, sheetName string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, sheetName)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ContentType returns the MIME type for format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ValidFormat reports whether format is supported
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatXLSX
+}
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func (cw *csvWriter) WriteRow(values []string) error {
+	safe := make([]string, len(values))
+	for i, v := range values {
+		safe[i] = neutralizeFormula(v)
+	}
+	if err := cw.w.Write(safe); err != nil {
+		return err
+	}
+
+	// Push data to the client regularly instead of buffering the whole file
+	cw.rows++
+	if cw.rows%500 == 0 {
+		cw.w.Flush()
+		return cw.w.Error()
+	}
+	return nil
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// neutralizeFormula stops spreadsheet apps from evaluating user-submitted text as a formula
+func neutralizeFormula(v string) string {
+	if v == "" || !strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	return "'" + v
+}
@@ -0,0 +1,34 @@
+// internal/handlers/activity_log.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin: Get the audit trail (latest first, superadmin only)
+// GET /api/admin/activity-log?adminEmail=&action=&from=&to=&limit=
+func AdminGetActivityLogs(c *gin.Context) {
+	db, err := filterActivityLogs(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "200"))
+	if err != nil || limit < 1 || limit > 1000 {
+		limit = 200
+	}
+
+	var logs []models.ActivityLog
+	db.Order("created_at DESC").Limit(limit).Find(&logs)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  logs,
+		"count": len(logs),
+	})
+}
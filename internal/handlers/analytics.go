@@ -0,0 +1,114 @@
+// internal/handlers/analytics.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/analytics"
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Admin: Attendance analytics
+// GET /api/admin/analytics/attendance?period=week|month|quarter|year&from=&to=&serviceTypeId=&window=4&top=5
+// Defaults to monthly figures for the last twelve months.
+func AdminGetAttendanceAnalytics(c *gin.Context) {
+	period := c.DefaultQuery("period", analytics.PeriodMonth)
+	if !analytics.ValidPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period. Use 'week', 'month', 'quarter' or 'year'"})
+		return
+	}
+
+	// Windows are whole church-local days; "to" is exclusive from here on
+	to := localtime.Today().AddDays(1)
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := localtime.ParseDate(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date. Use YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDays(1)
+	}
+	from := localtime.Date{Time: to.AddDate(-1, 0, 0)}
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := localtime.ParseDate(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date. Use YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "'from' must be before 'to'"})
+		return
+	}
+	if to.Sub(from.Time) > 10*366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range cannot exceed 10 years"})
+		return
+	}
+
+	window, err := strconv.Atoi(c.DefaultQuery("window", "4"))
+	if err != nil || window < 1 || window > 52 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be between 1 and 52"})
+		return
+	}
+	top, err := strconv.Atoi(c.DefaultQuery("top", "5"))
+	if err != nil || top < 1 || top > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "top must be between 1 and 50"})
+		return
+	}
+
+	opts := analytics.Options{Period: period, From: from.Time, To: to.Time, MovingWindow: window, TopServices: top}
+
+	categories, err := loadHeadcountCategories(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load headcount categories"})
+		return
+	}
+	keys := make(map[uint]string, len(categories))
+	for _, cat := range categories {
+		keys[cat.ID] = cat.Key
+		opts.Categories = append(opts.Categories, analytics.Category{Key: cat.Key, Name: cat.Name, Group: cat.GroupName})
+	}
+
+	scope := database.DB.Model(&models.Attendance{}).
+		Where("date >= ? AND date < ?", localtime.Date{Time: analytics.HistoryStart(opts)}, to)
+	if serviceTypeID := c.Query("serviceTypeId"); serviceTypeID != "" {
+		scope = scope.Where("service_type_id = ?", serviceTypeID)
+	}
+
+	var points []analytics.Point
+	if err := scope.Session(&gorm.Session{}).
+		Select("id, date, service_type_id, service_type, adults, children, total").
+		Order("date ASC").Find(&points).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance"})
+		return
+	}
+
+	var counts []models.AttendanceCount
+	if err := database.DB.Where("attendance_id IN (?)", scope.Session(&gorm.Session{}).Select("id")).
+		Find(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance"})
+		return
+	}
+	byRecord := make(map[uint]map[string]int)
+	for _, count := range counts {
+		if byRecord[count.AttendanceID] == nil {
+			byRecord[count.AttendanceID] = map[string]int{}
+		}
+		byRecord[count.AttendanceID][keys[count.CategoryID]] = count.Count
+	}
+
+	for i := range points {
+		points[i].Date = points[i].Date.UTC()
+		points[i].Counts = byRecord[points[i].ID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": analytics.Build(points, opts)})
+}
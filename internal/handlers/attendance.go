@@ -2,37 +2,100 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"time"
+	"strings"
 
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
 	"rccg-salvation-centre-backend/internal/middleware"
 	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/occurrence"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errAttendanceExists     = errors.New("attendance already recorded for this service and date")
+	errAttendanceLocked     = errors.New("Attendance record has been signed off. A superadmin must unlock it before it can be changed")
+	errAttendanceFromSheets = errors.New("Counts for this service come from count sheets. Submit or delete a count sheet instead")
 )
 
 // Admin: Get all attendance records (sorted latest date first)
 func AdminGetAttendance(c *gin.Context) {
+	db, err := filterAttendance(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var attendance []models.Attendance
-	database.DB.Order("date DESC").Find(&attendance)
+	db.Preload("Counts").Order("date DESC").Find(&attendance)
+	c.JSON(http.StatusOK, gin.H{"data": attendance})
+}
+
+// Admin: Services recorded more than once, from before each service could only have
+// one record. Resolve a group by sending the figures of the extra records to
+// POST /api/admin/attendance with onConflict "merge" (or "replace"), then deleting them.
+// GET /api/admin/attendance/duplicates
+func AdminGetDuplicateAttendance(c *gin.Context) {
+	var records []models.Attendance
+	if err := database.DB.Scopes(models.DuplicateAttendance).Preload("Counts").
+		Order("date DESC, service_type_id, id").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance"})
+		return
+	}
+
+	type duplicateGroup struct {
+		Date          localtime.Date      `json:"date"`
+		ServiceTypeID uint                `json:"serviceTypeId"`
+		ServiceType   string              `json:"serviceType"`
+		Records       []models.Attendance `json:"records"`
+	}
+	groups := []duplicateGroup{}
+	for _, a := range records {
+		if n := len(groups); n > 0 && groups[n-1].Date.Equal(a.Date.Time) && groups[n-1].ServiceTypeID == a.ServiceTypeID {
+			groups[n-1].Records = append(groups[n-1].Records, a)
+			continue
+		}
+		groups = append(groups, duplicateGroup{a.Date, a.ServiceTypeID, a.ServiceType, []models.Attendance{a}})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// Admin: Get a single attendance record
+func AdminGetAttendanceRecord(c *gin.Context) {
+	var attendance models.Attendance
+	if err := database.DB.Preload("Counts").First(&attendance, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"data": attendance})
 }
 
 // Admin: Create attendance record
+// Headcounts are sent as "counts": {"<category key>": n}; the original adults/children/
+// members/visitors/firstTimers fields are still accepted. Total is computed server-side.
+// If the service already has a record for the date, the request fails with 409 unless
+// "onConflict" is "merge" (add the new counts to the existing record) or "replace"
+// (overwrite the existing counts).
 func CreateAttendance(c *gin.Context) {
 	adminEmail := c.GetString("adminEmail")
 
 	var input struct {
-		Date        string `json:"date" binding:"required"` // YYYY-MM-DD
-		ServiceType string `json:"serviceType" binding:"required"`
-		Adults      int    `json:"adults"`
-		Children    int    `json:"children"`
-		Total       int    `json:"total"`
-		FirstTimers int    `json:"firstTimers"`
-		Visitors    int    `json:"visitors"`
-		Members     int    `json:"members"`
-		Notes       string `json:"notes"`
+		Date          string         `json:"date" binding:"required"` // YYYY-MM-DD
+		ServiceTypeID uint           `json:"serviceTypeId"`
+		ServiceType   string         `json:"serviceType"` // Name, accepted when serviceTypeId is not given
+		Counts        map[string]int `json:"counts"`
+		Adults        *int           `json:"adults"`
+		Children      *int           `json:"children"`
+		FirstTimers   *int           `json:"firstTimers"`
+		Visitors      *int           `json:"visitors"`
+		Members       *int           `json:"members"`
+		Notes         string         `json:"notes"`
+		OnConflict    string         `json:"onConflict"` // "", "merge" or "replace"
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -40,58 +103,158 @@ func CreateAttendance(c *gin.Context) {
 		return
 	}
 
-	parsedDate, err := time.Parse("2006-01-02", input.Date)
+	if input.OnConflict != "" && input.OnConflict != "merge" && input.OnConflict != "replace" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid onConflict. Use 'merge' or 'replace'"})
+		return
+	}
+
+	parsedDate, err := localtime.ParseDate(input.Date)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
 	}
 
+	serviceType, err := resolveServiceType(database.DB, input.ServiceTypeID, input.ServiceType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categories, err := loadHeadcountCategories(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load headcount categories"})
+		return
+	}
+
+	counts := models.LegacyCounts(input.Adults, input.Children, input.Members, input.Visitors, input.FirstTimers)
+	for key, n := range input.Counts {
+		counts[key] = n
+	}
+
 	attendance := models.Attendance{
-		Date:        parsedDate,
-		ServiceType: input.ServiceType,
-		Adults:      input.Adults,
-		Children:    input.Children,
-		Total:       input.Total,
-		FirstTimers: input.FirstTimers,
-		Visitors:    input.Visitors,
-		Members:     input.Members,
-		Notes:       input.Notes,
-		RecordedBy:  adminEmail,
-	}
-
-	if err := database.DB.Create(&attendance).Error; err != nil {
+		Date:          parsedDate,
+		ServiceTypeID: serviceType.ID,
+		ServiceType:   serviceType.Name,
+		Notes:         input.Notes,
+		RecordedBy:    adminEmail,
+	}
+	if err := attendance.ApplyCounts(counts, categories); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusCreated
+	message := "Attendance record created"
+	action := "Created attendance record"
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.Attendance
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Counts").
+			Where("date = ? AND service_type_id = ?", parsedDate, serviceType.ID).
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return saveAttendance(tx, &attendance)
+		}
+		if err != nil {
+			return err
+		}
+
+		if input.OnConflict != "" {
+			if existing.LockedAt != nil {
+				return errAttendanceLocked
+			}
+			if hasCountSheets(tx, existing.ID) {
+				return errAttendanceFromSheets
+			}
+		}
+
+		switch input.OnConflict {
+		case "merge":
+			merged := existing.CountsByKey(categories)
+			for key, n := range counts {
+				merged[key] += n
+			}
+			counts = merged
+			if attendance.Notes != "" {
+				existing.Notes = strings.TrimSpace(existing.Notes + "\n" + attendance.Notes)
+			}
+			message, action = "Attendance merged into existing record", "Merged attendance record"
+		case "replace":
+			existing.Notes = attendance.Notes
+			message, action = "Existing attendance record replaced", "Replaced attendance record"
+		default:
+			attendance = existing
+			return errAttendanceExists
+		}
+
+		existing.RecordedBy = adminEmail
+		if err := existing.ApplyCounts(counts, categories); err != nil {
+			return err
+		}
+		attendance = existing
+		status = http.StatusOK
+		return saveAttendance(tx, &attendance)
+	})
+
+	switch {
+	case errors.Is(err, errAttendanceExists):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      "Attendance already recorded for this service and date. Resend with onConflict 'merge' or 'replace'",
+			"attendance": attendance,
+		})
+		return
+	case errors.Is(err, errAttendanceLocked), errors.Is(err, errAttendanceFromSheets):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, models.ErrInvalidAttendance):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case database.IsUniqueViolation(err):
+		// Another request recorded the service between our check and the insert
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Attendance already recorded for this service and date. Resend with onConflict 'merge' or 'replace'",
+		})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attendance record"})
 		return
 	}
 
-	middleware.LogActivity(c, "Created attendance record", input.ServiceType+" on "+input.Date)
+	middleware.LogActivity(c, action, attendance.ServiceType+" on "+input.Date)
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message":    "Attendance record created",
+	c.JSON(status, gin.H{
+		"message":    message,
 		"attendance": attendance,
 	})
 }
 
 // Admin: Update attendance record
+// "counts" only needs the categories being changed; other stored counts are kept.
 func UpdateAttendance(c *gin.Context) {
 	id := c.Param("id")
 	adminEmail := c.GetString("adminEmail")
 
 	var attendance models.Attendance
-	if err := database.DB.First(&attendance, id).Error; err != nil {
+	if err := database.DB.Preload("Counts").First(&attendance, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
 		return
 	}
+	if attendance.LockedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": errAttendanceLocked.Error()})
+		return
+	}
 
 	var input struct {
-		ServiceType *string `json:"serviceType"`
-		Adults      *int    `json:"adults"`
-		Children    *int    `json:"children"`
-		Total       *int    `json:"total"`
-		FirstTimers *int    `json:"firstTimers"`
-		Visitors    *int    `json:"visitors"`
-		Members     *int    `json:"members"`
-		Notes       *string `json:"notes"`
+		ServiceTypeID *uint          `json:"serviceTypeId"`
+		ServiceType   *string        `json:"serviceType"`
+		Counts        map[string]int `json:"counts"`
+		Adults        *int           `json:"adults"`
+		Children      *int           `json:"children"`
+		FirstTimers   *int           `json:"firstTimers"`
+		Visitors      *int           `json:"visitors"`
+		Members       *int           `json:"members"`
+		Notes         *string        `json:"notes"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -99,38 +262,77 @@ func UpdateAttendance(c *gin.Context) {
 		return
 	}
 
-	if input.ServiceType != nil {
-		attendance.ServiceType = *input.ServiceType
-	}
-	if input.Adults != nil {
-		attendance.Adults = *input.Adults
-	}
-	if input.Children != nil {
-		attendance.Children = *input.Children
-	}
-	if input.Total != nil {
-		attendance.Total = *input.Total
-	}
-	if input.FirstTimers != nil {
-		attendance.FirstTimers = *input.FirstTimers
-	}
-	if input.Visitors != nil {
-		attendance.Visitors = *input.Visitors
-	}
-	if input.Members != nil {
-		attendance.Members = *input.Members
+	if input.ServiceTypeID != nil || input.ServiceType != nil {
+		var serviceTypeID uint
+		var name string
+		if input.ServiceTypeID != nil {
+			serviceTypeID = *input.ServiceTypeID
+		}
+		if input.ServiceType != nil {
+			name = *input.ServiceType
+		}
+		serviceType, err := resolveServiceType(database.DB, serviceTypeID, name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var clash int64
+		database.DB.Model(&models.Attendance{}).
+			Where("date = ? AND service_type_id = ? AND id <> ?", attendance.Date, serviceType.ID, attendance.ID).
+			Count(&clash)
+		if clash > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Another attendance record exists for this service and date"})
+			return
+		}
+
+		attendance.ServiceTypeID = serviceType.ID
+		attendance.ServiceType = serviceType.Name
 	}
 	if input.Notes != nil {
 		attendance.Notes = *input.Notes
 	}
 	attendance.RecordedBy = adminEmail
 
-	if err := database.DB.Save(&attendance).Error; err != nil {
+	categories, err := loadHeadcountCategories(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load headcount categories"})
+		return
+	}
+
+	legacy := models.LegacyCounts(input.Adults, input.Children, input.Members, input.Visitors, input.FirstTimers)
+	if len(legacy) > 0 || len(input.Counts) > 0 {
+		if hasCountSheets(database.DB, attendance.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": errAttendanceFromSheets.Error()})
+			return
+		}
+
+		counts := attendance.CountsByKey(categories)
+		for key, n := range legacy {
+			counts[key] = n
+		}
+		for key, n := range input.Counts {
+			counts[key] = n
+		}
+		if err := attendance.ApplyCounts(counts, categories); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return saveAttendance(tx, &attendance)
+	})
+	if database.IsUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Another attendance record exists for this service and date"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attendance record"})
 		return
 	}
 
-	middleware.LogActivity(c, "Updated attendance record", attendance.ServiceType+" on "+attendance.Date.Format("2006-01-02"))
+	middleware.LogActivity(c, "Updated attendance record", attendance.ServiceType+" on "+attendance.Date.String())
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Attendance record updated",
@@ -147,9 +349,42 @@ func DeleteAttendance(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
 		return
 	}
+	if attendance.LockedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": errAttendanceLocked.Error()})
+		return
+	}
 
 	database.DB.Delete(&attendance)
-	middleware.LogActivity(c, "Deleted attendance record", attendance.ServiceType+" on "+attendance.Date.Format("2006-01-02"))
+	middleware.LogActivity(c, "Deleted attendance record", attendance.ServiceType+" on "+attendance.Date.String())
 
 	c.JSON(http.StatusOK, gin.H{"message": "Attendance record deleted"})
 }
+
+// saveAttendance links the record to its service occurrence, writes it and replaces its per-category counts
+func saveAttendance(tx *gorm.DB, attendance *models.Attendance) error {
+	counts := attendance.Counts
+	occ, err := occurrence.Ensure(tx, attendance.Date, attendance.ServiceTypeID, attendance.ServiceType)
+	if err != nil {
+		return err
+	}
+	attendance.ServiceOccurrenceID = &occ.ID
+
+	if err := tx.Omit("Counts", "Sheets", "ServiceTypeRef").Save(attendance).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("attendance_id = ?", attendance.ID).Delete(&models.AttendanceCount{}).Error; err != nil {
+		return err
+	}
+	for i := range counts {
+		counts[i].ID = 0
+		counts[i].AttendanceID = attendance.ID
+	}
+	if len(counts) > 0 {
+		if err := tx.Create(&counts).Error; err != nil {
+			return err
+		}
+	}
+	attendance.Counts = counts
+	return nil
+}
@@ -0,0 +1,98 @@
+// internal/handlers/bulk.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Maximum number of items a single bulk request may touch
+const maxBulkItems = 500
+
+type bulkRequest struct {
+	IDs    []uint `json:"ids" binding:"required,min=1"`
+	Action string `json:"action" binding:"required"`
+	Status string `json:"status"`
+}
+
+type bulkItemResult struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// bulkChange is what applying a bulk action to one item produced, for the audit log
+type bulkChange struct {
+	Action  string
+	Details string
+}
+
+// bindBulkRequest parses and sanity-checks a bulk request body
+func bindBulkRequest(c *gin.Context) (*bulkRequest, bool) {
+	var input bulkRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if len(input.IDs) > maxBulkItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many items. Maximum is 500 per request"})
+		return nil, false
+	}
+	return &input, true
+}
+
+// runBulk applies fn to every item in ids inside one transaction and writes one
+// audit entry per changed item. Missing items are reported per item; any other
+// failure rolls back the whole batch.
+func runBulk[T any](c *gin.Context, ids []uint, fn func(tx *gorm.DB, item *T) (bulkChange, error)) {
+	results := make([]bulkItemResult, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	succeeded := 0
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			var item T
+			if err := tx.First(&item, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					results = append(results, bulkItemResult{ID: id, Error: "Not found"})
+					continue
+				}
+				return err
+			}
+
+			change, err := fn(tx, &item)
+			if err != nil {
+				return err
+			}
+			if err := middleware.LogActivityTx(tx, c, change.Action, change.Details); err != nil {
+				return err
+			}
+
+			results = append(results, bulkItemResult{ID: id, Success: true})
+			succeeded++
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Bulk action failed. No changes were made"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
@@ -0,0 +1,231 @@
+// internal/handlers/calendar.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/schedule"
+	"rccg-salvation-centre-backend/internal/slug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Longest range /api/calendar will expand
+const maxCalendarDays = 366
+
+// calendarItem is a special event or one occurrence of a regular program
+type calendarItem struct {
+	Kind        string     `json:"kind"` // "event" or "program"
+	ID          uint       `json:"id"`   // Special event or regular program id
+	Slug        string     `json:"slug,omitempty"`
+	Title       string     `json:"title"`
+	Type        string     `json:"type"`
+	Description string     `json:"description,omitempty"`
+	Location    string     `json:"location"`
+	Start       time.Time  `json:"start"`
+	End         *time.Time `json:"end,omitempty"`
+	AllDay      bool       `json:"allDay"`
+	Timezone    string     `json:"timezone"`
+
+	// Special events spread over several sessions
+	Sessions []models.SpecialEventSession `json:"sessions,omitempty"`
+
+	// Program occurrences changed by an exception
+	Cancelled     bool       `json:"cancelled"`
+	OriginalStart *time.Time `json:"originalStart,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+	Note          string     `json:"note,omitempty"`
+}
+
+// runningIn reports whether the item has not finished by from and starts before to
+func (item calendarItem) runningIn(from, to time.Time) bool {
+	if !item.Start.Before(to) {
+		return false
+	}
+	switch {
+	case item.End != nil:
+		return item.End.After(from)
+	case item.AllDay:
+		return item.Start.AddDate(0, 0, 1).After(from)
+	default:
+		return !item.Start.Before(from)
+	}
+}
+
+// calendarWindow reads the range to show:
+//
+//	?view=month&date=2025-03      the whole month
+//	?view=week&date=2025-03-12    Monday to Sunday of that week
+//	?from=2025-03-01&to=2025-03-31 (both inclusive; defaults to the next 30 days)
+func calendarWindow(c *gin.Context) (from, to time.Time, err error) {
+	loc := localtime.Location()
+	today := localtime.Today().Start()
+
+	parseDay := func(value string) (time.Time, error) {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return parsed, errors.New("Invalid date. Use YYYY-MM-DD")
+		}
+		return parsed, nil
+	}
+
+	switch c.Query("view") {
+	case "month":
+		day := today
+		if date := c.Query("date"); date != "" {
+			if day, err = time.ParseInLocation("2006-01", date, loc); err != nil {
+				if day, err = parseDay(date); err != nil {
+					return from, to, errors.New("Invalid date. Use YYYY-MM or YYYY-MM-DD")
+				}
+			}
+		}
+		from = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, loc)
+		return from, from.AddDate(0, 1, 0), nil
+	case "week":
+		day := today
+		if date := c.Query("date"); date != "" {
+			if day, err = parseDay(date); err != nil {
+				return from, to, err
+			}
+		}
+		from = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return from, from.AddDate(0, 0, 7), nil
+	case "":
+	default:
+		return from, to, errors.New("view must be 'month' or 'week'")
+	}
+
+	from = today
+	if value := c.Query("from"); value != "" {
+		if from, err = parseDay(value); err != nil {
+			return from, to, err
+		}
+	}
+	to = from.AddDate(0, 0, 30)
+	if value := c.Query("to"); value != "" {
+		if to, err = parseDay(value); err != nil {
+			return from, to, err
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		return from, to, errors.New("'to' must not be before 'from'")
+	}
+	if to.Sub(from) > maxCalendarDays*24*time.Hour {
+		return from, to, errors.New("The range cannot be longer than 366 days")
+	}
+	return from, to, nil
+}
+
+// Public: Special events and regular program occurrences in one chronological list
+// GET /api/calendar?from=&to=&type=&location= or ?view=month|week&date=
+func GetCalendar(c *gin.Context) {
+	from, to, err := calendarWindow(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	typeKey := slug.Make(c.Query("type"))
+	location := strings.ToLower(strings.TrimSpace(c.Query("location")))
+
+	matches := func(item calendarItem) bool {
+		if typeKey != "" && slug.Make(item.Type) != typeKey {
+			return false
+		}
+		if location != "" && !strings.Contains(strings.ToLower(item.Location), location) {
+			return false
+		}
+		return item.runningIn(from, to)
+	}
+
+	// Multi-day events that began before the range are included; a day either side
+	// covers events running past midnight
+	var events []models.SpecialEvent
+	firstDay := localtime.DateOf(from).AddDays(-1)
+	lastDay := localtime.DateOf(to)
+	if err := database.DB.Preload("Sessions", orderSessions).
+		Scopes(models.Visible(time.Now())).Where("date <= ? AND end_date >= ?", lastDay, firstDay).
+		Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load events"})
+		return
+	}
+	var programs []models.RegularProgram
+	if err := database.DB.Preload("Exceptions").
+		Where("active = ? AND rrule <> '' AND recurrence_needs_review = ?", true, false).
+		Find(&programs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load programs"})
+		return
+	}
+
+	items := []calendarItem{}
+	for _, e := range events {
+		start, end, allDay := specialEventTimes(e)
+		item := calendarItem{
+			Kind:        "event",
+			ID:          e.ID,
+			Slug:        e.Slug,
+			Title:       e.Title,
+			Type:        e.Type,
+			Description: e.Description,
+			Location:    e.Location,
+			Start:       start,
+			AllDay:      allDay,
+			Timezone:    start.Location().String(),
+			Sessions:    e.Sessions,
+		}
+		if !end.IsZero() {
+			item.End = &end
+		}
+		if matches(item) {
+			items = append(items, item)
+		}
+	}
+	for _, p := range programs {
+		occurrences, err := schedule.Program(p, from, to, 0)
+		if err != nil {
+			continue
+		}
+		for _, occ := range occurrences {
+			item := calendarItem{
+				Kind:          "program",
+				ID:            p.ID,
+				Title:         occ.Title,
+				Type:          occ.Type,
+				Description:   p.Description,
+				Location:      occ.Location,
+				Start:         occ.Start,
+				End:           occ.End,
+				AllDay:        occ.AllDay,
+				Timezone:      occ.Timezone,
+				Cancelled:     occ.Cancelled,
+				OriginalStart: occ.OriginalStart,
+				Reason:        occ.Reason,
+				Note:          occ.Note,
+			}
+			if matches(item) {
+				items = append(items, item)
+			}
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].Start.Equal(items[j].Start) {
+			return items[i].Start.Before(items[j].Start)
+		}
+		return items[i].Title < items[j].Title
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":  from.Format("2006-01-02"),
+		"to":    to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"data":  items,
+		"count": len(items),
+	})
+}
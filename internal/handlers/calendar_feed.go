@@ -0,0 +1,239 @@
+// internal/handlers/calendar_feed.go
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/ical"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/schedule"
+	"rccg-salvation-centre-backend/internal/slug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Feeds include special events from this many days back so recent ones stay in calendars
+const feedHistoryDays = 365
+
+// specialEventTimes is when an event runs. An event without a start time is all-day
+// from its first to its last day; a one-day event with no end time has no end.
+func specialEventTimes(e models.SpecialEvent) (start, end time.Time, allDay bool) {
+	start, end = e.Span()
+	start, end = start.In(localtime.Location()), end.In(localtime.Location())
+	if e.StartsAt != nil && e.EndsAt == nil && e.LastDay() == e.Date {
+		end = time.Time{}
+	}
+	return start, end, e.StartsAt == nil
+}
+
+func specialEventCalendarEvent(e models.SpecialEvent) ical.Event {
+	start, end, allDay := specialEventTimes(e)
+	return ical.Event{
+		UID:         ical.UID("event", e.ID),
+		Summary:     e.Title,
+		Description: e.Description,
+		Location:    e.Location,
+		Start:       start,
+		End:         end,
+		AllDay:      allDay,
+		Modified:    e.UpdatedAt,
+	}
+}
+
+// programCalendarEvents turns a program into a recurring event, plus one event for
+// each moved, relocated or annotated occurrence. Cancelled occurrences are excluded
+// from the series. Programs without a usable schedule produce nothing.
+func programCalendarEvents(p models.RegularProgram) []ical.Event {
+	if p.RecurrenceNeedsReview {
+		return nil
+	}
+	s, err := schedule.ForProgram(p)
+	if err != nil {
+		return nil
+	}
+	// DTSTART must be a real occurrence, and the series start may not be one
+	first := s.Between(s.Start, s.Start.AddDate(2, 0, 0), 1)
+	if len(first) == 0 {
+		return nil
+	}
+
+	modified := p.UpdatedAt
+	for _, e := range p.Exceptions {
+		if e.UpdatedAt.After(modified) {
+			modified = e.UpdatedAt
+		}
+	}
+
+	series := ical.Event{
+		UID:         ical.UID("program", p.ID),
+		Summary:     p.Title,
+		Description: p.Description,
+		Location:    p.Location,
+		Start:       first[0],
+		AllDay:      s.AllDay,
+		RRule:       s.Rule.String(),
+		Modified:    modified,
+	}
+	if s.Duration > 0 {
+		series.End = first[0].Add(s.Duration)
+	}
+	if !s.Until.IsZero() {
+		series.Until = s.Until.Add(-time.Second)
+	}
+
+	var changed []ical.Event
+	for _, e := range p.Exceptions {
+		original, ok := s.Occurs(e.Date)
+		if !ok {
+			continue
+		}
+		if e.Cancelled {
+			series.ExDates = append(series.ExDates, original)
+			continue
+		}
+
+		instance := ical.Event{
+			UID:          series.UID,
+			Summary:      p.Title,
+			Location:     p.Location,
+			Start:        original,
+			AllDay:       s.AllDay,
+			RecurrenceID: &original,
+			Modified:     modified,
+		}
+		duration := s.Duration
+		if e.Rescheduled() {
+			start, d, err := s.Moved(e, original)
+			if err != nil {
+				continue
+			}
+			instance.Start, duration = start, d
+			instance.AllDay = s.AllDay && e.NewStartTime == ""
+		}
+		if duration > 0 {
+			instance.End = instance.Start.Add(duration)
+		}
+		if e.Location != "" {
+			instance.Location = e.Location
+		}
+		var description []string
+		for _, text := range []string{e.Reason, e.Note, p.Description} {
+			if text != "" {
+				description = append(description, text)
+			}
+		}
+		instance.Description = strings.Join(description, "\n\n")
+		changed = append(changed, instance)
+	}
+
+	return append([]ical.Event{series}, changed...)
+}
+
+// writeCalendar sends a calendar with an ETag so unchanged feeds cost subscribers nothing.
+// A filename makes it a download rather than a subscription.
+func writeCalendar(c *gin.Context, cal ical.Calendar, filename string) {
+	body := cal.Bytes()
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=300")
+	if filename != "" {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	}
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, ical.ContentType, body)
+}
+
+// calendarFeed builds a feed of published special events and active programs,
+// optionally limited to one type (compared by slug, so "youth-service" matches "Youth Service")
+func calendarFeed(c *gin.Context, typeKey string) {
+	var events []models.SpecialEvent
+	if err := database.DB.Scopes(models.Visible(time.Now())).Where("end_date >= ?", localtime.Today().AddDays(-feedHistoryDays)).
+		Order("date ASC").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load events"})
+		return
+	}
+	var programs []models.RegularProgram
+	if err := database.DB.Preload("Exceptions").Where("active = ? AND rrule <> ''", true).
+		Order("id ASC").Find(&programs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load programs"})
+		return
+	}
+
+	cal := ical.Calendar{Name: "RCCG Salvation Centre"}
+	typeName := ""
+	for _, e := range events {
+		if typeKey == "" || slug.Make(e.Type) == typeKey {
+			cal.Events = append(cal.Events, specialEventCalendarEvent(e))
+			typeName = e.Type
+		}
+	}
+	for _, p := range programs {
+		if typeKey == "" || slug.Make(p.Type) == typeKey {
+			cal.Events = append(cal.Events, programCalendarEvents(p)...)
+			typeName = p.Type
+		}
+	}
+	if typeKey != "" {
+		// An empty feed keeps existing subscriptions alive until the type is used again
+		if typeName == "" {
+			typeName = typeKey
+		}
+		cal.Name += " - " + typeName
+	}
+	writeCalendar(c, cal, "")
+}
+
+// Public: iCalendar feed of every published event and active program
+// GET /api/feeds/church.ics
+func GetChurchCalendarFeed(c *gin.Context) {
+	calendarFeed(c, "")
+}
+
+// Public: iCalendar feed for one event or program type
+// GET /api/feeds/types/:type (e.g. /api/feeds/types/youth-service.ics)
+func GetTypeCalendarFeed(c *gin.Context) {
+	typeKey := slug.Make(strings.TrimSuffix(c.Param("type"), ".ics"))
+	if typeKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown calendar"})
+		return
+	}
+	calendarFeed(c, typeKey)
+}
+
+// Public: Download one special event as .ics
+// GET /api/special-events/:slug/calendar.ics
+func GetSpecialEventICS(c *gin.Context) {
+	var event models.SpecialEvent
+	if err := database.DB.Scopes(models.Visible(time.Now())).Where("slug = ?", c.Param("slug")).First(&event).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	writeCalendar(c, ical.Calendar{Events: []ical.Event{specialEventCalendarEvent(event)}}, event.Slug+".ics")
+}
+
+// Public: Download one regular program's schedule as .ics
+// GET /api/regular-programs/:id/calendar.ics
+func GetRegularProgramICS(c *gin.Context) {
+	var program models.RegularProgram
+	if err := database.DB.Preload("Exceptions").Where("active = ?", true).First(&program, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+	events := programCalendarEvents(program)
+	if len(events) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This program has no published schedule"})
+		return
+	}
+	writeCalendar(c, ical.Calendar{Events: events}, slug.Make(program.Title)+".ics")
+}
@@ -0,0 +1,577 @@
+// internal/handlers/count_sheet.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/analytics"
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// How many earlier services a section's count is compared against
+const outlierHistory = 12
+
+var (
+	errUnknownSection       = errors.New("Unknown or inactive attendance section")
+	errAttendanceEnteredAll = errors.New("Attendance for this service was entered as a single record. Delete it before submitting count sheets")
+	errCountSheetExists     = errors.New("A count sheet for this section has already been submitted")
+)
+
+// countSheetInput is one usher's count of one section, from the API or an offline device
+type countSheetInput struct {
+	Date          string         `json:"date" binding:"required"` // YYYY-MM-DD
+	ServiceTypeID uint           `json:"serviceTypeId"`
+	ServiceType   string         `json:"serviceType"`
+	SectionID     uint           `json:"sectionId" binding:"required"`
+	CountedBy     string         `json:"countedBy"` // Defaults to the submitting admin
+	CountedAt     *time.Time     `json:"countedAt"` // Defaults to now
+	Counts        map[string]int `json:"counts" binding:"required"`
+	Notes         string         `json:"notes"`
+}
+
+// sheetOutlier flags a section whose count is far from its usual figure for the service
+type sheetOutlier struct {
+	SheetID uint   `json:"sheetId"`
+	Section string `json:"section"`
+	analytics.OutlierCheck
+}
+
+// reconciliation is what the secretariat reviews before signing off a service
+type reconciliation struct {
+	Attendance      models.Attendance          `json:"attendance"`
+	Sheets          []models.CountSheet        `json:"sheets"`
+	MissingSections []models.AttendanceSection `json:"missingSections"`
+	Unreconciled    []string                   `json:"unreconciled"`
+	Outliers        []sheetOutlier             `json:"outliers"`
+	TotalCheck      *analytics.OutlierCheck    `json:"totalCheck"` // The service total against earlier services of the same type
+	ReadyToSignOff  bool                       `json:"readyToSignOff"`
+}
+
+func (r reconciliation) issues() []string {
+	var issues []string
+	for _, s := range r.MissingSections {
+		issues = append(issues, "missing count sheet for "+s.Name)
+	}
+	issues = append(issues, r.Unreconciled...)
+	for _, o := range r.Outliers {
+		issues = append(issues, fmt.Sprintf("%s count of %d is unusual (typically %.0f)", o.Section, o.Value, o.Mean))
+	}
+	if r.TotalCheck != nil && r.TotalCheck.Outlier {
+		issues = append(issues, fmt.Sprintf("service total of %d is unusual (typically %.0f)", r.TotalCheck.Value, r.TotalCheck.Mean))
+	}
+	return issues
+}
+
+// submitCountSheet records a sheet, creating the service's attendance record if needed,
+// and rolls every sheet for the service up into the record. A second sheet for the same
+// section replaces the first when allowReplace is set. Reports whether a new sheet was created.
+func submitCountSheet(tx *gorm.DB, input countSheetInput, submittedBy string, allowReplace bool) (models.CountSheet, models.Attendance, bool, error) {
+	var sheet models.CountSheet
+	var attendance models.Attendance
+
+	date, err := localtime.ParseDate(input.Date)
+	if err != nil {
+		return sheet, attendance, false, fmt.Errorf("%w: invalid date format. Use YYYY-MM-DD", models.ErrInvalidAttendance)
+	}
+	serviceType, err := resolveServiceType(tx, input.ServiceTypeID, input.ServiceType)
+	if err != nil {
+		return sheet, attendance, false, fmt.Errorf("%w: %s", models.ErrInvalidAttendance, err.Error())
+	}
+
+	var section models.AttendanceSection
+	if err := tx.Where("active = ?", true).First(&section, input.SectionID).Error; err != nil {
+		return sheet, attendance, false, errUnknownSection
+	}
+
+	categories, err := loadHeadcountCategories(tx)
+	if err != nil {
+		return sheet, attendance, false, err
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("date = ? AND service_type_id = ?", date, serviceType.ID).
+		First(&attendance).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		attendance = models.Attendance{
+			Date:          date,
+			ServiceTypeID: serviceType.ID,
+			ServiceType:   serviceType.Name,
+			RecordedBy:    submittedBy,
+		}
+		if err := saveAttendance(tx, &attendance); err != nil {
+			return sheet, attendance, false, err
+		}
+	case err != nil:
+		return sheet, attendance, false, err
+	case attendance.LockedAt != nil:
+		return sheet, attendance, false, errAttendanceLocked
+	case attendance.Total > 0 && !hasCountSheets(tx, attendance.ID):
+		return sheet, attendance, false, errAttendanceEnteredAll
+	}
+
+	created := false
+	err = tx.Where("attendance_id = ? AND section_id = ?", attendance.ID, section.ID).First(&sheet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sheet = models.CountSheet{AttendanceID: attendance.ID, SectionID: section.ID}
+		created = true
+	} else if err != nil {
+		return sheet, attendance, false, err
+	} else if !allowReplace {
+		return sheet, attendance, false, errCountSheetExists
+	}
+
+	sheet.CountedBy = strings.TrimSpace(input.CountedBy)
+	if sheet.CountedBy == "" {
+		sheet.CountedBy = submittedBy
+	}
+	sheet.CountedAt = time.Now()
+	if input.CountedAt != nil {
+		sheet.CountedAt = *input.CountedAt
+	}
+	sheet.SubmittedBy = submittedBy
+	sheet.Notes = input.Notes
+	if err := sheet.ApplyCounts(input.Counts, categories); err != nil {
+		return sheet, attendance, false, err
+	}
+
+	counts := sheet.Counts
+	if err := tx.Omit("Counts", "Section").Save(&sheet).Error; err != nil {
+		return sheet, attendance, false, err
+	}
+	if err := tx.Where("count_sheet_id = ?", sheet.ID).Delete(&models.CountSheetCount{}).Error; err != nil {
+		return sheet, attendance, false, err
+	}
+	for i := range counts {
+		counts[i].CountSheetID = sheet.ID
+	}
+	if len(counts) > 0 {
+		if err := tx.Create(&counts).Error; err != nil {
+			return sheet, attendance, false, err
+		}
+	}
+	sheet.Counts = counts
+	sheet.Section = section
+
+	attendance.RecordedBy = submittedBy
+	if err := rollUpCountSheets(tx, &attendance, categories); err != nil {
+		return sheet, attendance, false, err
+	}
+	return sheet, attendance, created, nil
+}
+
+// rollUpCountSheets sets the record's counts to the sum of its sheets
+func rollUpCountSheets(tx *gorm.DB, attendance *models.Attendance, categories []models.HeadcountCategory) error {
+	var sheets []models.CountSheet
+	if err := tx.Preload("Counts").Where("attendance_id = ?", attendance.ID).Find(&sheets).Error; err != nil {
+		return err
+	}
+
+	sum := make(map[string]int)
+	for i := range sheets {
+		for key, n := range sheets[i].CountsByKey(categories) {
+			sum[key] += n
+		}
+	}
+	if err := attendance.SetCounts(sum, categories); err != nil {
+		return err
+	}
+	return saveAttendance(tx, attendance)
+}
+
+func hasCountSheets(db *gorm.DB, attendanceID uint) bool {
+	var n int64
+	db.Model(&models.CountSheet{}).Where("attendance_id = ?", attendanceID).Count(&n)
+	return n > 0
+}
+
+// buildReconciliation checks a service's sheets for missing sections, groups that do
+// not add up and counts far outside what the section usually sees for this service type
+func buildReconciliation(db *gorm.DB, attendance models.Attendance) (reconciliation, error) {
+	r := reconciliation{
+		Attendance:      attendance,
+		Sheets:          []models.CountSheet{},
+		MissingSections: []models.AttendanceSection{},
+		Unreconciled:    []string{},
+		Outliers:        []sheetOutlier{},
+	}
+
+	categories, err := loadHeadcountCategories(db)
+	if err != nil {
+		return r, err
+	}
+	if err := db.Preload("Section").Preload("Counts").
+		Joins("JOIN attendance_sections ON attendance_sections.id = count_sheets.section_id").
+		Where("count_sheets.attendance_id = ?", attendance.ID).
+		Order("attendance_sections.display_order ASC, count_sheets.id ASC").
+		Find(&r.Sheets).Error; err != nil {
+		return r, err
+	}
+
+	if len(r.Sheets) > 0 {
+		counted := make(map[uint]bool, len(r.Sheets))
+		for _, s := range r.Sheets {
+			counted[s.SectionID] = true
+		}
+		var expected []models.AttendanceSection
+		if err := db.Where("expected = ? AND active = ?", true, true).Order("display_order ASC, id ASC").Find(&expected).Error; err != nil {
+			return r, err
+		}
+		for _, s := range expected {
+			if !counted[s.ID] {
+				r.MissingSections = append(r.MissingSections, s)
+			}
+		}
+	}
+
+	if problems := models.UnreconciledGroups(attendance.CountsByKey(categories), attendance.Total, categories); len(problems) > 0 {
+		r.Unreconciled = problems
+	}
+
+	for _, s := range r.Sheets {
+		var history []int
+		if err := db.Model(&models.CountSheet{}).
+			Joins("JOIN attendances ON attendances.id = count_sheets.attendance_id").
+			Where("count_sheets.section_id = ? AND attendances.service_type_id = ? AND attendances.date < ?",
+				s.SectionID, attendance.ServiceTypeID, attendance.Date).
+			Order("attendances.date DESC").
+			Limit(outlierHistory).
+			Pluck("count_sheets.total", &history).Error; err != nil {
+			return r, err
+		}
+		if check := analytics.CheckOutlier(history, s.Total); check != nil && check.Outlier {
+			r.Outliers = append(r.Outliers, sheetOutlier{SheetID: s.ID, Section: s.Section.Name, OutlierCheck: *check})
+		}
+	}
+
+	var totals []int
+	if err := db.Model(&models.Attendance{}).
+		Where("service_type_id = ? AND date < ?", attendance.ServiceTypeID, attendance.Date).
+		Order("date DESC").
+		Limit(outlierHistory).
+		Pluck("total", &totals).Error; err != nil {
+		return r, err
+	}
+	r.TotalCheck = analytics.CheckOutlier(totals, attendance.Total)
+
+	r.ReadyToSignOff = len(r.issues()) == 0
+	return r, nil
+}
+
+// Admin: Submit a count sheet for one section of a service
+// POST /api/admin/attendance/count-sheets
+func SubmitCountSheet(c *gin.Context) {
+	var input countSheetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var sheet models.CountSheet
+	var attendance models.Attendance
+	var created bool
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		sheet, attendance, created, err = submitCountSheet(tx, input, c.GetString("adminEmail"), true)
+		return err
+	})
+	if err != nil {
+		respondCountSheetError(c, err)
+		return
+	}
+
+	status, message := http.StatusOK, "Count sheet replaced"
+	if created {
+		status, message = http.StatusCreated, "Count sheet submitted"
+	}
+	middleware.LogActivity(c, "Submitted count sheet",
+		fmt.Sprintf("%s, %s on %s: %d counted by %s", sheet.Section.Name, attendance.ServiceType, input.Date, sheet.Total, sheet.CountedBy))
+
+	c.JSON(status, gin.H{
+		"message":    message,
+		"sheet":      sheet,
+		"attendance": attendance,
+	})
+}
+
+func respondCountSheetError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidAttendance), errors.Is(err, errUnknownSection):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errAttendanceLocked), errors.Is(err, errAttendanceEnteredAll):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case database.IsUniqueViolation(err):
+		c.JSON(http.StatusConflict, gin.H{"error": "Another count sheet for this service was saved at the same time. Please submit again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save count sheet"})
+	}
+}
+
+// Admin: Count sheets and reconciliation checks for a service
+// GET /api/admin/attendance/:id/count-sheets
+func AdminGetCountSheets(c *gin.Context) {
+	var attendance models.Attendance
+	if err := database.DB.Preload("Counts").First(&attendance, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+
+	r, err := buildReconciliation(database.DB, attendance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load count sheets"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": r})
+}
+
+// Admin: Delete a count sheet and roll the remaining sheets up again
+func DeleteCountSheet(c *gin.Context) {
+	var sheet models.CountSheet
+	if err := database.DB.Preload("Section").First(&sheet, c.Param("sheetId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Count sheet not found"})
+		return
+	}
+
+	var attendance models.Attendance
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&attendance, sheet.AttendanceID).Error; err != nil {
+			return err
+		}
+		if attendance.LockedAt != nil {
+			return errAttendanceLocked
+		}
+		if err := tx.Delete(&sheet).Error; err != nil {
+			return err
+		}
+		categories, err := loadHeadcountCategories(tx)
+		if err != nil {
+			return err
+		}
+		return rollUpCountSheets(tx, &attendance, categories)
+	})
+	if err != nil {
+		respondCountSheetError(c, err)
+		return
+	}
+
+	middleware.LogActivity(c, "Deleted count sheet",
+		fmt.Sprintf("%s, %s on %s", sheet.Section.Name, attendance.ServiceType, attendance.Date.String()))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Count sheet deleted",
+		"attendance": attendance,
+	})
+}
+
+// Admin: Sign off a service's final attendance, locking it against further edits.
+// Outstanding reconciliation issues must be acknowledged explicitly.
+func SignOffAttendance(c *gin.Context) {
+	var input struct {
+		AcknowledgeIssues bool   `json:"acknowledgeIssues"`
+		Note              string `json:"note"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var attendance models.Attendance
+	if err := database.DB.Preload("Counts").First(&attendance, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+	if attendance.LockedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attendance record is already signed off"})
+		return
+	}
+
+	r, err := buildReconciliation(database.DB, attendance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check count sheets"})
+		return
+	}
+	issues := r.issues()
+	if len(issues) > 0 && !input.AcknowledgeIssues {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":          "Attendance has unresolved issues. Resend with acknowledgeIssues to sign off anyway",
+			"reconciliation": r,
+		})
+		return
+	}
+
+	now := time.Now()
+	adminEmail := c.GetString("adminEmail")
+	result := database.DB.Model(&models.Attendance{}).
+		Where("id = ? AND locked_at IS NULL", attendance.ID).
+		Updates(map[string]interface{}{"locked_at": now, "locked_by": adminEmail})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign off attendance"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Attendance record is already signed off"})
+		return
+	}
+	attendance.LockedAt = &now
+	attendance.LockedBy = adminEmail
+
+	details := fmt.Sprintf("%s on %s: total %d", attendance.ServiceType, attendance.Date.String(), attendance.Total)
+	if len(issues) > 0 {
+		details += "; acknowledged: " + strings.Join(issues, "; ")
+	}
+	if input.Note != "" {
+		details += "; note: " + input.Note
+	}
+	middleware.LogActivity(c, "Signed off attendance", details)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Attendance signed off",
+		"attendance": attendance,
+	})
+}
+
+// Admin: Unlock a signed-off attendance record (superadmin only)
+func UnlockAttendance(c *gin.Context) {
+	var attendance models.Attendance
+	if err := database.DB.First(&attendance, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+	if attendance.LockedAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attendance record is not signed off"})
+		return
+	}
+
+	database.DB.Model(&attendance).Updates(map[string]interface{}{"locked_at": nil, "locked_by": ""})
+	middleware.LogActivity(c, "Unlocked attendance", attendance.ServiceType+" on "+attendance.Date.String())
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Attendance unlocked",
+		"attendance": attendance,
+	})
+}
+
+// Admin: List attendance sections
+func AdminGetAttendanceSections(c *gin.Context) {
+	var sections []models.AttendanceSection
+	database.DB.Order("display_order ASC, id ASC").Find(&sections)
+	c.JSON(http.StatusOK, gin.H{"data": sections})
+}
+
+// Admin: Create attendance section
+func CreateAttendanceSection(c *gin.Context) {
+	var input struct {
+		Name         string `json:"name" binding:"required"`
+		Expected     *bool  `json:"expected"`
+		DisplayOrder int    `json:"displayOrder"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	section := models.AttendanceSection{
+		Name:         strings.TrimSpace(input.Name),
+		Expected:     input.Expected == nil || *input.Expected,
+		DisplayOrder: input.DisplayOrder,
+		Active:       true,
+	}
+
+	var existing int64
+	database.DB.Model(&models.AttendanceSection{}).Where("LOWER(name) = ?", strings.ToLower(section.Name)).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An attendance section with this name already exists"})
+		return
+	}
+
+	if err := database.DB.Create(&section).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attendance section"})
+		return
+	}
+
+	middleware.LogActivity(c, "Created attendance section", section.Name)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Attendance section created",
+		"section": section,
+	})
+}
+
+// Admin: Update attendance section
+func UpdateAttendanceSection(c *gin.Context) {
+	var section models.AttendanceSection
+	if err := database.DB.First(&section, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance section not found"})
+		return
+	}
+
+	var input struct {
+		Name         *string `json:"name"`
+		Expected     *bool   `json:"expected"`
+		DisplayOrder *int    `json:"displayOrder"`
+		Active       *bool   `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Name != nil && strings.TrimSpace(*input.Name) != "" {
+		section.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Expected != nil {
+		section.Expected = *input.Expected
+	}
+	if input.DisplayOrder != nil {
+		section.DisplayOrder = *input.DisplayOrder
+	}
+	if input.Active != nil {
+		section.Active = *input.Active
+	}
+
+	if err := database.DB.Save(&section).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attendance section"})
+		return
+	}
+
+	middleware.LogActivity(c, "Updated attendance section", section.Name)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Attendance section updated",
+		"section": section,
+	})
+}
+
+// Admin: Delete attendance section. Sections with count sheets can only be deactivated.
+func DeleteAttendanceSection(c *gin.Context) {
+	var section models.AttendanceSection
+	if err := database.DB.First(&section, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance section not found"})
+		return
+	}
+
+	var used int64
+	database.DB.Model(&models.CountSheet{}).Where("section_id = ?", section.ID).Count(&used)
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This section has count sheets. Deactivate it instead"})
+		return
+	}
+
+	database.DB.Delete(&section)
+	middleware.LogActivity(c, "Deleted attendance section", section.Name)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attendance section deleted"})
+}
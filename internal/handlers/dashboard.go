@@ -3,10 +3,12 @@ package handlers
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
 	"rccg-salvation-centre-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -14,10 +16,10 @@ import (
 
 // Admin: Get dashboard stats and visualization data
 func AdminGetDashboard(c *gin.Context) {
-	now := time.Now()
-	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-	weekStart := todayStart.AddDate(0, 0, -7)
-	monthStart := todayStart.AddDate(0, -1, 0)
+	// Days are counted in the church's timezone, not the server's
+	today := localtime.Today()
+	weekStart := today.AddDays(-7)
+	monthStart := localtime.Date{Time: today.AddDate(0, -1, 0)}
 
 	// 1. Total Sermons
 	var totalSermons int64
@@ -30,28 +32,36 @@ func AdminGetDashboard(c *gin.Context) {
 	// 3. Today's First-Timers
 	var todaysFirstTimers int64
 	database.DB.Model(&models.FirstTimer{}).
-		Where("DATE(visit_date) = DATE(?)", todayStart).
+		Where("visit_date = ?", today).
 		Count(&todaysFirstTimers)
 
-	// 4. Upcoming Special Events (next 14 days, published)
-	var upcomingEvents []struct {
-		ID    uint      `json:"id"`
-		Title string    `json:"title"`
-		Date  time.Time `json:"date"`
+	// 4. Ongoing and upcoming special events (published), soonest first
+	type UpcomingEvent struct {
+		ID      uint           `json:"id"`
+		Title   string         `json:"title"`
+		Date    localtime.Date `json:"date"`
+		EndDate localtime.Date `json:"endDate"`
+		Status  string         `json:"status"`
 	}
-	database.DB.Model(&models.SpecialEvent{}).
-		Select("id, title, date").
-		Where("published = ? AND date >= ?", true, todayStart).
-		Order("date ASC").
+	// Events that finished earlier today are left out here so they don't take a place
+	now := time.Now()
+	var events []models.SpecialEvent
+	database.DB.Scopes(models.Visible(now)).Where("end_date >= ?", today).
+		Where("ends_at IS NULL OR ends_at > ?", now).
+		Order("date ASC, starts_at ASC").
 		Limit(6).
-		Find(&upcomingEvents)
+		Find(&events)
+	upcomingEvents := []UpcomingEvent{}
+	for _, e := range events {
+		upcomingEvents = append(upcomingEvents, UpcomingEvent{e.ID, e.Title, e.Date, e.LastDay(), e.Status})
+	}
 
 	// 5. Recent Attendance for visualization (last 30 days)
 	type AttendanceStat struct {
-		Date     time.Time `json:"date"`
-		Total    int       `json:"total"`
-		Adults   int       `json:"adults"`
-		Children int       `json:"children"`
+		Date     localtime.Date `json:"date"`
+		Total    int            `json:"total"`
+		Adults   int            `json:"adults"`
+		Children int            `json:"children"`
 	}
 	var attendanceStats []AttendanceStat
 	database.DB.Model(&models.Attendance{}).
@@ -70,12 +80,15 @@ func AdminGetDashboard(c *gin.Context) {
 	var lastWeekTotal int64
 	database.DB.Model(&models.Attendance{}).
 		Select("COALESCE(SUM(total), 0)").
-		Where("date >= ? AND date < ?", weekStart.AddDate(0, 0, -7), weekStart).
+		Where("date >= ? AND date < ?", weekStart.AddDays(-7), weekStart).
 		Scan(&lastWeekTotal)
 
 	trend := "No change"
+	var trendPercent *float64
 	if lastWeekTotal > 0 {
 		diff := float64(thisWeekTotal-lastWeekTotal) / float64(lastWeekTotal) * 100
+		rounded := math.Round(diff*100) / 100
+		trendPercent = &rounded
 		if diff > 0 {
 			trend = "+" + fmt.Sprintf("%.0f", diff) + "% from last week"
 		} else if diff < 0 {
@@ -91,6 +104,7 @@ func AdminGetDashboard(c *gin.Context) {
 			"upcomingEvents":     upcomingEvents,
 			"attendanceStats":    attendanceStats,
 			"attendanceTrend":    trend,
+			"attendanceGrowth":   trendPercent, // Week-over-week change in percent; null when last week had no data
 		},
 	})
 }
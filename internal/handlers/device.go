@@ -0,0 +1,105 @@
+// internal/handlers/device.go
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/auth"
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin: List registered sync devices
+func AdminGetDevices(c *gin.Context) {
+	var devices []models.Device
+	database.DB.Order("created_at DESC").Find(&devices)
+	c.JSON(http.StatusOK, gin.H{"data": devices})
+}
+
+// Admin: Register a sync device. The key is returned once and cannot be retrieved later.
+func RegisterDevice(c *gin.Context) {
+	var input struct {
+		Name    string `json:"name" binding:"required"`
+		Purpose string `json:"purpose" binding:"required"` // usher or welcome_desk
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Purpose != models.DevicePurposeUsher && input.Purpose != models.DevicePurposeWelcomeDesk {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purpose. Use 'usher' or 'welcome_desk'"})
+		return
+	}
+
+	key, hash, err := auth.GenerateDeviceKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate device key"})
+		return
+	}
+
+	device := models.Device{
+		Name:      strings.TrimSpace(input.Name),
+		Purpose:   input.Purpose,
+		KeyPrefix: key[:len(auth.DeviceKeyPrefix)+8],
+		KeyHash:   hash,
+		CreatedBy: c.GetString("adminEmail"),
+	}
+	if err := database.DB.Create(&device).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register device"})
+		return
+	}
+
+	middleware.LogActivity(c, "Registered sync device", device.Name+" ("+device.Purpose+")")
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Device registered. Store the key on the device now; it will not be shown again",
+		"device":  device,
+		"key":     key,
+	})
+}
+
+// Admin: Revoke a sync device's key
+func RevokeDevice(c *gin.Context) {
+	var device models.Device
+	if err := database.DB.First(&device, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+	if device.RevokedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Device is already revoked"})
+		return
+	}
+
+	now := time.Now()
+	device.RevokedAt = &now
+	database.DB.Model(&device).Update("revoked_at", now)
+	middleware.LogActivity(c, "Revoked sync device", device.Name)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device revoked",
+		"device":  device,
+	})
+}
+
+// Admin: Operations a device has synced (?status=applied|conflict|rejected)
+func AdminGetDeviceOperations(c *gin.Context) {
+	var device models.Device
+	if err := database.DB.First(&device, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		return
+	}
+
+	db := database.DB.Where("device_id = ?", device.ID)
+	if status := c.Query("status"); status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var operations []models.SyncOperation
+	db.Order("created_at DESC").Limit(500).Find(&operations)
+	c.JSON(http.StatusOK, gin.H{"data": operations})
+}
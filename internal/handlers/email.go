@@ -0,0 +1,187 @@
+// internal/handlers/email.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/email"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// emailTemplateView is a template as admins edit it, with what it can use
+type emailTemplateView struct {
+	Type        string     `json:"type"`
+	Description string     `json:"description"`
+	Fields      []string   `json:"fields"` // Usable as {{.Field}}
+	Subject     string     `json:"subject"`
+	HTMLBody    string     `json:"htmlBody"`
+	TextBody    string     `json:"textBody"`
+	Customized  bool       `json:"customized"` // False while the built-in wording is used
+	UpdatedBy   string     `json:"updatedBy,omitempty"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+func viewEmailTemplate(t models.EmailTemplate) emailTemplateView {
+	d, _ := email.DefaultFor(t.Type)
+	fields := make([]string, 0, len(d.Fields))
+	for f := range d.Fields {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	view := emailTemplateView{
+		Type:        t.Type,
+		Description: d.Description,
+		Fields:      fields,
+		Subject:     t.Subject,
+		HTMLBody:    t.HTMLBody,
+		TextBody:    t.TextBody,
+		Customized:  t.ID != 0,
+		UpdatedBy:   t.UpdatedBy,
+	}
+	if t.ID != 0 {
+		view.UpdatedAt = &t.UpdatedAt
+	}
+	return view
+}
+
+// Admin: List email templates (superadmin only)
+// GET /api/admin/email-templates
+func AdminGetEmailTemplates(c *gin.Context) {
+	views := []emailTemplateView{}
+	for _, emailType := range email.Types() {
+		t, err := email.Template(database.DB, emailType)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load templates"})
+			return
+		}
+		views = append(views, viewEmailTemplate(t))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": views})
+}
+
+// Admin: Change the wording of an email (superadmin only). The template is tried
+// with example values first, so a mistake is caught here rather than when sending.
+// PUT /api/admin/email-templates/:type
+func UpdateEmailTemplate(c *gin.Context) {
+	emailType := c.Param("type")
+	d, ok := email.DefaultFor(emailType)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+		return
+	}
+
+	var input struct {
+		Subject  string `json:"subject" binding:"required"`
+		HTMLBody string `json:"htmlBody" binding:"required"`
+		TextBody string `json:"textBody" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t := models.EmailTemplate{
+		Type:      emailType,
+		Subject:   input.Subject,
+		HTMLBody:  input.HTMLBody,
+		TextBody:  input.TextBody,
+		UpdatedBy: c.GetString("adminEmail"),
+	}
+	subject, html, text, err := email.Render(t, d.Fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The template has a mistake: " + err.Error()})
+		return
+	}
+
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "html_body", "text_body", "updated_by", "updated_at"}),
+	}).Create(&t).Error
+	if err == nil {
+		t, err = email.Template(database.DB, emailType)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save template"})
+		return
+	}
+
+	middleware.LogActivity(c, "Updated email template", emailType)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Template saved",
+		"template": viewEmailTemplate(t),
+		"preview":  gin.H{"subject": subject, "html": html, "text": text},
+	})
+}
+
+// Admin: Go back to the built-in wording of an email (superadmin only)
+// DELETE /api/admin/email-templates/:type
+func ResetEmailTemplate(c *gin.Context) {
+	emailType := c.Param("type")
+	if _, ok := email.DefaultFor(emailType); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+		return
+	}
+	if err := database.DB.Where("type = ?", emailType).Delete(&models.EmailTemplate{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset template"})
+		return
+	}
+	middleware.LogActivity(c, "Reset email template", emailType)
+
+	t, err := email.Template(database.DB, emailType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load template"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Template reset to the default",
+		"template": viewEmailTemplate(t),
+	})
+}
+
+// Admin: Emails sent or waiting to be sent, latest first (superadmin only)
+// GET /api/admin/email-deliveries?status=&type=&recipient=&entityType=&entityId=&limit=
+func AdminGetEmailDeliveries(c *gin.Context) {
+	db := database.DB.Model(&models.EmailDelivery{})
+	for param, column := range map[string]string{
+		"status": "status", "type": "type", "recipient": "recipient", "entityType": "entity_type", "entityId": "entity_id",
+	} {
+		if value := c.Query(param); value != "" {
+			db = db.Where(column+" = ?", value)
+		}
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	var deliveries []models.EmailDelivery
+	db.Order("created_at DESC, id DESC").Limit(limit).Find(&deliveries)
+	c.JSON(http.StatusOK, gin.H{"data": deliveries})
+}
+
+// Admin: One email as it was sent, including the HTML (superadmin only)
+// GET /api/admin/email-deliveries/:id
+func AdminGetEmailDelivery(c *gin.Context) {
+	var delivery models.EmailDelivery
+	err := database.DB.First(&delivery, c.Param("id")).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": delivery, "htmlBody": delivery.HTMLBody})
+}
@@ -0,0 +1,341 @@
+// internal/handlers/event_check_in.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/auth"
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Side of a ticket QR image in pixels
+const ticketQRSize = 512
+
+var (
+	errTicketOtherEvent  = errors.New("This ticket is for a different event")
+	errTicketNotFound    = errors.New("No registration matches this ticket")
+	errNotConfirmed      = errors.New("This registration is not confirmed")
+	errAlreadyCheckedIn  = errors.New("Already checked in")
+	errTicketOrCodeEmpty = errors.New("ticket or code is required")
+)
+
+// registrationQRPath is where the registrant's ticket image can be fetched, or empty
+// until the registration is confirmed
+func registrationQRPath(r models.EventRegistration, token string) string {
+	if r.Status != models.RegistrationConfirmed {
+		return ""
+	}
+	return "/api/registrations/" + r.ConfirmationCode + "/qr.png?token=" + url.QueryEscape(token)
+}
+
+// writeTicketQR responds with the registration's ticket as a PNG QR code
+func writeTicketQR(c *gin.Context, r models.EventRegistration) {
+	if r.Status != models.RegistrationConfirmed {
+		c.JSON(http.StatusConflict, gin.H{"error": "A ticket is issued once the registration is confirmed"})
+		return
+	}
+	png, err := qrcode.Encode(auth.SignTicket(r.EventID, r.ConfirmationCode), qrcode.Medium, ticketQRSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate ticket"})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// Public: The registrant's ticket as a QR code
+// GET /api/registrations/:code/qr.png?token=
+func GetOwnRegistrationQR(c *gin.Context) {
+	registration, err := findOwnRegistration(database.DB, c.Param("code"), c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+	writeTicketQR(c, registration)
+}
+
+// Admin: A registrant's ticket as a QR code, e.g. to print or resend
+// GET /api/admin/special-events/:id/registrations/:registrationId/qr.png
+func AdminGetRegistrationQR(c *gin.Context) {
+	var registration models.EventRegistration
+	if err := database.DB.Where("event_id = ?", c.Param("id")).
+		First(&registration, c.Param("registrationId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+	writeTicketQR(c, registration)
+}
+
+// findTicket loads the registration for a scanned ticket, or for a confirmation code
+// typed in by hand when a ticket will not scan
+func findTicket(tx *gorm.DB, eventID uint, ticket, code string) (models.EventRegistration, error) {
+	var r models.EventRegistration
+	switch {
+	case ticket != "":
+		ticketEventID, ticketCode, err := auth.VerifyTicket(ticket)
+		if err != nil {
+			return r, err
+		}
+		if ticketEventID != eventID {
+			return r, errTicketOtherEvent
+		}
+		code = ticketCode
+	case code == "":
+		return r, errTicketOrCodeEmpty
+	}
+	err := tx.Where("event_id = ? AND confirmation_code = ?", eventID, strings.ToUpper(strings.TrimSpace(code))).First(&r).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r, errTicketNotFound
+	}
+	return r, err
+}
+
+// checkInRegistration marks a confirmed registration as arrived. The update only
+// succeeds once, so two ushers scanning the same ticket cannot both admit it.
+func checkInRegistration(tx *gorm.DB, r *models.EventRegistration, by string, at time.Time) error {
+	if r.Status != models.RegistrationConfirmed {
+		return errNotConfirmed
+	}
+	result := tx.Model(&models.EventRegistration{}).
+		Where("id = ? AND checked_in_at IS NULL", r.ID).
+		Updates(map[string]interface{}{"checked_in_at": at, "checked_in_by": by})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := tx.First(r, r.ID).Error; err != nil {
+			return err
+		}
+		return errAlreadyCheckedIn
+	}
+	r.CheckedInAt, r.CheckedInBy = &at, by
+	return nil
+}
+
+// checkInSummary is the live count shown at the door
+type checkInSummary struct {
+	Registrations   int  `json:"registrations"` // Confirmed, including walk-ins
+	Places          int  `json:"places"`
+	CheckedIn       int  `json:"checkedIn"`
+	CheckedInPlaces int  `json:"checkedInPlaces"`
+	WalkIns         int  `json:"walkIns"`
+	WalkInPlaces    int  `json:"walkInPlaces"`
+	Capacity        int  `json:"capacity"` // 0 for no limit
+	PlacesLeft      *int `json:"placesLeft"`
+	Expected        int  `json:"expected"` // Confirmed but not yet arrived
+	ExpectedPlaces  int  `json:"expectedPlaces"`
+}
+
+func summarizeCheckIns(tx *gorm.DB, event models.SpecialEvent) (checkInSummary, error) {
+	summary := checkInSummary{Capacity: event.Capacity}
+	err := tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status = ?", event.ID, models.RegistrationConfirmed).
+		Select(`COUNT(*) AS registrations,
+			COALESCE(SUM(places), 0) AS places,
+			COUNT(checked_in_at) AS checked_in,
+			COALESCE(SUM(places) FILTER (WHERE checked_in_at IS NOT NULL), 0) AS checked_in_places,
+			COUNT(*) FILTER (WHERE walk_in) AS walk_ins,
+			COALESCE(SUM(places) FILTER (WHERE walk_in), 0) AS walk_in_places`).
+		Scan(&summary).Error
+	if err != nil {
+		return summary, err
+	}
+	if summary.PlacesLeft, err = placesLeft(tx, event); err != nil {
+		return summary, err
+	}
+	summary.Expected = summary.Registrations - summary.CheckedIn
+	summary.ExpectedPlaces = summary.Places - summary.CheckedInPlaces
+	return summary, nil
+}
+
+// Admin/usher: Live check-in counts for an event
+// GET /api/admin/special-events/:id/check-ins/summary
+func GetEventCheckInSummary(c *gin.Context) {
+	var event models.SpecialEvent
+	if err := database.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	summary, err := summarizeCheckIns(database.DB, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count check-ins"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+// Admin/usher: Check in a scanned ticket, or a confirmation code typed in by hand
+// POST /api/admin/special-events/:id/check-ins {"ticket": "..."} or {"code": "K7QM-2XPA"}
+func CheckInEventRegistration(c *gin.Context) {
+	var event models.SpecialEvent
+	if err := database.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	var input struct {
+		Ticket string `json:"ticket"`
+		Code   string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var registration models.EventRegistration
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if registration, err = findTicket(tx, event.ID, input.Ticket, input.Code); err != nil {
+			return err
+		}
+		return checkInRegistration(tx, &registration, c.GetString("adminEmail"), time.Now())
+	})
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidTicket):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This ticket is not valid"})
+		return
+	case errors.Is(err, errTicketOrCodeEmpty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errTicketNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errTicketOtherEvent):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errNotConfirmed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": registration.Status})
+		return
+	case errors.Is(err, errAlreadyCheckedIn):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        err.Error(),
+			"checkedInAt":  registration.CheckedInAt,
+			"checkedInBy":  registration.CheckedInBy,
+			"registration": registration,
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+		return
+	}
+
+	middleware.LogActivity(c, "Checked in event registration", event.Title+": "+registration.ConfirmationCode)
+
+	summary, _ := summarizeCheckIns(database.DB, event)
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Checked in",
+		"registration": registration,
+		"summary":      summary,
+	})
+}
+
+// Admin/usher: Register and check in someone who arrives without registering
+// POST /api/admin/special-events/:id/walk-ins
+func CreateEventWalkIn(c *gin.Context) {
+	var input struct {
+		FirstName    string `json:"firstName" binding:"required"`
+		LastName     string `json:"lastName" binding:"required"`
+		Email        string `json:"email"`
+		Phone        string `json:"phone"`
+		Places       int    `json:"places"`       // Defaults to 1
+		OverCapacity bool   `json:"overCapacity"` // Admit even though the event is full
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	registration := models.EventRegistration{
+		FirstName:   strings.TrimSpace(input.FirstName),
+		LastName:    strings.TrimSpace(input.LastName),
+		Email:       strings.ToLower(strings.TrimSpace(input.Email)),
+		Phone:       strings.TrimSpace(input.Phone),
+		Places:      input.Places,
+		Answers:     map[string]string{},
+		Status:      models.RegistrationConfirmed,
+		WalkIn:      true,
+		CheckedInAt: &now,
+		CheckedInBy: c.GetString("adminEmail"),
+	}
+	if registration.Places == 0 {
+		registration.Places = 1
+	}
+	if registration.Places < 1 || registration.Places > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "places must be between 1 and 50"})
+		return
+	}
+
+	var event models.SpecialEvent
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, c.Param("id")).Error; err != nil {
+			return err
+		}
+		registration.EventID = event.ID
+
+		if registration.Email != "" {
+			var existing int64
+			if err := tx.Model(&models.EventRegistration{}).
+				Where("event_id = ? AND email = ? AND status <> ?", event.ID, registration.Email, models.RegistrationCancelled).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				return errAlreadyRegistered
+			}
+		}
+		left, err := placesLeft(tx, event)
+		if err != nil {
+			return err
+		}
+		if left != nil && registration.Places > *left && !input.OverCapacity {
+			return errEventFull
+		}
+
+		if registration.ConfirmationCode, err = uniqueConfirmationCode(tx); err != nil {
+			return err
+		}
+		// Walk-ins get a token like everyone else, though it is not sent to them
+		if _, registration.CancelTokenHash, err = auth.GenerateToken(); err != nil {
+			return err
+		}
+		return tx.Create(&registration).Error
+	})
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, errAlreadyRegistered):
+		c.JSON(http.StatusConflict, gin.H{"error": "This email address is already registered for this event. Check in their ticket or confirmation code instead"})
+		return
+	case errors.Is(err, errEventFull):
+		c.JSON(http.StatusConflict, gin.H{"error": "This event is full. Send overCapacity to admit them anyway"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save walk-in"})
+		return
+	}
+
+	middleware.LogActivity(c, "Checked in walk-in", event.Title+": "+registration.FirstName+" "+registration.LastName)
+
+	summary, _ := summarizeCheckIns(database.DB, event)
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Walk-in checked in",
+		"registration": registration,
+		"summary":      summary,
+	})
+}
@@ -0,0 +1,694 @@
+// internal/handlers/event_registration.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/auth"
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/slug"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errRegistrationClosed = errors.New("Registration for this event is not open")
+	errEventFull          = errors.New("This event is full")
+	errAlreadyRegistered  = errors.New("This email address is already registered for this event. Use the link in your confirmation to change or cancel it")
+)
+
+// invalidRegistrationError is a registration that does not fit the event's form
+type invalidRegistrationError struct{ error }
+
+// orderRegistrationFields lists a form's fields in display order when preloading
+func orderRegistrationFields(db *gorm.DB) *gorm.DB {
+	return db.Order("display_order ASC, id ASC")
+}
+
+// siteURL is the public website, used to build links sent to registrants
+func siteURL() string {
+	if url := os.Getenv("SITE_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return "https://rccgsalvationcentre.org"
+}
+
+// registrationCancelURL is the page where a registrant can see or cancel their registration
+func registrationCancelURL(code, token string) string {
+	return siteURL() + "/registrations/" + code + "?token=" + token
+}
+
+type registrationFieldInput struct {
+	Key      string   `json:"key"` // Defaults to one made from the label
+	Label    string   `json:"label"`
+	Type     string   `json:"type"` // text, textarea, number, select or checkbox
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+}
+
+// specialEventRegistrationInput is how an event takes registrations. The window is
+// part of specialEventScheduleInput.
+type specialEventRegistrationInput struct {
+	Capacity           *int                      `json:"capacity"` // 0 for no limit
+	Waitlist           *bool                     `json:"waitlist"`
+	MaxPlaces          *int                      `json:"maxPlaces"`
+	RegistrationFields *[]registrationFieldInput `json:"registrationFields"` // Replaces the whole form when sent
+}
+
+// apply sets the event's registration settings and returns the form fields to save,
+// or nil when the form is unchanged
+func (input specialEventRegistrationInput) apply(event *models.SpecialEvent) ([]models.RegistrationField, error) {
+	if input.Capacity != nil {
+		if *input.Capacity < 0 {
+			return nil, errors.New("capacity cannot be negative")
+		}
+		event.Capacity = *input.Capacity
+	}
+	if input.Waitlist != nil {
+		event.Waitlist = *input.Waitlist
+	}
+	if input.MaxPlaces != nil {
+		if *input.MaxPlaces < 1 || *input.MaxPlaces > 50 {
+			return nil, errors.New("maxPlaces must be between 1 and 50")
+		}
+		event.MaxPlaces = *input.MaxPlaces
+	}
+	if input.RegistrationFields == nil {
+		return nil, nil
+	}
+
+	fields := []models.RegistrationField{}
+	seen := map[string]bool{}
+	for i, f := range *input.RegistrationFields {
+		label := strings.TrimSpace(f.Label)
+		if label == "" {
+			return nil, fmt.Errorf("registrationFields[%d]: label is required", i)
+		}
+		key := slug.Make(f.Key)
+		if key == "" {
+			key = slug.Make(label)
+		}
+		if key == "" || seen[key] {
+			return nil, fmt.Errorf("registrationFields[%d]: key %q is missing or used twice", i, key)
+		}
+		seen[key] = true
+
+		field := models.RegistrationField{Key: key, Label: label, Type: f.Type, Required: f.Required, DisplayOrder: i + 1}
+		switch f.Type {
+		case models.FieldText, models.FieldTextarea, models.FieldNumber, models.FieldCheckbox:
+		case models.FieldSelect:
+			for _, option := range f.Options {
+				if option = strings.TrimSpace(option); option != "" {
+					field.Options = append(field.Options, option)
+				}
+			}
+			if len(field.Options) == 0 {
+				return nil, fmt.Errorf("registrationFields[%d]: a select field needs options", i)
+			}
+		default:
+			return nil, fmt.Errorf("registrationFields[%d]: type must be text, textarea, number, select or checkbox", i)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// saveRegistrationFields replaces an event's form. Answers already given to removed
+// fields are kept on the registrations.
+func saveRegistrationFields(tx *gorm.DB, eventID uint, fields []models.RegistrationField) error {
+	if err := tx.Where("event_id = ?", eventID).Delete(&models.RegistrationField{}).Error; err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	for i := range fields {
+		fields[i].EventID = eventID
+	}
+	return tx.Create(&fields).Error
+}
+
+// confirmedPlaces counts the places taken by confirmed registrations
+func confirmedPlaces(tx *gorm.DB, eventID uint) (int, error) {
+	var taken int
+	err := tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status = ?", eventID, models.RegistrationConfirmed).
+		Select("COALESCE(SUM(places), 0)").Scan(&taken).Error
+	return taken, err
+}
+
+// placesLeft is how many places can still be confirmed; nil when there is no limit
+func placesLeft(tx *gorm.DB, event models.SpecialEvent) (*int, error) {
+	if event.Capacity == 0 {
+		return nil, nil
+	}
+	taken, err := confirmedPlaces(tx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+	left := max(event.Capacity-taken, 0)
+	return &left, nil
+}
+
+// promoteWaitlist confirms waitlisted registrations, oldest first, while places
+// allow. A party too large for the places left is passed over for smaller ones
+// behind it. The event row must be locked.
+func promoteWaitlist(tx *gorm.DB, event models.SpecialEvent) ([]models.EventRegistration, error) {
+	var waiting []models.EventRegistration
+	if err := tx.Where("event_id = ? AND status = ?", event.ID, models.RegistrationWaitlisted).
+		Order("created_at ASC, id ASC").Find(&waiting).Error; err != nil {
+		return nil, err
+	}
+	if len(waiting) == 0 {
+		return nil, nil
+	}
+
+	left := -1 // No limit
+	if event.Capacity > 0 {
+		taken, err := confirmedPlaces(tx, event.ID)
+		if err != nil {
+			return nil, err
+		}
+		left = event.Capacity - taken
+	}
+
+	var promoted []models.EventRegistration
+	now := time.Now()
+	for _, r := range waiting {
+		if left >= 0 && r.Places > left {
+			continue
+		}
+		if err := tx.Model(&r).Updates(map[string]interface{}{
+			"status":      models.RegistrationConfirmed,
+			"promoted_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		r.Status, r.PromotedAt = models.RegistrationConfirmed, &now
+		promoted = append(promoted, r)
+		if left >= 0 {
+			left -= r.Places
+		}
+	}
+	for _, r := range promoted {
+		log.Printf("[REGISTRATION] %s promoted from the waitlist for %q", r.ConfirmationCode, event.Title)
+	}
+	return promoted, nil
+}
+
+// waitlistPosition is the registration's place in the queue, from 1
+func waitlistPosition(tx *gorm.DB, r models.EventRegistration) (int, error) {
+	var ahead int64
+	err := tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status = ? AND (created_at < ? OR (created_at = ? AND id < ?))",
+			r.EventID, models.RegistrationWaitlisted, r.CreatedAt, r.CreatedAt, r.ID).
+		Count(&ahead).Error
+	return int(ahead) + 1, err
+}
+
+// registrationView is what a registrant sees of their own registration
+type registrationView struct {
+	ConfirmationCode string            `json:"confirmationCode"`
+	Status           string            `json:"status"`
+	WaitlistPosition int               `json:"waitlistPosition,omitempty"`
+	FirstName        string            `json:"firstName"`
+	LastName         string            `json:"lastName"`
+	Email            string            `json:"email"`
+	Places           int               `json:"places"`
+	Answers          map[string]string `json:"answers"`
+	Ticket           string            `json:"ticket,omitempty"` // Signed QR payload, once confirmed
+	CheckedInAt      *time.Time        `json:"checkedInAt,omitempty"`
+	Event            gin.H             `json:"event"`
+	CreatedAt        time.Time         `json:"createdAt"`
+}
+
+func viewRegistration(tx *gorm.DB, r models.EventRegistration, event models.SpecialEvent) (registrationView, error) {
+	view := registrationView{
+		ConfirmationCode: r.ConfirmationCode,
+		Status:           r.Status,
+		FirstName:        r.FirstName,
+		LastName:         r.LastName,
+		Email:            r.Email,
+		Places:           r.Places,
+		Answers:          r.Answers,
+		CheckedInAt:      r.CheckedInAt,
+		Event: gin.H{
+			"title":    event.Title,
+			"slug":     event.Slug,
+			"date":     event.Date,
+			"endDate":  event.LastDay(),
+			"startsAt": event.StartsAt,
+			"location": event.Location,
+		},
+		CreatedAt: r.CreatedAt,
+	}
+	if r.Status == models.RegistrationConfirmed {
+		view.Ticket = auth.SignTicket(r.EventID, r.ConfirmationCode)
+	}
+	if r.Status == models.RegistrationWaitlisted {
+		position, err := waitlistPosition(tx, r)
+		if err != nil {
+			return view, err
+		}
+		view.WaitlistPosition = position
+	}
+	return view, nil
+}
+
+type eventRegistrationInput struct {
+	FirstName string                 `json:"firstName" binding:"required"`
+	LastName  string                 `json:"lastName" binding:"required"`
+	Email     string                 `json:"email" binding:"required"`
+	Phone     string                 `json:"phone"`
+	Places    int                    `json:"places"` // Defaults to 1
+	Answers   map[string]interface{} `json:"answers"`
+}
+
+// build checks the registration against the event's form
+func (input eventRegistrationInput) build(event models.SpecialEvent, fields []models.RegistrationField) (models.EventRegistration, error) {
+	r := models.EventRegistration{
+		EventID:   event.ID,
+		FirstName: strings.TrimSpace(input.FirstName),
+		LastName:  strings.TrimSpace(input.LastName),
+		Email:     strings.ToLower(strings.TrimSpace(input.Email)),
+		Phone:     strings.TrimSpace(input.Phone),
+		Places:    input.Places,
+		Answers:   map[string]string{},
+	}
+	if r.FirstName == "" || r.LastName == "" {
+		return r, errors.New("firstName and lastName are required")
+	}
+	if _, err := mail.ParseAddress(r.Email); err != nil {
+		return r, errors.New("Please enter a valid email address")
+	}
+	if r.Places == 0 {
+		r.Places = 1
+	}
+	if r.Places < 1 || r.Places > event.MaxPlaces {
+		return r, fmt.Errorf("places must be between 1 and %d", event.MaxPlaces)
+	}
+
+	for _, f := range fields {
+		answer := ""
+		switch v := input.Answers[f.Key].(type) {
+		case nil:
+		case string:
+			answer = strings.TrimSpace(v)
+		case bool:
+			answer = strconv.FormatBool(v)
+		case float64:
+			answer = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return r, fmt.Errorf("%s: answer must be text, a number or true/false", f.Label)
+		}
+
+		switch f.Type {
+		case models.FieldCheckbox:
+			if answer == "" {
+				answer = "false"
+			}
+			if answer != "true" && answer != "false" {
+				return r, fmt.Errorf("%s: answer must be true or false", f.Label)
+			}
+			if f.Required && answer != "true" {
+				return r, fmt.Errorf("%s must be ticked", f.Label)
+			}
+		case models.FieldNumber:
+			if answer != "" {
+				if _, err := strconv.ParseFloat(answer, 64); err != nil {
+					return r, fmt.Errorf("%s: answer must be a number", f.Label)
+				}
+			}
+		case models.FieldSelect:
+			if answer != "" && !containsString(f.Options, answer) {
+				return r, fmt.Errorf("%s: choose one of %s", f.Label, strings.Join(f.Options, ", "))
+			}
+		}
+		if f.Required && answer == "" {
+			return r, fmt.Errorf("%s is required", f.Label)
+		}
+		if len(answer) > 2000 {
+			return r, fmt.Errorf("%s: answer is too long", f.Label)
+		}
+		if answer != "" {
+			r.Answers[f.Key] = answer
+		}
+	}
+	return r, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Public: Register for a special event
+// POST /api/special-events/:slug/registrations
+func CreateEventRegistration(c *gin.Context) {
+	var input eventRegistrationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var registration models.EventRegistration
+	var event models.SpecialEvent
+	var cancelToken string
+	var view registrationView
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Locking the event serialises registrations, so capacity cannot be overbooked
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scopes(models.Visible(time.Now())).Where("slug = ?", c.Param("slug")).First(&event).Error; err != nil {
+			return err
+		}
+		if !event.RegistrationOpenAt(time.Now()) {
+			return errRegistrationClosed
+		}
+		var fields []models.RegistrationField
+		if err := orderRegistrationFields(tx.Where("event_id = ?", event.ID)).Find(&fields).Error; err != nil {
+			return err
+		}
+
+		var err error
+		if registration, err = input.build(event, fields); err != nil {
+			return invalidRegistrationError{err}
+		}
+
+		var existing int64
+		if err := tx.Model(&models.EventRegistration{}).
+			Where("event_id = ? AND email = ? AND status <> ?", event.ID, registration.Email, models.RegistrationCancelled).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errAlreadyRegistered
+		}
+
+		registration.Status = models.RegistrationConfirmed
+		left, err := placesLeft(tx, event)
+		if err != nil {
+			return err
+		}
+		if left != nil && registration.Places > *left {
+			if !event.Waitlist {
+				return errEventFull
+			}
+			registration.Status = models.RegistrationWaitlisted
+		}
+
+		if registration.ConfirmationCode, err = uniqueConfirmationCode(tx); err != nil {
+			return err
+		}
+		if cancelToken, registration.CancelTokenHash, err = auth.GenerateToken(); err != nil {
+			return err
+		}
+		if err := tx.Create(&registration).Error; err != nil {
+			return err
+		}
+		view, err = viewRegistration(tx, registration, event)
+		return err
+	})
+
+	var invalid invalidRegistrationError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	case errors.Is(err, errRegistrationClosed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, errEventFull), errors.Is(err, errAlreadyRegistered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save registration"})
+		return
+	}
+
+	message := "You are registered. Please keep your confirmation code"
+	if registration.Status == models.RegistrationWaitlisted {
+		message = "The event is full, so you have been added to the waitlist. We will let you know if a place becomes free"
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      message,
+		"registration": view,
+		"cancelToken":  cancelToken, // Only ever sent here; needed to view or cancel the registration
+		"cancelUrl":    registrationCancelURL(registration.ConfirmationCode, cancelToken),
+		"qrUrl":        registrationQRPath(registration, cancelToken),
+	})
+}
+
+// uniqueConfirmationCode generates a code not yet used by any registration
+func uniqueConfirmationCode(tx *gorm.DB) (string, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := auth.GenerateCode()
+		if err != nil {
+			return "", err
+		}
+		var used int64
+		if err := tx.Model(&models.EventRegistration{}).Where("confirmation_code = ?", code).Count(&used).Error; err != nil {
+			return "", err
+		}
+		if used == 0 {
+			return code, nil
+		}
+	}
+	return "", errors.New("could not generate a unique confirmation code")
+}
+
+// findOwnRegistration loads the registration named by :code if token matches it
+func findOwnRegistration(tx *gorm.DB, code, token string) (models.EventRegistration, error) {
+	var r models.EventRegistration
+	err := tx.Where("confirmation_code = ? AND cancel_token_hash = ?",
+		strings.ToUpper(strings.TrimSpace(code)), auth.HashToken(strings.TrimSpace(token))).First(&r).Error
+	return r, err
+}
+
+// Public: See one's own registration
+// GET /api/registrations/:code?token=
+func GetOwnEventRegistration(c *gin.Context) {
+	registration, err := findOwnRegistration(database.DB, c.Param("code"), c.Query("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+	var event models.SpecialEvent
+	if err := database.DB.First(&event, registration.EventID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+	view, err := viewRegistration(database.DB, registration, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load registration"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  view,
+		"qrUrl": registrationQRPath(registration, c.Query("token")),
+	})
+}
+
+// cancelRegistration cancels r and fills any places it frees from the waitlist.
+// cancelledBy is empty when the registrant cancels.
+func cancelRegistration(tx *gorm.DB, r *models.EventRegistration, cancelledBy string) ([]models.EventRegistration, error) {
+	var event models.SpecialEvent
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, r.EventID).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := tx.Model(r).Updates(map[string]interface{}{
+		"status":       models.RegistrationCancelled,
+		"cancelled_at": now,
+		"cancelled_by": cancelledBy,
+	}).Error; err != nil {
+		return nil, err
+	}
+	r.Status, r.CancelledAt, r.CancelledBy = models.RegistrationCancelled, &now, cancelledBy
+	return promoteWaitlist(tx, event)
+}
+
+// Public: Cancel one's own registration
+// POST /api/registrations/:code/cancel {"token": "..."}
+func CancelOwnEventRegistration(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var registration models.EventRegistration
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if registration, err = findOwnRegistration(tx, c.Param("code"), input.Token); err != nil {
+			return err
+		}
+		if registration.Status == models.RegistrationCancelled {
+			return nil
+		}
+		_, err = cancelRegistration(tx, &registration, "")
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Your registration has been cancelled"})
+}
+
+// Roles that may see registrants' contact details and answers
+var registrationPIIRoles = []string{"superadmin", "admin"}
+
+// GET filters: ?status=&q=. Only roles that can see emails may search by them.
+func filterEventRegistrations(c *gin.Context, eventID uint) *gorm.DB {
+	db := database.DB.Model(&models.EventRegistration{}).Where("event_id = ?", eventID)
+	if status := c.Query("status"); status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if q := c.Query("q"); q != "" {
+		like := "%" + q + "%"
+		if middleware.HasRole(c, registrationPIIRoles...) {
+			db = db.Where("first_name ILIKE ? OR last_name ILIKE ? OR email ILIKE ? OR confirmation_code ILIKE ?", like, like, like, like)
+		} else {
+			db = db.Where("first_name ILIKE ? OR last_name ILIKE ? OR confirmation_code ILIKE ?", like, like, like)
+		}
+	}
+	return db
+}
+
+// Admin: List an event's registrations with a summary of places
+// GET /api/admin/special-events/:id/registrations?status=&q=
+func AdminGetEventRegistrations(c *gin.Context) {
+	var event models.SpecialEvent
+	if err := database.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	var registrations []models.EventRegistration
+	filterEventRegistrations(c, event.ID).Order("created_at ASC, id ASC").Find(&registrations)
+	// The same details the export withholds from other roles
+	if !middleware.HasRole(c, registrationPIIRoles...) {
+		for i := range registrations {
+			registrations[i].Email, registrations[i].Phone, registrations[i].Answers = "", "", nil
+		}
+	}
+
+	var summary []struct {
+		Status        string `json:"status"`
+		Registrations int    `json:"registrations"`
+		Places        int    `json:"places"`
+	}
+	database.DB.Model(&models.EventRegistration{}).Where("event_id = ?", event.ID).
+		Select("status, COUNT(*) AS registrations, COALESCE(SUM(places), 0) AS places").
+		Group("status").Scan(&summary)
+	left, _ := placesLeft(database.DB, event)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       registrations,
+		"summary":    summary,
+		"capacity":   event.Capacity,
+		"placesLeft": left,
+	})
+}
+
+// Admin: Cancel a registration on the registrant's behalf
+// POST /api/admin/special-events/:id/registrations/:registrationId/cancel
+func AdminCancelEventRegistration(c *gin.Context) {
+	var registration models.EventRegistration
+	if err := database.DB.Where("event_id = ?", c.Param("id")).
+		First(&registration, c.Param("registrationId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+	if registration.Status == models.RegistrationCancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Registration is already cancelled"})
+		return
+	}
+
+	var promoted []models.EventRegistration
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		promoted, err = cancelRegistration(tx, &registration, c.GetString("adminEmail"))
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel registration"})
+		return
+	}
+
+	middleware.LogActivity(c, "Cancelled event registration", registration.ConfirmationCode+" ("+registration.Email+")")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Registration cancelled",
+		"registration": registration,
+		"promoted":     promoted,
+	})
+}
+
+// registrationExport has a column for each question on the event's form
+func registrationExport(event models.SpecialEvent, fields []models.RegistrationField) exportSpec[models.EventRegistration] {
+	spec := exportSpec[models.EventRegistration]{
+		Name:     "registrations-" + event.Slug,
+		PIIRoles: registrationPIIRoles,
+		Order:    "created_at ASC, id ASC",
+		Columns: []exportColumn[models.EventRegistration]{
+			{Key: "id", Header: "ID", Value: func(r *models.EventRegistration) string { return strconv.FormatUint(uint64(r.ID), 10) }},
+			{Key: "confirmationCode", Header: "Confirmation Code", Value: func(r *models.EventRegistration) string { return r.ConfirmationCode }},
+			{Key: "firstName", Header: "First Name", Value: func(r *models.EventRegistration) string { return r.FirstName }},
+			{Key: "lastName", Header: "Last Name", Value: func(r *models.EventRegistration) string { return r.LastName }},
+			{Key: "email", Header: "Email", PII: true, Value: func(r *models.EventRegistration) string { return r.Email }},
+			{Key: "phone", Header: "Phone", PII: true, Value: func(r *models.EventRegistration) string { return r.Phone }},
+			{Key: "places", Header: "Places", Value: func(r *models.EventRegistration) string { return strconv.Itoa(r.Places) }},
+			{Key: "status", Header: "Status", Value: func(r *models.EventRegistration) string { return r.Status }},
+			{Key: "createdAt", Header: "Registered At", Value: func(r *models.EventRegistration) string { return formatExportTime(r.CreatedAt) }},
+		},
+	}
+	for _, f := range fields {
+		key := f.Key
+		// Answers can be personal (health, dietary needs), so they are treated as PII
+		spec.Columns = append(spec.Columns, exportColumn[models.EventRegistration]{
+			Key: "answers." + key, Header: f.Label, PII: true,
+			Value: func(r *models.EventRegistration) string { return r.Answers[key] },
+		})
+	}
+	return spec
+}
+
+// Admin: Export an event's registrations (GET /api/admin/special-events/:id/registrations/export)
+func ExportEventRegistrations(c *gin.Context) {
+	var event models.SpecialEvent
+	if err := database.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	var fields []models.RegistrationField
+	orderRegistrationFields(database.DB.Where("event_id = ?", event.ID)).Find(&fields)
+
+	streamExport(c, registrationExport(event, fields), filterEventRegistrations(c, event.ID))
+}
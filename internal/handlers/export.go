@@ -0,0 +1,287 @@
+// internal/handlers/export.go
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/export"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exportColumn describes one selectable column of an export.
+// PII columns are only offered to the roles listed on the exportSpec.
+type exportColumn[T any] struct {
+	Key    string
+	Header string
+	PII    bool
+	Value  func(*T) string
+}
+
+type exportSpec[T any] struct {
+	Name     string // used for the file and sheet name
+	Columns  []exportColumn[T]
+	PIIRoles []string
+	Order    string
+}
+
+// streamExport writes every row matched by db to the response as CSV or XLSX.
+// Query params: ?format=csv|xlsx&columns=key1,key2 (defaults to every column the caller may see)
+func streamExport[T any](c *gin.Context, spec exportSpec[T], db *gorm.DB) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if !export.ValidFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Use 'csv' or 'xlsx'"})
+		return
+	}
+
+	canSeePII := middleware.HasRole(c, spec.PIIRoles...)
+	columns, err := selectExportColumns(spec.Columns, c.Query("columns"), canSeePII)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := db.Order(spec.Order).Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load export data"})
+		return
+	}
+	defer rows.Close()
+
+	// Large exports outlive the server's default write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(10 * time.Minute))
+
+	filename := fmt.Sprintf("%s-%s.%s", spec.Name, localtime.Now().Format("20060102-1504"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	w, err := export.NewWriter(format, c.Writer, spec.Name)
+	if err != nil {
+		log.Printf("[EXPORT] Failed to start %s export: %v", spec.Name, err)
+		return
+	}
+
+	headers := make([]string, len(columns))
+	for i, col := range columns {
+		headers[i] = col.Header
+	}
+	if err := w.WriteRow(headers); err != nil {
+		log.Printf("[EXPORT] %s export aborted: %v", spec.Name, err)
+		return
+	}
+
+	count := 0
+	values := make([]string, len(columns))
+	for rows.Next() {
+		var item T
+		if err := database.DB.ScanRows(rows, &item); err != nil {
+			log.Printf("[EXPORT] %s export aborted: %v", spec.Name, err)
+			return
+		}
+		for i, col := range columns {
+			values[i] = col.Value(&item)
+		}
+		if err := w.WriteRow(values); err != nil {
+			// Usually the client went away
+			log.Printf("[EXPORT] %s export aborted: %v", spec.Name, err)
+			return
+		}
+		count++
+	}
+
+	if err := w.Close(); err != nil {
+		log.Printf("[EXPORT] %s export aborted: %v", spec.Name, err)
+		return
+	}
+
+	middleware.LogActivity(c, "Exported "+spec.Name, fmt.Sprintf("%d rows as %s", count, format))
+}
+
+// selectExportColumns resolves the requested column keys in the order given
+func selectExportColumns[T any](all []exportColumn[T], requested string, canSeePII bool) ([]exportColumn[T], error) {
+	if requested == "" {
+		var columns []exportColumn[T]
+		for _, col := range all {
+			if !col.PII || canSeePII {
+				columns = append(columns, col)
+			}
+		}
+		return columns, nil
+	}
+
+	byKey := make(map[string]exportColumn[T], len(all))
+	for _, col := range all {
+		byKey[col.Key] = col
+	}
+
+	var columns []exportColumn[T]
+	for _, key := range strings.Split(requested, ",") {
+		key = strings.TrimSpace(key)
+		col, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("Unknown column %q", key)
+		}
+		if col.PII && !canSeePII {
+			return nil, fmt.Errorf("Forbidden: your role cannot export column %q", key)
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+var firstTimerExport = exportSpec[models.FirstTimer]{
+	Name:     "first-timers",
+	PIIRoles: []string{"superadmin", "visitors_welfare"},
+	Order:    "visit_date DESC",
+	Columns: []exportColumn[models.FirstTimer]{
+		{Key: "id", Header: "ID", Value: func(f *models.FirstTimer) string { return strconv.FormatUint(uint64(f.ID), 10) }},
+		{Key: "firstName", Header: "First Name", Value: func(f *models.FirstTimer) string { return f.FirstName }},
+		{Key: "lastName", Header: "Last Name", Value: func(f *models.FirstTimer) string { return f.LastName }},
+		{Key: "email", Header: "Email", PII: true, Value: func(f *models.FirstTimer) string { return f.Email }},
+		{Key: "phone", Header: "Phone", PII: true, Value: func(f *models.FirstTimer) string { return f.Phone }},
+		{Key: "address", Header: "Address", PII: true, Value: func(f *models.FirstTimer) string { return f.Address }},
+		{Key: "city", Header: "City", Value: func(f *models.FirstTimer) string { return f.City }},
+		{Key: "state", Header: "State", Value: func(f *models.FirstTimer) string { return f.State }},
+		{Key: "dateOfBirth", Header: "Date of Birth", PII: true, Value: func(f *models.FirstTimer) string { return f.DateOfBirth }},
+		{Key: "gender", Header: "Gender", Value: func(f *models.FirstTimer) string { return f.Gender }},
+		{Key: "maritalStatus", Header: "Marital Status", PII: true, Value: func(f *models.FirstTimer) string { return f.MaritalStatus }},
+		{Key: "occupation", Header: "Occupation", Value: func(f *models.FirstTimer) string { return f.Occupation }},
+		{Key: "visitDate", Header: "Visit Date", Value: func(f *models.FirstTimer) string { return f.VisitDate.String() }},
+		{Key: "howDidYouHear", Header: "How Did You Hear", Value: func(f *models.FirstTimer) string { return f.HowDidYouHear }},
+		{Key: "prayerRequest", Header: "Prayer Request", PII: true, Value: func(f *models.FirstTimer) string { return f.PrayerRequest }},
+		{Key: "interestedInMembership", Header: "Interested in Membership", Value: func(f *models.FirstTimer) string {
+			return strconv.FormatBool(f.InterestedInMembership)
+		}},
+		{Key: "followUpStatus", Header: "Follow-up Status", Value: func(f *models.FirstTimer) string { return f.FollowUpStatus }},
+		{Key: "status", Header: "Status", Value: func(f *models.FirstTimer) string { return f.Status }},
+		{Key: "createdAt", Header: "Submitted At", Value: func(f *models.FirstTimer) string { return formatExportTime(f.CreatedAt) }},
+	},
+}
+
+var attendanceExport = exportSpec[models.Attendance]{
+	Name:  "attendance",
+	Order: "date DESC",
+	Columns: []exportColumn[models.Attendance]{
+		{Key: "id", Header: "ID", Value: func(a *models.Attendance) string { return strconv.FormatUint(uint64(a.ID), 10) }},
+		{Key: "date", Header: "Date", Value: func(a *models.Attendance) string { return a.Date.String() }},
+		{Key: "serviceType", Header: "Service Type", Value: func(a *models.Attendance) string { return a.ServiceType }},
+		{Key: "adults", Header: "Adults", Value: func(a *models.Attendance) string { return strconv.Itoa(a.Adults) }},
+		{Key: "children", Header: "Children", Value: func(a *models.Attendance) string { return strconv.Itoa(a.Children) }},
+		{Key: "total", Header: "Total", Value: func(a *models.Attendance) string { return strconv.Itoa(a.Total) }},
+		{Key: "firstTimers", Header: "First-Timers", Value: func(a *models.Attendance) string { return strconv.Itoa(a.FirstTimers) }},
+		{Key: "visitors", Header: "Visitors", Value: func(a *models.Attendance) string { return strconv.Itoa(a.Visitors) }},
+		{Key: "members", Header: "Members", Value: func(a *models.Attendance) string { return strconv.Itoa(a.Members) }},
+		{Key: "notes", Header: "Notes", Value: func(a *models.Attendance) string { return a.Notes }},
+		{Key: "recordedBy", Header: "Recorded By", Value: func(a *models.Attendance) string { return a.RecordedBy }},
+	},
+}
+
+var prayerRequestExport = exportSpec[models.PrayerRequest]{
+	Name:     "prayer-requests",
+	PIIRoles: []string{"superadmin", "secretariat"},
+	Order:    "submitted_at DESC",
+	Columns: []exportColumn[models.PrayerRequest]{
+		{Key: "id", Header: "ID", Value: func(p *models.PrayerRequest) string { return strconv.FormatUint(uint64(p.ID), 10) }},
+		{Key: "name", Header: "Name", Value: func(p *models.PrayerRequest) string { return p.Name }},
+		{Key: "email", Header: "Email", PII: true, Value: func(p *models.PrayerRequest) string { return p.Email }},
+		{Key: "request", Header: "Request", PII: true, Value: func(p *models.PrayerRequest) string { return p.Request }},
+		{Key: "status", Header: "Status", Value: func(p *models.PrayerRequest) string { return p.Status }},
+		{Key: "submittedAt", Header: "Submitted At", Value: func(p *models.PrayerRequest) string { return formatExportTime(p.SubmittedAt) }},
+	},
+}
+
+var testimonyExport = exportSpec[models.Testimony]{
+	Name:     "testimonies",
+	PIIRoles: []string{"superadmin", "secretariat"},
+	Order:    "submitted_at DESC",
+	Columns: []exportColumn[models.Testimony]{
+		{Key: "id", Header: "ID", Value: func(t *models.Testimony) string { return strconv.FormatUint(uint64(t.ID), 10) }},
+		{Key: "name", Header: "Name", Value: func(t *models.Testimony) string { return t.Name }},
+		{Key: "title", Header: "Title", Value: func(t *models.Testimony) string { return t.Title }},
+		{Key: "message", Header: "Message", Value: func(t *models.Testimony) string { return t.Message }},
+		{Key: "email", Header: "Email", PII: true, Value: func(t *models.Testimony) string { return t.Email }},
+		{Key: "phone", Header: "Phone", PII: true, Value: func(t *models.Testimony) string { return t.Phone }},
+		{Key: "status", Header: "Status", Value: func(t *models.Testimony) string { return string(t.Status) }},
+		{Key: "submittedAt", Header: "Submitted At", Value: func(t *models.Testimony) string { return formatExportTime(t.SubmittedAt) }},
+	},
+}
+
+var activityLogExport = exportSpec[models.ActivityLog]{
+	Name:  "activity-log",
+	Order: "created_at DESC",
+	Columns: []exportColumn[models.ActivityLog]{
+		{Key: "id", Header: "ID", Value: func(l *models.ActivityLog) string { return strconv.FormatUint(uint64(l.ID), 10) }},
+		{Key: "adminEmail", Header: "Admin", Value: func(l *models.ActivityLog) string { return l.AdminEmail }},
+		{Key: "action", Header: "Action", Value: func(l *models.ActivityLog) string { return l.Action }},
+		{Key: "details", Header: "Details", Value: func(l *models.ActivityLog) string { return l.Details }},
+		{Key: "createdAt", Header: "Time", Value: func(l *models.ActivityLog) string { return formatExportTime(l.CreatedAt) }},
+	},
+}
+
+// Admin: Export first-timers (GET /api/admin/first-timers/export)
+func ExportFirstTimers(c *gin.Context) {
+	db, err := filterFirstTimers(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	streamExport(c, firstTimerExport, db)
+}
+
+// Admin: Export attendance records (GET /api/admin/attendance/export)
+func ExportAttendance(c *gin.Context) {
+	db, err := filterAttendance(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	streamExport(c, attendanceExport, db)
+}
+
+// Admin: Export prayer requests (GET /api/admin/prayer-requests/export)
+func ExportPrayerRequests(c *gin.Context) {
+	db, err := filterPrayerRequests(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	streamExport(c, prayerRequestExport, db)
+}
+
+// Admin: Export testimonies (GET /api/admin/testimonies/export)
+func ExportTestimonies(c *gin.Context) {
+	db, err := filterTestimonies(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	streamExport(c, testimonyExport, db)
+}
+
+// Admin: Export the activity log (GET /api/admin/activity-log/export, superadmin only)
+func ExportActivityLogs(c *gin.Context) {
+	db, err := filterActivityLogs(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	streamExport(c, activityLogExport, db)
+}
@@ -0,0 +1,102 @@
+// internal/handlers/filters.go
+package handlers
+
+import (
+	"errors"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// List filters are shared by the admin list endpoints and their exports,
+// so a download always matches what the admin was looking at.
+
+var errInvalidDateFilter = errors.New("Invalid 'from' or 'to' date. Use YYYY-MM-DD")
+
+// dateRange narrows db to rows where column falls within ?from=&to= (both inclusive).
+// A date column is compared as a date; a timestamp column by church-local days.
+func dateRange(c *gin.Context, db *gorm.DB, column string, timestamp bool) (*gorm.DB, error) {
+	bound := func(d localtime.Date) interface{} {
+		if timestamp {
+			return d.Start()
+		}
+		return d
+	}
+	if from := c.Query("from"); from != "" {
+		parsed, err := localtime.ParseDate(from)
+		if err != nil {
+			return nil, errInvalidDateFilter
+		}
+		db = db.Where(column+" >= ?", bound(parsed))
+	}
+	if to := c.Query("to"); to != "" {
+		parsed, err := localtime.ParseDate(to)
+		if err != nil {
+			return nil, errInvalidDateFilter
+		}
+		db = db.Where(column+" < ?", bound(parsed.AddDays(1)))
+	}
+	return db, nil
+}
+
+// GET filters: ?status=&followUpStatus=&q=&from=&to= (visit date)
+func filterFirstTimers(c *gin.Context) (*gorm.DB, error) {
+	db := database.DB.Model(&models.FirstTimer{})
+	if status := c.Query("status"); status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if followUp := c.Query("followUpStatus"); followUp != "" {
+		db = db.Where("follow_up_status = ?", followUp)
+	}
+	if q := c.Query("q"); q != "" {
+		like := "%" + q + "%"
+		db = db.Where("first_name ILIKE ? OR last_name ILIKE ? OR email ILIKE ? OR phone ILIKE ?", like, like, like, like)
+	}
+	return dateRange(c, db, "visit_date", false)
+}
+
+// GET filters: ?serviceTypeId=&serviceType=&from=&to=
+func filterAttendance(c *gin.Context) (*gorm.DB, error) {
+	db := database.DB.Model(&models.Attendance{})
+	if serviceTypeID := c.Query("serviceTypeId"); serviceTypeID != "" {
+		db = db.Where("service_type_id = ?", serviceTypeID)
+	}
+	if serviceType := c.Query("serviceType"); serviceType != "" {
+		db = db.Where("service_type = ?", serviceType)
+	}
+	return dateRange(c, db, "date", false)
+}
+
+// GET filters: ?status=&from=&to= (submission date)
+func filterPrayerRequests(c *gin.Context) (*gorm.DB, error) {
+	db := database.DB.Model(&models.PrayerRequest{})
+	if status := c.Query("status"); status != "" {
+		db = db.Where("status = ?", status)
+	}
+	return dateRange(c, db, "submitted_at", true)
+}
+
+// GET filters: ?status=&from=&to= (submission date)
+func filterTestimonies(c *gin.Context) (*gorm.DB, error) {
+	db := database.DB.Model(&models.Testimony{})
+	if status := c.Query("status"); status != "" {
+		db = db.Where("status = ?", status)
+	}
+	return dateRange(c, db, "submitted_at", true)
+}
+
+// GET filters: ?adminEmail=&action=&from=&to=
+func filterActivityLogs(c *gin.Context) (*gorm.DB, error) {
+	db := database.DB.Model(&models.ActivityLog{})
+	if email := c.Query("adminEmail"); email != "" {
+		db = db.Where("admin_email = ?", email)
+	}
+	if action := c.Query("action"); action != "" {
+		db = db.Where("action ILIKE ?", "%"+action+"%")
+	}
+	return dateRange(c, db, "created_at", true)
+}
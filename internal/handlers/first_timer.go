@@ -2,48 +2,120 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"time"
+	"strings"
 
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/email"
+	"rccg-salvation-centre-backend/internal/localtime"
 	"rccg-salvation-centre-backend/internal/middleware"
 	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/occurrence"
+	"rccg-salvation-centre-backend/internal/welcome"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Public: Submit first-timer information
 func CreateFirstTimer(c *gin.Context) {
-	var input struct {
-		FirstName              string `json:"firstName" binding:"required"`
-		LastName               string `json:"lastName" binding:"required"`
-		Email                  string `json:"email"`
-		Phone                  string `json:"phone"`
-		Address                string `json:"address"`
-		City                   string `json:"city"`
-		State                  string `json:"state"`
-		DateOfBirth            string `json:"dateOfBirth"`
-		Gender                 string `json:"gender"`
-		MaritalStatus          string `json:"maritalStatus"`
-		Occupation             string `json:"occupation"`
-		VisitDate              string `json:"visitDate" binding:"required"` // YYYY-MM-DD
-		HowDidYouHear          string `json:"howDidYouHear"`
-		PrayerRequest          string `json:"prayerRequest"`
-		InterestedInMembership bool   `json:"interestedInMembership"`
+	var input firstTimerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
+	firstTimer, err := input.build()
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	parsedVisitDate, err := time.Parse("2006-01-02", input.VisitDate)
+	if firstTimer.ServiceOccurrenceID, err = occurrence.OnlyOnDate(database.DB, firstTimer.VisitDate); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save first-timer"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&firstTimer).Error; err != nil {
+			return err
+		}
+		if err := notifyFirstTimer(tx, firstTimer); err != nil {
+			return err
+		}
+		return welcome.Start(tx, firstTimer)
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid visit date format. Use YYYY-MM-DD"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save first-timer"})
 		return
 	}
 
-	firstTimer := models.FirstTimer{
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "First-timer information submitted successfully. Thank you!",
+	})
+}
+
+// notifyFirstTimer tells visitors welfare about a new first-timer
+func notifyFirstTimer(tx *gorm.DB, ft models.FirstTimer) error {
+	interested := "no"
+	if ft.InterestedInMembership {
+		interested = "yes"
+	}
+	return email.NotifyRole(tx, "visitors_welfare", email.Notification{
+		Type:       email.FirstTimerSubmitted,
+		EntityType: "first_timer",
+		EntityID:   ft.ID,
+		Data: map[string]string{
+			"FirstName":              ft.FirstName,
+			"LastName":               ft.LastName,
+			"VisitDate":              ft.VisitDate.String(),
+			"Phone":                  ft.Phone,
+			"Email":                  ft.Email,
+			"InterestedInMembership": interested,
+		},
+	})
+}
+
+// stopWelcomeIfJoined cancels the rest of the welcome sequence once a first-timer joins
+func stopWelcomeIfJoined(tx *gorm.DB, ft models.FirstTimer) error {
+	if !welcome.Joined(ft) {
+		return nil
+	}
+	return welcome.Stop(tx, ft.ID, "the first-timer joined")
+}
+
+// firstTimerInput is the welcome card, submitted online or synced from the welcome desk
+type firstTimerInput struct {
+	FirstName              string `json:"firstName" binding:"required"`
+	LastName               string `json:"lastName" binding:"required"`
+	Email                  string `json:"email"`
+	Phone                  string `json:"phone"`
+	Address                string `json:"address"`
+	City                   string `json:"city"`
+	State                  string `json:"state"`
+	DateOfBirth            string `json:"dateOfBirth"`
+	Gender                 string `json:"gender"`
+	MaritalStatus          string `json:"maritalStatus"`
+	Occupation             string `json:"occupation"`
+	VisitDate              string `json:"visitDate" binding:"required"` // YYYY-MM-DD
+	HowDidYouHear          string `json:"howDidYouHear"`
+	PrayerRequest          string `json:"prayerRequest"`
+	InterestedInMembership bool   `json:"interestedInMembership"`
+	ContactConsent         bool   `json:"contactConsent"` // Agrees to welcome messages
+}
+
+func (input firstTimerInput) build() (models.FirstTimer, error) {
+	if strings.TrimSpace(input.FirstName) == "" || strings.TrimSpace(input.LastName) == "" {
+		return models.FirstTimer{}, errors.New("firstName and lastName are required")
+	}
+
+	parsedVisitDate, err := localtime.ParseDate(input.VisitDate)
+	if err != nil {
+		return models.FirstTimer{}, errors.New("Invalid visit date format. Use YYYY-MM-DD")
+	}
+
+	return models.FirstTimer{
 		FirstName:              input.FirstName,
 		LastName:               input.LastName,
 		Email:                  input.Email,
@@ -59,27 +131,39 @@ func CreateFirstTimer(c *gin.Context) {
 		HowDidYouHear:          input.HowDidYouHear,
 		PrayerRequest:          input.PrayerRequest,
 		InterestedInMembership: input.InterestedInMembership,
+		ContactConsent:         input.ContactConsent,
 		FollowUpStatus:         "pending",
 		Status:                 "new",
-	}
-
-	if err := database.DB.Create(&firstTimer).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save first-timer"})
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "First-timer information submitted successfully. Thank you!",
-	})
+	}, nil
 }
 
 // Admin: Get all first-timers (sorted latest visit first)
 func AdminGetFirstTimers(c *gin.Context) {
+	db, err := filterFirstTimers(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var firstTimers []models.FirstTimer
-	database.DB.Order("visit_date DESC").Find(&firstTimers)
+	db.Order("visit_date DESC").Find(&firstTimers)
 	c.JSON(http.StatusOK, gin.H{"data": firstTimers})
 }
 
+// Admin: Get a single first-timer, with their return visits and welcome messages
+func AdminGetFirstTimer(c *gin.Context) {
+	var firstTimer models.FirstTimer
+	if err := database.DB.Preload("Visits", func(db *gorm.DB) *gorm.DB {
+		return db.Order("visit_date ASC")
+	}).Preload("WelcomeMessages", func(db *gorm.DB) *gorm.DB {
+		return db.Order("scheduled_for ASC, id ASC")
+	}).First(&firstTimer, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "First-timer not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": firstTimer})
+}
+
 // Admin: Update first-timer (follow-up status, etc.)
 func UpdateFirstTimer(c *gin.Context) {
 	id := c.Param("id")
@@ -91,8 +175,9 @@ func UpdateFirstTimer(c *gin.Context) {
 	}
 
 	var input struct {
-		FollowUpStatus *string `json:"followUpStatus"`
-		Status         *string `json:"status"`
+		FollowUpStatus      *string `json:"followUpStatus"`
+		Status              *string `json:"status"`
+		ServiceOccurrenceID *uint   `json:"serviceOccurrenceId"` // The service of their first visit
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -106,8 +191,22 @@ func UpdateFirstTimer(c *gin.Context) {
 	if input.Status != nil {
 		firstTimer.Status = *input.Status
 	}
+	if input.ServiceOccurrenceID != nil {
+		var occ models.ServiceOccurrence
+		if err := database.DB.First(&occ, *input.ServiceOccurrenceID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown service occurrence"})
+			return
+		}
+		firstTimer.ServiceOccurrenceID = &occ.ID
+	}
 
-	if err := database.DB.Save(&firstTimer).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&firstTimer).Error; err != nil {
+			return err
+		}
+		return stopWelcomeIfJoined(tx, firstTimer)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update first-timer"})
 		return
 	}
@@ -135,3 +234,37 @@ func DeleteFirstTimer(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "First-timer deleted"})
 }
+
+// Admin: Change the follow-up status of, or delete, many first-timers at once
+func BulkUpdateFirstTimers(c *gin.Context) {
+	input, ok := bindBulkRequest(c)
+	if !ok {
+		return
+	}
+
+	switch input.Action {
+	case "followUpStatus":
+		if input.Status == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status is required"})
+			return
+		}
+		runBulk(c, input.IDs, func(tx *gorm.DB, firstTimer *models.FirstTimer) (bulkChange, error) {
+			firstTimer.FollowUpStatus = input.Status
+			change := bulkChange{"Updated first-timer", firstTimer.FirstName + " " + firstTimer.LastName}
+			if err := tx.Save(firstTimer).Error; err != nil {
+				return change, err
+			}
+			return change, stopWelcomeIfJoined(tx, *firstTimer)
+		})
+	case "delete":
+		if !middleware.HasRole(c, "superadmin") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Insufficient permissions"})
+			return
+		}
+		runBulk(c, input.IDs, func(tx *gorm.DB, firstTimer *models.FirstTimer) (bulkChange, error) {
+			return bulkChange{"Deleted first-timer", firstTimer.FirstName + " " + firstTimer.LastName}, tx.Delete(firstTimer).Error
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action. Use 'followUpStatus' or 'delete'"})
+	}
+}
@@ -0,0 +1,193 @@
+// internal/handlers/headcount_category.go
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var headcountKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// loadHeadcountCategories returns every category, including inactive ones, so older
+// records keep their counts. Inactive categories are only hidden from entry forms.
+func loadHeadcountCategories(db *gorm.DB) ([]models.HeadcountCategory, error) {
+	var categories []models.HeadcountCategory
+	err := db.Order("display_order ASC, id ASC").Find(&categories).Error
+	return categories, err
+}
+
+// Admin: List headcount categories (?active=true for entry forms)
+func AdminGetHeadcountCategories(c *gin.Context) {
+	db := database.DB
+	if c.Query("active") == "true" {
+		db = db.Where("active = ?", true)
+	}
+
+	categories, err := loadHeadcountCategories(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load headcount categories"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": categories})
+}
+
+// Admin: Create headcount category
+func CreateHeadcountCategory(c *gin.Context) {
+	var input struct {
+		Key                 string `json:"key" binding:"required"`
+		Name                string `json:"name" binding:"required"`
+		Group               string `json:"group" binding:"required"`
+		CountsTowardTotal   bool   `json:"countsTowardTotal"`
+		ReconcilesWithTotal bool   `json:"reconcilesWithTotal"`
+		DisplayOrder        int    `json:"displayOrder"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := strings.ToLower(strings.TrimSpace(input.Key))
+	if !headcountKeyPattern.MatchString(key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key must start with a letter and use only lowercase letters, digits and underscores"})
+		return
+	}
+	if input.CountsTowardTotal && input.ReconcilesWithTotal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot both count toward and reconcile with the total"})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.HeadcountCategory{}).Where("key = ?", key).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A headcount category with this key already exists"})
+		return
+	}
+
+	category := models.HeadcountCategory{
+		Key:                 key,
+		Name:                strings.TrimSpace(input.Name),
+		GroupName:           strings.ToLower(strings.TrimSpace(input.Group)),
+		CountsTowardTotal:   input.CountsTowardTotal,
+		ReconcilesWithTotal: input.ReconcilesWithTotal,
+		DisplayOrder:        input.DisplayOrder,
+		Active:              true,
+	}
+
+	if err := database.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create headcount category"})
+		return
+	}
+
+	middleware.LogActivity(c, "Created headcount category", category.Name)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Headcount category created",
+		"category": category,
+	})
+}
+
+// Admin: Update headcount category. The key never changes; how a category adds up
+// only applies to attendance saved afterwards.
+func UpdateHeadcountCategory(c *gin.Context) {
+	var category models.HeadcountCategory
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Headcount category not found"})
+		return
+	}
+
+	var input struct {
+		Key                 *string `json:"key"`
+		Name                *string `json:"name"`
+		Group               *string `json:"group"`
+		CountsTowardTotal   *bool   `json:"countsTowardTotal"`
+		ReconcilesWithTotal *bool   `json:"reconcilesWithTotal"`
+		DisplayOrder        *int    `json:"displayOrder"`
+		Active              *bool   `json:"active"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Key != nil && *input.Key != category.Key {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A headcount category's key cannot be changed"})
+		return
+	}
+	if category.BuiltIn && (input.Group != nil || input.CountsTowardTotal != nil || input.ReconcilesWithTotal != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only the name, order and active flag of built-in categories can be changed"})
+		return
+	}
+
+	if input.Name != nil && strings.TrimSpace(*input.Name) != "" {
+		category.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Group != nil && strings.TrimSpace(*input.Group) != "" {
+		category.GroupName = strings.ToLower(strings.TrimSpace(*input.Group))
+	}
+	if input.CountsTowardTotal != nil {
+		category.CountsTowardTotal = *input.CountsTowardTotal
+	}
+	if input.ReconcilesWithTotal != nil {
+		category.ReconcilesWithTotal = *input.ReconcilesWithTotal
+	}
+	if input.DisplayOrder != nil {
+		category.DisplayOrder = *input.DisplayOrder
+	}
+	if input.Active != nil {
+		category.Active = *input.Active
+	}
+
+	if category.CountsTowardTotal && category.ReconcilesWithTotal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A category cannot both count toward and reconcile with the total"})
+		return
+	}
+
+	if err := database.DB.Save(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update headcount category"})
+		return
+	}
+
+	middleware.LogActivity(c, "Updated headcount category", category.Name)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Headcount category updated",
+		"category": category,
+	})
+}
+
+// Admin: Delete headcount category. Categories already used by attendance records
+// can only be deactivated, so historical counts stay intact.
+func DeleteHeadcountCategory(c *gin.Context) {
+	var category models.HeadcountCategory
+	if err := database.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Headcount category not found"})
+		return
+	}
+
+	if category.BuiltIn {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Built-in headcount categories cannot be deleted. Deactivate it instead"})
+		return
+	}
+
+	var used int64
+	database.DB.Model(&models.AttendanceCount{}).Where("category_id = ?", category.ID).Count(&used)
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This category has recorded counts. Deactivate it instead"})
+		return
+	}
+
+	database.DB.Delete(&category)
+	middleware.LogActivity(c, "Deleted headcount category", category.Name)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Headcount category deleted"})
+}
@@ -0,0 +1,133 @@
+// internal/handlers/import.go
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/importer"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Largest CSV accepted through the API; bigger archives should go through the import CLI
+const maxImportUpload = 10 << 20
+
+// Admin: Import attendance from CSV (POST /api/admin/attendance/import)
+func ImportAttendance(c *gin.Context) {
+	runImport(c, models.ImportKindAttendance)
+}
+
+// Admin: Import first-timers from CSV (POST /api/admin/first-timers/import)
+func ImportFirstTimers(c *gin.Context) {
+	runImport(c, models.ImportKindFirstTimers)
+}
+
+// runImport reads a multipart upload:
+//
+//	file      - the CSV (required)
+//	mapping   - JSON object of field -> CSV header, e.g. {"visitDate": "Timestamp"}
+//	dateOrder - "dmy" (default) or "mdy"
+//	dryRun    - "true" to only validate and report
+func runImport(c *gin.Context, kind string) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUpload)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A CSV file is required (max 10 MB)"})
+		return
+	}
+
+	var mapping map[string]string
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object of field to column name"})
+			return
+		}
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultPostForm("dryRun", "false"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	report, err := importer.Run(database.DB, file, importer.Options{
+		Kind:      kind,
+		Mapping:   mapping,
+		DateOrder: c.PostForm("dateOrder"),
+		DryRun:    dryRun,
+		Filename:  fileHeader.Filename,
+		CreatedBy: c.GetString("adminEmail"),
+	})
+	if err != nil {
+		if errors.Is(err, importer.ErrInvalidFile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed. No records were saved"})
+		return
+	}
+
+	if report.Committed {
+		middleware.LogActivity(c, "Imported "+kind, fmt.Sprintf("Batch #%d: %d rows from %s", report.BatchID, report.Imported, fileHeader.Filename))
+	}
+
+	status := http.StatusOK
+	if !dryRun && len(report.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, gin.H{"data": report})
+}
+
+// Admin: List import batches (latest first)
+func AdminGetImportBatches(c *gin.Context) {
+	db := database.DB.Model(&models.ImportBatch{})
+	if kind := c.Query("kind"); kind != "" {
+		db = db.Where("kind = ?", kind)
+	}
+
+	var batches []models.ImportBatch
+	db.Order("created_at DESC").Find(&batches)
+	c.JSON(http.StatusOK, gin.H{"data": batches})
+}
+
+// Admin: Roll back an import batch, deleting every row it created (superadmin only)
+func RollbackImportBatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import batch id"})
+		return
+	}
+
+	batch, removed, err := importer.Rollback(database.DB, uint(id), c.GetString("adminEmail"))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Import batch not found"})
+		case errors.Is(err, importer.ErrAlreadyRolledBack):
+			c.JSON(http.StatusConflict, gin.H{"error": "Import batch was already rolled back"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back import"})
+		}
+		return
+	}
+
+	middleware.LogActivity(c, "Rolled back "+batch.Kind+" import", fmt.Sprintf("Batch #%d: %d rows removed", batch.ID, removed))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import rolled back",
+		"removed": removed,
+		"batch":   batch,
+	})
+}
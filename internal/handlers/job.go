@@ -0,0 +1,45 @@
+// internal/handlers/job.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/scheduler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin: List background jobs with their last run (superadmin only)
+// GET /api/admin/jobs
+func AdminGetJobs(c *gin.Context) {
+	jobs, err := scheduler.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load jobs"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": jobs})
+}
+
+// Admin: Run a background job now, outside its schedule (superadmin only)
+// POST /api/admin/jobs/:name/run
+func RunJob(c *gin.Context) {
+	name := c.Param("name")
+	err := scheduler.RunNow(name, c.GetString("adminEmail"))
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	case errors.Is(err, scheduler.ErrJobRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start job"})
+		return
+	}
+
+	middleware.LogActivity(c, "Ran job", name)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Job started. Check the job list for the result"})
+}
@@ -5,12 +5,16 @@ import (
 	"net/http"
 
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/email"
 	"rccg-salvation-centre-backend/internal/middleware"
 	"rccg-salvation-centre-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+var prayerRequestStatuses = map[string]bool{"pending": true, "prayed": true, "archived": true}
+
 // Public: Submit prayer request
 func CreatePrayerRequest(c *gin.Context) {
 	var input struct {
@@ -31,7 +35,18 @@ func CreatePrayerRequest(c *gin.Context) {
 		Status:  "pending",
 	}
 
-	if err := database.DB.Create(&prayer).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&prayer).Error; err != nil {
+			return err
+		}
+		return email.NotifyRole(tx, "secretariat", email.Notification{
+			Type:       email.PrayerRequestSubmitted,
+			EntityType: "prayer_request",
+			EntityID:   prayer.ID,
+			Data:       map[string]string{"Name": prayer.Name, "Email": prayer.Email, "Request": prayer.Request},
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save prayer request"})
 		return
 	}
@@ -45,8 +60,14 @@ func CreatePrayerRequest(c *gin.Context) {
 
 // Admin: Get all prayer requests
 func AdminGetPrayerRequests(c *gin.Context) {
+	db, err := filterPrayerRequests(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var requests []models.PrayerRequest
-	database.DB.Order("submitted_at DESC").Find(&requests)
+	db.Order("submitted_at DESC").Find(&requests)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -54,6 +75,20 @@ func AdminGetPrayerRequests(c *gin.Context) {
 	})
 }
 
+// Admin: Get a single prayer request
+func AdminGetPrayerRequest(c *gin.Context) {
+	var request models.PrayerRequest
+	if err := database.DB.First(&request, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prayer request not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    request,
+	})
+}
+
 // Admin: Update prayer request status (or details)
 func UpdatePrayerRequest(c *gin.Context) {
 	id := c.Param("id")
@@ -85,8 +120,7 @@ func UpdatePrayerRequest(c *gin.Context) {
 		request.Request = *input.Request
 	}
 	if input.Status != nil {
-		allowed := map[string]bool{"pending": true, "prayed": true, "archived": true}
-		if !allowed[*input.Status] {
+		if !prayerRequestStatuses[*input.Status] {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
 			return
 		}
@@ -125,3 +159,29 @@ func DeletePrayerRequest(c *gin.Context) {
 		"message": "Prayer request deleted",
 	})
 }
+
+// Admin: Change the status of, or delete, many prayer requests at once
+func BulkUpdatePrayerRequests(c *gin.Context) {
+	input, ok := bindBulkRequest(c)
+	if !ok {
+		return
+	}
+
+	switch input.Action {
+	case "status":
+		if !prayerRequestStatuses[input.Status] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		runBulk(c, input.IDs, func(tx *gorm.DB, request *models.PrayerRequest) (bulkChange, error) {
+			request.Status = input.Status
+			return bulkChange{"Updated prayer request", request.Name + " (" + input.Status + ")"}, tx.Save(request).Error
+		})
+	case "delete":
+		runBulk(c, input.IDs, func(tx *gorm.DB, request *models.PrayerRequest) (bulkChange, error) {
+			return bulkChange{"Deleted prayer request", request.Name}, tx.Delete(request).Error
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action. Use 'status' or 'delete'"})
+	}
+}
@@ -0,0 +1,219 @@
+// internal/handlers/program_exception.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/schedule"
+
+	"github.com/gin-gonic/gin"
+)
+
+type programExceptionInput struct {
+	Date         *string `json:"date"` // YYYY-MM-DD, the occurrence's usual date (required on create)
+	Cancelled    *bool   `json:"cancelled"`
+	NewDate      *string `json:"newDate"` // YYYY-MM-DD; "" keeps the usual date
+	NewStartTime *string `json:"newStartTime"`
+	NewEndTime   *string `json:"newEndTime"`
+	Location     *string `json:"location"`
+	Reason       *string `json:"reason"` // Shown publicly
+	Note         *string `json:"note"`
+}
+
+// apply copies the given fields onto e
+func (input programExceptionInput) apply(e *models.ProgramException) error {
+	if input.Date != nil {
+		date, err := parseOptionalDate("date", *input.Date)
+		if err != nil || date == nil {
+			return errors.New("date is required. Use YYYY-MM-DD")
+		}
+		e.Date = *date
+	}
+	if input.Cancelled != nil {
+		e.Cancelled = *input.Cancelled
+	}
+	if input.NewDate != nil {
+		newDate, err := parseOptionalDate("newDate", *input.NewDate)
+		if err != nil {
+			return err
+		}
+		e.NewDate = newDate
+	}
+	if input.NewStartTime != nil {
+		e.NewStartTime = *input.NewStartTime
+	}
+	if input.NewEndTime != nil {
+		e.NewEndTime = *input.NewEndTime
+	}
+	if input.Location != nil {
+		e.Location = *input.Location
+	}
+	if input.Reason != nil {
+		e.Reason = *input.Reason
+	}
+	if input.Note != nil {
+		e.Note = *input.Note
+	}
+	return nil
+}
+
+// validateProgramException checks the exception names a real occurrence of the
+// program and makes a sensible change to it
+func validateProgramException(program models.RegularProgram, e models.ProgramException) error {
+	series, err := schedule.ForProgram(program)
+	if err != nil {
+		return errors.New("This program has no valid schedule yet: " + err.Error())
+	}
+	original, ok := series.Occurs(e.Date)
+	if !ok {
+		return errors.New("The program does not run on " + e.Date.String())
+	}
+
+	if !validClock(e.NewStartTime) || !validClock(e.NewEndTime) {
+		return errors.New("newStartTime and newEndTime must be HH:MM")
+	}
+	if e.Cancelled && (e.Rescheduled() || e.NewEndTime != "" || e.Location != "") {
+		return errors.New("A cancelled occurrence cannot also be moved")
+	}
+	if series.AllDay && e.NewStartTime == "" && e.NewEndTime != "" {
+		return errors.New("newEndTime needs a newStartTime for a program without a start time")
+	}
+	if !e.Cancelled && !e.Rescheduled() && e.NewEndTime == "" && e.Location == "" && e.Reason == "" && e.Note == "" {
+		return errors.New("Set cancelled, a new date or time, a location, a reason or a note")
+	}
+	if e.Rescheduled() {
+		if _, _, err := series.Moved(e, original); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Admin: List a program's exceptions
+func AdminGetProgramExceptions(c *gin.Context) {
+	var program models.RegularProgram
+	if err := database.DB.First(&program, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	var exceptions []models.ProgramException
+	database.DB.Where("program_id = ?", program.ID).Order("date ASC").Find(&exceptions)
+	c.JSON(http.StatusOK, gin.H{"data": exceptions})
+}
+
+// Admin: Cancel, move, relocate or annotate one occurrence of a program
+func CreateProgramException(c *gin.Context) {
+	var program models.RegularProgram
+	if err := database.DB.First(&program, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	var input programExceptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Date == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is required"})
+		return
+	}
+
+	exception := models.ProgramException{ProgramID: program.ID, CreatedBy: c.GetString("adminEmail")}
+	if err := input.apply(&exception); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateProgramException(program, exception); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.ProgramException{}).
+		Where("program_id = ? AND date = ?", program.ID, exception.Date).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This occurrence already has an exception. Update it instead"})
+		return
+	}
+
+	if err := database.DB.Create(&exception).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exception"})
+		return
+	}
+
+	middleware.LogActivity(c, "Created program exception", program.Title+" on "+exception.Date.String())
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Exception saved",
+		"exception": exception,
+	})
+}
+
+// Admin: Update an exception
+func UpdateProgramException(c *gin.Context) {
+	var program models.RegularProgram
+	if err := database.DB.First(&program, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+	var exception models.ProgramException
+	if err := database.DB.Where("program_id = ?", program.ID).First(&exception, c.Param("exceptionId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exception not found"})
+		return
+	}
+
+	var input programExceptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Date != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date cannot be changed. Delete the exception and create another"})
+		return
+	}
+	if err := input.apply(&exception); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateProgramException(program, exception); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Save(&exception).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exception"})
+		return
+	}
+
+	middleware.LogActivity(c, "Updated program exception", program.Title+" on "+exception.Date.String())
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Exception updated",
+		"exception": exception,
+	})
+}
+
+// Admin: Delete an exception, restoring the usual occurrence
+func DeleteProgramException(c *gin.Context) {
+	var program models.RegularProgram
+	if err := database.DB.First(&program, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+	var exception models.ProgramException
+	if err := database.DB.Where("program_id = ?", program.ID).First(&exception, c.Param("exceptionId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exception not found"})
+		return
+	}
+
+	database.DB.Delete(&exception)
+	middleware.LogActivity(c, "Deleted program exception", program.Title+" on "+exception.Date.String())
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exception deleted"})
+}
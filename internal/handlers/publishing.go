@@ -0,0 +1,68 @@
+// internal/handlers/publishing.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// publishingInput schedules when content goes live and comes down. It is sent
+// alongside "published".
+type publishingInput struct {
+	PublishAt   *string `json:"publishAt"`   // RFC 3339; "" to clear
+	UnpublishAt *string `json:"unpublishAt"` // RFC 3339; "" to clear
+}
+
+// apply sets p from published (when sent) and the schedule. A publishAt that has
+// already passed publishes straight away; one still to come keeps the content
+// hidden until then.
+func (input publishingInput) apply(p *models.Publishing, published *bool, now time.Time) error {
+	if published != nil {
+		p.SetPublished(*published, now)
+	}
+	if input.PublishAt != nil {
+		publishAt, err := parseOptionalTimestamp("publishAt", *input.PublishAt)
+		if err != nil {
+			return err
+		}
+		switch {
+		case publishAt == nil:
+			p.PublishAt = nil
+		case publishAt.After(now):
+			p.Published, p.PublishAt = false, publishAt
+		default:
+			p.SetPublished(true, now)
+		}
+	}
+	if input.UnpublishAt != nil {
+		unpublishAt, err := parseOptionalTimestamp("unpublishAt", *input.UnpublishAt)
+		if err != nil {
+			return err
+		}
+		p.UnpublishAt = unpublishAt
+	}
+	if p.PublishAt != nil && p.UnpublishAt != nil && !p.UnpublishAt.After(*p.PublishAt) {
+		return errors.New("unpublishAt must be after publishAt")
+	}
+	return nil
+}
+
+// filterPublishState narrows an admin list by ?publishState=draft|scheduled|published|expired,
+// responding 400 to anything else
+func filterPublishState(c *gin.Context, db *gorm.DB) (*gorm.DB, bool) {
+	state := c.Query("publishState")
+	switch state {
+	case "":
+		return db, true
+	case models.PublishDraft, models.PublishScheduled, models.PublishLive, models.PublishExpired:
+		return db.Scopes(models.InPublishState(state, time.Now())), true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid publishState. Use 'draft', 'scheduled', 'published' or 'expired'"})
+	return nil, false
+}
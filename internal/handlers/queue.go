@@ -0,0 +1,77 @@
+// internal/handlers/queue.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/queue"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Admin: List queued background work, latest first, with counts by type and status
+// (superadmin only)
+// GET /api/admin/queue?status=&type=&limit=
+func AdminGetQueuedJobs(c *gin.Context) {
+	db := database.DB.Model(&models.QueuedJob{})
+	if status := c.Query("status"); status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if jobType := c.Query("type"); jobType != "" {
+		db = db.Where("type = ?", jobType)
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	var jobs []models.QueuedJob
+	db.Order("created_at DESC, id DESC").Limit(limit).Find(&jobs)
+
+	var summary []struct {
+		Type   string `json:"type"`
+		Status string `json:"status"`
+		Count  int    `json:"count"`
+	}
+	database.DB.Model(&models.QueuedJob{}).Select("type, status, COUNT(*) AS count").
+		Group("type, status").Order("type, status").Scan(&summary)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    jobs,
+		"summary": summary,
+		"types":   queue.Types(),
+	})
+}
+
+// Admin: Put a dead job back on the queue (superadmin only)
+// POST /api/admin/queue/:id/retry
+func RetryQueuedJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	job, err := queue.Retry(database.DB, uint(id))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	case errors.Is(err, queue.ErrNotDead):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": job.Status})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry job"})
+		return
+	}
+
+	middleware.LogActivity(c, "Retried queued job", job.Type+" #"+c.Param("id"))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job queued again"})
+}
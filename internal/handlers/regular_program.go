@@ -2,13 +2,21 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
 	"rccg-salvation-centre-backend/internal/middleware"
 	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/recurrence"
+	"rccg-salvation-centre-backend/internal/schedule"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Public: Get active regular programs
@@ -18,6 +26,37 @@ func GetRegularPrograms(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": programs})
 }
 
+// Public: Next occurrences across all active programs (?limit=20&days=90&type=)
+func GetUpcomingProgramOccurrences(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days < 1 || days > 366 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 366"})
+		return
+	}
+
+	db := database.DB.Preload("Exceptions").Where("active = ? AND rrule <> ''", true)
+	if programType := c.Query("type"); programType != "" {
+		db = db.Where("LOWER(type) = ?", strings.ToLower(programType))
+	}
+	var programs []models.RegularProgram
+	if err := db.Find(&programs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load programs"})
+		return
+	}
+
+	now := time.Now()
+	occurrences := schedule.Upcoming(programs, now, now.AddDate(0, 0, days), limit)
+	c.JSON(http.StatusOK, gin.H{
+		"data":  occurrences,
+		"count": len(occurrences),
+	})
+}
+
 // Admin: Get all regular programs
 func AdminGetRegularPrograms(c *gin.Context) {
 	var programs []models.RegularProgram
@@ -25,17 +64,103 @@ func AdminGetRegularPrograms(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": programs})
 }
 
+// Admin: Get a single regular program
+func AdminGetRegularProgram(c *gin.Context) {
+	var program models.RegularProgram
+	err := database.DB.Preload("Exceptions", func(db *gorm.DB) *gorm.DB { return db.Order("date ASC") }).
+		First(&program, c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": program})
+}
+
+// parseOptionalDate reads a YYYY-MM-DD value; an empty string clears the date
+func parseOptionalDate(field, value string) (*localtime.Date, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := localtime.ParseDate(value)
+	if err != nil {
+		return nil, errors.New("Invalid " + field + ". Use YYYY-MM-DD")
+	}
+	return &parsed, nil
+}
+
+// applyProgramSchedule validates the structured schedule and fills whichever of the
+// rule and the display text is missing from the other. With no rrule, the rule is
+// read from Day and Frequency, and must be unambiguous.
+func applyProgramSchedule(program *models.RegularProgram) error {
+	if program.RRule == "" {
+		rule, notes := recurrence.ParseText(program.Day, program.Frequency)
+		if rule.Freq == "" || len(notes) > 0 {
+			return errors.New("Could not work out the schedule from day and frequency (" +
+				strings.Join(notes, "; ") + "). Send an rrule such as FREQ=WEEKLY;BYDAY=SU")
+		}
+		program.RRule = rule.String()
+	}
+	rule, err := recurrence.Parse(program.RRule)
+	if err != nil {
+		return errors.New("Invalid rrule: " + err.Error())
+	}
+	program.RRule = rule.String()
+
+	if program.StartTime == "" && program.Time != "" {
+		start, end, err := recurrence.ParseTimeRange(program.Time)
+		if err != nil {
+			return errors.New("Could not read a start time from time; send startTime as HH:MM")
+		}
+		program.StartTime, program.EndTime = start, end
+	}
+	if !validClock(program.StartTime) || !validClock(program.EndTime) {
+		return errors.New("startTime and endTime must be HH:MM")
+	}
+	if program.EndTime != "" && program.StartTime == "" {
+		return errors.New("endTime needs a startTime")
+	}
+	if program.Timezone == "" {
+		program.Timezone = localtime.Location().String()
+	}
+
+	if program.Day == "" {
+		program.Day = rule.Days()
+	}
+	if program.Frequency == "" {
+		program.Frequency = rule.Describe()
+	}
+	if program.Time == "" && program.StartTime != "" {
+		program.Time = program.StartTime
+		if program.EndTime != "" {
+			program.Time += " - " + program.EndTime
+		}
+	}
+
+	program.RecurrenceNeedsReview = false
+	program.RecurrenceNote = ""
+	_, err = schedule.ForProgram(*program)
+	return err
+}
+
 // Admin: Create regular program
+// The schedule is given as an rrule with startTime/endTime, or as day and frequency text
+// that can be read unambiguously (e.g. "Sunday", "Weekly")
 func CreateRegularProgram(c *gin.Context) {
 	var input struct {
 		Title       string `json:"title" binding:"required"`
 		Description string `json:"description"`
-		Day         string `json:"day" binding:"required"`
-		Frequency   string `json:"frequency" binding:"required"`
+		Day         string `json:"day"`
+		Frequency   string `json:"frequency"`
 		Time        string `json:"time"`
 		Location    string `json:"location"`
 		Type        string `json:"type" binding:"required"` // ADDED
 		Active      bool   `json:"active"`
+		RRule       string `json:"rrule"`
+		StartTime   string `json:"startTime"`
+		EndTime     string `json:"endTime"`
+		Timezone    string `json:"timezone"`
+		StartsOn    string `json:"startsOn"` // YYYY-MM-DD
+		EndsOn      string `json:"endsOn"`   // YYYY-MM-DD
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -52,6 +177,24 @@ func CreateRegularProgram(c *gin.Context) {
 		Location:    input.Location,
 		Type:        input.Type, // ADDED
 		Active:      input.Active,
+		RRule:       input.RRule,
+		StartTime:   input.StartTime,
+		EndTime:     input.EndTime,
+		Timezone:    input.Timezone,
+	}
+
+	var err error
+	if program.StartsOn, err = parseOptionalDate("startsOn", input.StartsOn); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if program.EndsOn, err = parseOptionalDate("endsOn", input.EndsOn); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyProgramSchedule(&program); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if err := database.DB.Create(&program).Error; err != nil {
@@ -86,6 +229,12 @@ func UpdateRegularProgram(c *gin.Context) {
 		Location    *string `json:"location"`
 		Type        *string `json:"type"` // ADDED
 		Active      *bool   `json:"active"`
+		RRule       *string `json:"rrule"`
+		StartTime   *string `json:"startTime"`
+		EndTime     *string `json:"endTime"`
+		Timezone    *string `json:"timezone"`
+		StartsOn    *string `json:"startsOn"` // "" clears
+		EndsOn      *string `json:"endsOn"`   // "" clears
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -99,15 +248,6 @@ func UpdateRegularProgram(c *gin.Context) {
 	if input.Description != nil {
 		program.Description = *input.Description
 	}
-	if input.Day != nil {
-		program.Day = *input.Day
-	}
-	if input.Frequency != nil {
-		program.Frequency = *input.Frequency
-	}
-	if input.Time != nil {
-		program.Time = *input.Time
-	}
 	if input.Location != nil {
 		program.Location = *input.Location
 	}
@@ -118,6 +258,80 @@ func UpdateRegularProgram(c *gin.Context) {
 		program.Active = *input.Active
 	}
 
+	// Changing the text without a new rule means the rule is read from the text again,
+	// and a new rule without new text regenerates the text
+	scheduleChanged := false
+	if input.Day != nil || input.Frequency != nil {
+		if input.Day != nil {
+			program.Day = *input.Day
+		}
+		if input.Frequency != nil {
+			program.Frequency = *input.Frequency
+		}
+		if input.RRule == nil {
+			program.RRule = ""
+		}
+		scheduleChanged = true
+	}
+	if input.RRule != nil {
+		program.RRule = *input.RRule
+		if input.Day == nil {
+			program.Day = ""
+		}
+		if input.Frequency == nil {
+			program.Frequency = ""
+		}
+		scheduleChanged = true
+	}
+	if input.Time != nil {
+		program.Time = *input.Time
+		if input.StartTime == nil {
+			program.StartTime, program.EndTime = "", ""
+		}
+		scheduleChanged = true
+	}
+	if input.StartTime != nil || input.EndTime != nil {
+		if input.StartTime != nil {
+			program.StartTime = *input.StartTime
+		}
+		if input.EndTime != nil {
+			program.EndTime = *input.EndTime
+		}
+		if input.Time == nil {
+			program.Time = ""
+		}
+		scheduleChanged = true
+	}
+	if input.Timezone != nil {
+		program.Timezone = *input.Timezone
+		scheduleChanged = true
+	}
+	if input.StartsOn != nil {
+		startsOn, err := parseOptionalDate("startsOn", *input.StartsOn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		program.StartsOn = startsOn
+		scheduleChanged = true
+	}
+	if input.EndsOn != nil {
+		endsOn, err := parseOptionalDate("endsOn", *input.EndsOn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		program.EndsOn = endsOn
+		scheduleChanged = true
+	}
+
+	if scheduleChanged {
+		if err := applyProgramSchedule(&program); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	if err := database.DB.Save(&program).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update program"})
 		return
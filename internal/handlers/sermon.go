@@ -6,10 +6,14 @@ import (
 	"time"
 
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
 	"rccg-salvation-centre-backend/internal/middleware"
 	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/occurrence"
+	"rccg-salvation-centre-backend/internal/slug"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 /*
@@ -19,7 +23,7 @@ PUBLIC ENDPOINTS
 // GET /api/sermons
 func GetSermons(c *gin.Context) {
 	var sermons []models.Sermon
-	database.DB.Where("published = ?", true).
+	database.DB.Scopes(models.Visible(time.Now())).
 		Order("date DESC, created_at DESC"). // ADD created_at DESC as secondary sort
 		Find(&sermons)
 
@@ -32,7 +36,7 @@ func GetSermons(c *gin.Context) {
 // GET /api/sermons/latest
 func GetLatestSermon(c *gin.Context) {
 	var sermon models.Sermon
-	err := database.DB.Where("published = ?", true).
+	err := database.DB.Scopes(models.Visible(time.Now())).
 		Order("created_at DESC"). // Changed from "date DESC" to "created_at DESC"
 		First(&sermon).Error
 
@@ -57,7 +61,7 @@ func SearchSermons(c *gin.Context) {
 
 	// Public: only published sermons
 	if c.GetString("adminEmail") == "" {
-		db = db.Where("published = ?", true)
+		db = db.Scopes(models.Visible(time.Now()))
 	}
 
 	db = db.Where("title ILIKE ? OR pastor ILIKE ? OR description ILIKE ?",
@@ -72,21 +76,58 @@ func SearchSermons(c *gin.Context) {
 	})
 }
 
+// GET /api/sermons/:slug
+// Public sermon page; retired slugs redirect to the current one
+func GetSermonBySlug(c *gin.Context) {
+	s := c.Param("slug")
+
+	var sermon models.Sermon
+	err := database.DB.Scopes(models.Visible(time.Now())).Where("slug = ?", s).First(&sermon).Error
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{"data": sermon})
+		return
+	}
+
+	current, err := slug.Resolve(database.DB, &models.Sermon{}, models.SlugEntitySermon, s)
+	if err == nil {
+		err = database.DB.Scopes(models.Visible(time.Now())).Where("slug = ?", current).First(&sermon).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sermon not found"})
+		return
+	}
+	c.Redirect(http.StatusMovedPermanently, "/api/sermons/"+current)
+}
+
 /*
 ADMIN ENDPOINTS (Protected)
 */
 
-// GET /api/admin/sermons
-// Admin sees all sermons (including drafts)
+// GET /api/admin/sermons?publishState=
+// Admin sees all sermons (including drafts and scheduled ones)
 func AdminGetSermons(c *gin.Context) {
+	db, ok := filterPublishState(c, database.DB)
+	if !ok {
+		return
+	}
 	var sermons []models.Sermon
-	database.DB.Order("date DESC").Find(&sermons)
+	db.Order("date DESC").Find(&sermons)
 	c.JSON(http.StatusOK, gin.H{
 		"data":  sermons,
 		"count": len(sermons),
 	})
 }
 
+// GET /api/admin/sermons/:id
+func AdminGetSermon(c *gin.Context) {
+	var sermon models.Sermon
+	if err := database.DB.First(&sermon, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sermon not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sermon})
+}
+
 // POST /api/admin/sermons
 // Create new sermon (media_team or superadmin)
 func CreateSermon(c *gin.Context) {
@@ -101,6 +142,7 @@ func CreateSermon(c *gin.Context) {
 		Duration    string `json:"duration"`
 		Description string `json:"description"`
 		Published   bool   `json:"published"`
+		publishingInput
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -109,7 +151,7 @@ func CreateSermon(c *gin.Context) {
 	}
 
 	// Validate date
-	parsedDate, err := time.Parse("2006-01-02", input.Date)
+	parsedDate, err := localtime.ParseDate(input.Date)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
 		return
@@ -130,10 +172,24 @@ func CreateSermon(c *gin.Context) {
 		YoutubeID:   input.YoutubeID,
 		Duration:    input.Duration,
 		Description: input.Description,
-		Published:   input.Published,
+	}
+	if err := input.publishingInput.apply(&sermon.Publishing, &input.Published, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := database.DB.Create(&sermon).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		s, err := slug.Assign(tx, &models.Sermon{}, models.SlugEntitySermon, 0, "", sermon.Title, sermon.Date.Time)
+		if err != nil {
+			return err
+		}
+		sermon.Slug = s
+		if sermon.ServiceOccurrenceID, err = occurrence.ForServiceName(tx, sermon.Date, sermon.Service); err != nil {
+			return err
+		}
+		return tx.Create(&sermon).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save sermon"})
 		return
 	}
@@ -166,6 +222,9 @@ func UpdateSermon(c *gin.Context) {
 		Duration    *string `json:"duration"`
 		Description *string `json:"description"`
 		Published   *bool   `json:"published"`
+		publishingInput
+		// Links the sermon to a specific service; otherwise it follows service and date
+		ServiceOccurrenceID *uint `json:"serviceOccurrenceId"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -184,7 +243,7 @@ func UpdateSermon(c *gin.Context) {
 		sermon.Service = *input.Service
 	}
 	if input.Date != nil {
-		if parsed, err := time.Parse("2006-01-02", *input.Date); err == nil {
+		if parsed, err := localtime.ParseDate(*input.Date); err == nil {
 			sermon.Date = parsed
 		}
 	}
@@ -203,11 +262,37 @@ func UpdateSermon(c *gin.Context) {
 	if input.Description != nil {
 		sermon.Description = *input.Description
 	}
-	if input.Published != nil {
-		sermon.Published = *input.Published
+	if err := input.publishingInput.apply(&sermon.Publishing, input.Published, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	if input.ServiceOccurrenceID != nil {
+		var occ models.ServiceOccurrence
+		if err := database.DB.First(&occ, *input.ServiceOccurrenceID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown service occurrence"})
+			return
+		}
+		sermon.ServiceOccurrenceID = &occ.ID
+	}
+	relink := input.ServiceOccurrenceID == nil && (input.Service != nil || input.Date != nil || sermon.ServiceOccurrenceID == nil)
 
-	database.DB.Save(&sermon)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		s, err := slug.Assign(tx, &models.Sermon{}, models.SlugEntitySermon, sermon.ID, sermon.Slug, sermon.Title, sermon.Date.Time)
+		if err != nil {
+			return err
+		}
+		sermon.Slug = s
+		if relink {
+			if sermon.ServiceOccurrenceID, err = occurrence.ForServiceName(tx, sermon.Date, sermon.Service); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&sermon).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sermon"})
+		return
+	}
 	middleware.LogActivity(c, adminEmail, "Updated sermon")
 
 	c.JSON(http.StatusOK, gin.H{
@@ -227,8 +312,54 @@ func DeleteSermon(c *gin.Context) {
 		return
 	}
 
-	database.DB.Delete(&sermon)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("entity = ? AND target_id = ?", models.SlugEntitySermon, sermon.ID).
+			Delete(&models.SlugRedirect{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&sermon).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sermon"})
+		return
+	}
 	middleware.LogActivity(c, adminEmail, "Deleted sermon")
 
 	c.JSON(http.StatusOK, gin.H{"message": "Sermon deleted permanently"})
 }
+
+// POST /api/admin/sermons/bulk
+// Publish, unpublish or delete many sermons at once (delete is superadmin only)
+func BulkUpdateSermons(c *gin.Context) {
+	input, ok := bindBulkRequest(c)
+	if !ok {
+		return
+	}
+
+	switch input.Action {
+	case "publish", "unpublish":
+		published := input.Action == "publish"
+		action := "Published sermon"
+		if !published {
+			action = "Unpublished sermon"
+		}
+		runBulk(c, input.IDs, func(tx *gorm.DB, sermon *models.Sermon) (bulkChange, error) {
+			sermon.SetPublished(published, time.Now())
+			return bulkChange{action, sermon.Title}, tx.Save(sermon).Error
+		})
+	case "delete":
+		if !middleware.HasRole(c, "superadmin") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Insufficient permissions"})
+			return
+		}
+		runBulk(c, input.IDs, func(tx *gorm.DB, sermon *models.Sermon) (bulkChange, error) {
+			if err := tx.Where("entity = ? AND target_id = ?", models.SlugEntitySermon, sermon.ID).
+				Delete(&models.SlugRedirect{}).Error; err != nil {
+				return bulkChange{}, err
+			}
+			return bulkChange{"Deleted sermon", sermon.Title}, tx.Delete(sermon).Error
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action. Use 'publish', 'unpublish' or 'delete'"})
+	}
+}
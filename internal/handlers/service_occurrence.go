@@ -0,0 +1,355 @@
+// internal/handlers/service_occurrence.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Roles that see offering amounts and first-timer contact details in a service summary
+var (
+	offeringRoles   = []string{"superadmin", "secretariat"}
+	firstTimerRoles = []string{"superadmin", "visitors_welfare"}
+)
+
+// validClock accepts an empty value or a 24-hour HH:MM time
+func validClock(value string) bool {
+	if value == "" {
+		return true
+	}
+	_, err := time.Parse("15:04", value)
+	return err == nil
+}
+
+// Admin: List service occurrences (?from=&to=&serviceTypeId=)
+func AdminGetServiceOccurrences(c *gin.Context) {
+	db := database.DB.Model(&models.ServiceOccurrence{})
+	if serviceTypeID := c.Query("serviceTypeId"); serviceTypeID != "" {
+		db = db.Where("service_type_id = ?", serviceTypeID)
+	}
+	db, err := dateRange(c, db, "date", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var occurrences []models.ServiceOccurrence
+	db.Order("date DESC, start_time ASC").Find(&occurrences)
+	c.JSON(http.StatusOK, gin.H{"data": occurrences})
+}
+
+// Admin: Everything recorded about one service - headcount, sermons, first-timers,
+// returning visitors and offerings. Offering amounts and first-timer details are only
+// included for the roles that handle them; everyone else gets the totals.
+func AdminGetServiceOccurrence(c *gin.Context) {
+	var occ models.ServiceOccurrence
+	if err := database.DB.First(&occ, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	var attendance *models.Attendance
+	var record models.Attendance
+	if err := database.DB.Preload("Counts").Where("service_occurrence_id = ?", occ.ID).First(&record).Error; err == nil {
+		attendance = &record
+	}
+
+	var sermons []models.Sermon
+	database.DB.Where("service_occurrence_id = ?", occ.ID).Order("id ASC").Find(&sermons)
+
+	var firstTimers []models.FirstTimer
+	database.DB.Where("service_occurrence_id = ?", occ.ID).Order("last_name ASC, first_name ASC").Find(&firstTimers)
+
+	var visits []models.FirstTimerVisit
+	database.DB.Where("service_occurrence_id = ?", occ.ID).Order("id ASC").Find(&visits)
+
+	firstTimerSummary := gin.H{"count": len(firstTimers)}
+	returningSummary := gin.H{"count": len(visits)}
+	if middleware.HasRole(c, firstTimerRoles...) {
+		firstTimerSummary["people"] = firstTimers
+
+		ids := make([]uint, 0, len(visits))
+		for _, v := range visits {
+			ids = append(ids, v.FirstTimerID)
+		}
+		var returning []models.FirstTimer
+		if len(ids) > 0 {
+			database.DB.Where("id IN ?", ids).Order("last_name ASC, first_name ASC").Find(&returning)
+		}
+		returningSummary["people"] = returning
+	}
+
+	var offerings []models.Offering
+	database.DB.Where("service_occurrence_id = ?", occ.ID).Order("kind ASC, id ASC").Find(&offerings)
+	offeringSummary := gin.H{"count": len(offerings)}
+	if middleware.HasRole(c, offeringRoles...) {
+		totals := map[string]int64{}
+		byKind := map[string]int64{}
+		for _, o := range offerings {
+			totals[o.Currency] += o.AmountMinor
+			byKind[o.Kind] += o.AmountMinor
+		}
+		offeringSummary["totalsMinor"] = totals
+		offeringSummary["byKindMinor"] = byKind
+		offeringSummary["items"] = offerings
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"service":           occ,
+		"attendance":        attendance,
+		"sermons":           sermons,
+		"firstTimers":       firstTimerSummary,
+		"returningVisitors": returningSummary,
+		"offerings":         offeringSummary,
+	}})
+}
+
+// Admin: Create a service occurrence ahead of time, e.g. to record the minister and theme
+func CreateServiceOccurrence(c *gin.Context) {
+	var input struct {
+		Date          string `json:"date" binding:"required"` // YYYY-MM-DD
+		ServiceTypeID uint   `json:"serviceTypeId"`
+		ServiceType   string `json:"serviceType"`
+		StartTime     string `json:"startTime"` // HH:MM
+		EndTime       string `json:"endTime"`   // HH:MM
+		Minister      string `json:"minister"`
+		Theme         string `json:"theme"`
+		Notes         string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date, err := localtime.ParseDate(input.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+	if !validClock(input.StartTime) || !validClock(input.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format. Use HH:MM"})
+		return
+	}
+	serviceType, err := resolveServiceType(database.DB, input.ServiceTypeID, input.ServiceType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing models.ServiceOccurrence
+	if err := database.DB.Where("date = ? AND service_type_id = ?", date, serviceType.ID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This service already exists for that date", "service": existing})
+		return
+	}
+
+	occ := models.ServiceOccurrence{
+		Date:          date,
+		ServiceTypeID: serviceType.ID,
+		ServiceType:   serviceType.Name,
+		StartTime:     input.StartTime,
+		EndTime:       input.EndTime,
+		Minister:      strings.TrimSpace(input.Minister),
+		Theme:         strings.TrimSpace(input.Theme),
+		Notes:         input.Notes,
+	}
+	if err := database.DB.Omit("ServiceTypeRef").Create(&occ).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service"})
+		return
+	}
+
+	middleware.LogActivity(c, "Created service", occ.ServiceType+" on "+input.Date)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Service created",
+		"service": occ,
+	})
+}
+
+// Admin: Update a service's times, minister, theme or notes. Date and service type are
+// fixed because attendance and sermons are linked by them.
+func UpdateServiceOccurrence(c *gin.Context) {
+	var occ models.ServiceOccurrence
+	if err := database.DB.First(&occ, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	var input struct {
+		StartTime *string `json:"startTime"`
+		EndTime   *string `json:"endTime"`
+		Minister  *string `json:"minister"`
+		Theme     *string `json:"theme"`
+		Notes     *string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.StartTime != nil {
+		occ.StartTime = *input.StartTime
+	}
+	if input.EndTime != nil {
+		occ.EndTime = *input.EndTime
+	}
+	if !validClock(occ.StartTime) || !validClock(occ.EndTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time format. Use HH:MM"})
+		return
+	}
+	if input.Minister != nil {
+		occ.Minister = strings.TrimSpace(*input.Minister)
+	}
+	if input.Theme != nil {
+		occ.Theme = strings.TrimSpace(*input.Theme)
+	}
+	if input.Notes != nil {
+		occ.Notes = *input.Notes
+	}
+
+	if err := database.DB.Omit("ServiceTypeRef").Save(&occ).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service"})
+		return
+	}
+
+	middleware.LogActivity(c, "Updated service", occ.ServiceType+" on "+occ.Date.String())
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Service updated",
+		"service": occ,
+	})
+}
+
+// Admin: Delete a service occurrence (superadmin only). Linked records are kept and
+// unlinked; a service with offerings cannot be deleted.
+func DeleteServiceOccurrence(c *gin.Context) {
+	var occ models.ServiceOccurrence
+	if err := database.DB.First(&occ, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	var offerings int64
+	database.DB.Model(&models.Offering{}).Where("service_occurrence_id = ?", occ.ID).Count(&offerings)
+	if offerings > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This service has recorded offerings and cannot be deleted"})
+		return
+	}
+	var attendance int64
+	database.DB.Model(&models.Attendance{}).Where("service_occurrence_id = ?", occ.ID).Count(&attendance)
+	if attendance > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This service has an attendance record. Delete the attendance first"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.Sermon{}, &models.FirstTimer{}, &models.FirstTimerVisit{}} {
+			if err := tx.Model(model).Where("service_occurrence_id = ?", occ.ID).
+				Update("service_occurrence_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&occ).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete service"})
+		return
+	}
+
+	middleware.LogActivity(c, "Deleted service", occ.ServiceType+" on "+occ.Date.String())
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service deleted"})
+}
+
+// Admin: Record a counted offering for a service
+func CreateOffering(c *gin.Context) {
+	var occ models.ServiceOccurrence
+	if err := database.DB.First(&occ, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	var input struct {
+		Kind        string `json:"kind" binding:"required"`
+		AmountMinor int64  `json:"amountMinor"` // Kobo for NGN
+		Currency    string `json:"currency"`
+		CountedBy   string `json:"countedBy"`
+		Notes       string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kindOK := false
+	for _, k := range models.OfferingKinds {
+		kindOK = kindOK || k == input.Kind
+	}
+	if !kindOK {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind. Use one of: " + strings.Join(models.OfferingKinds, ", ")})
+		return
+	}
+	if input.AmountMinor <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amountMinor must be greater than zero"})
+		return
+	}
+	currency := strings.ToUpper(strings.TrimSpace(input.Currency))
+	if currency == "" {
+		currency = "NGN"
+	}
+	if len(currency) != 3 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be a three-letter code"})
+		return
+	}
+
+	offering := models.Offering{
+		ServiceOccurrenceID: occ.ID,
+		Kind:                input.Kind,
+		AmountMinor:         input.AmountMinor,
+		Currency:            currency,
+		CountedBy:           strings.TrimSpace(input.CountedBy),
+		RecordedBy:          c.GetString("adminEmail"),
+		Notes:               input.Notes,
+	}
+	if err := database.DB.Omit("ServiceOccurrence").Create(&offering).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record offering"})
+		return
+	}
+
+	middleware.LogActivity(c, "Recorded offering",
+		fmt.Sprintf("%s for %s on %s", offering.Kind, occ.ServiceType, occ.Date.String()))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Offering recorded",
+		"offering": offering,
+	})
+}
+
+// Admin: Delete a recorded offering
+func DeleteOffering(c *gin.Context) {
+	var offering models.Offering
+	err := database.DB.Where("id = ? AND service_occurrence_id = ?", c.Param("offeringId"), c.Param("id")).First(&offering).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offering not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete offering"})
+		return
+	}
+
+	database.DB.Delete(&offering)
+	middleware.LogActivity(c, "Deleted offering", fmt.Sprintf("%s #%d", offering.Kind, offering.ID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Offering deleted"})
+}
@@ -2,16 +2,22 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
 	"rccg-salvation-centre-backend/internal/models"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// Public: Active service types in display order
 func GetServiceTypes(c *gin.Context) {
 	var types []models.ServiceType
-	if err := database.DB.Order("name ASC").Find(&types).Error; err != nil {
+	if err := database.DB.Where("active = ?", true).Order("display_order ASC, name ASC").Find(&types).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load service types"})
 		return
 	}
@@ -20,3 +26,331 @@ func GetServiceTypes(c *gin.Context) {
 		"data":    types,
 	})
 }
+
+// resolveServiceType finds a service type by id, or by name (case-insensitive) when no id is given.
+// Archived types are refused so new records only use current services.
+func resolveServiceType(db *gorm.DB, id uint, name string) (models.ServiceType, error) {
+	var st models.ServiceType
+	switch {
+	case id != 0:
+		if err := db.First(&st, id).Error; err != nil {
+			return st, errors.New("Unknown service type")
+		}
+	case strings.TrimSpace(name) != "":
+		if err := db.Where("LOWER(name) = ?", strings.ToLower(strings.TrimSpace(name))).First(&st).Error; err != nil {
+			return st, errors.New("Unknown service type: " + name)
+		}
+	default:
+		return st, errors.New("serviceTypeId or serviceType is required")
+	}
+	if !st.Active {
+		return st, errors.New("Service type " + st.Name + " is archived")
+	}
+	return st, nil
+}
+
+// serviceTypeUsage counts the records that reference a service type
+func serviceTypeUsage(db *gorm.DB, st models.ServiceType) map[string]int64 {
+	usage := map[string]int64{}
+	var n int64
+	db.Model(&models.Attendance{}).Where("service_type_id = ?", st.ID).Count(&n)
+	usage["attendance"] = n
+	db.Model(&models.Sermon{}).Where("LOWER(TRIM(service)) = ?", strings.ToLower(st.Name)).Count(&n)
+	usage["sermons"] = n
+	db.Model(&models.ServiceOccurrence{}).Where("service_type_id = ?", st.ID).Count(&n)
+	usage["services"] = n
+	db.Model(&models.FirstTimerVisit{}).Where("service_type_id = ?", st.ID).Count(&n)
+	usage["firstTimerVisits"] = n
+	return usage
+}
+
+// Admin: All service types, including archived ones, with how often each is used
+func AdminGetServiceTypes(c *gin.Context) {
+	var types []models.ServiceType
+	if err := database.DB.Order("display_order ASC, name ASC").Find(&types).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load service types"})
+		return
+	}
+
+	data := make([]gin.H, 0, len(types))
+	for _, st := range types {
+		data = append(data, gin.H{"serviceType": st, "usage": serviceTypeUsage(database.DB, st)})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// Admin: Create service type
+func CreateServiceType(c *gin.Context) {
+	var input struct {
+		Name         string `json:"name" binding:"required"`
+		DisplayOrder *int   `json:"displayOrder"` // Defaults to the end of the list
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.ServiceType{}).Where("LOWER(name) = ?", strings.ToLower(name)).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A service type with this name already exists"})
+		return
+	}
+
+	st := models.ServiceType{Name: name, Active: true}
+	if input.DisplayOrder != nil {
+		st.DisplayOrder = *input.DisplayOrder
+	} else {
+		var last int
+		database.DB.Model(&models.ServiceType{}).Select("COALESCE(MAX(display_order), 0)").Scan(&last)
+		st.DisplayOrder = last + 1
+	}
+
+	if err := database.DB.Create(&st).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service type"})
+		return
+	}
+
+	middleware.LogActivity(c, "Created service type", st.Name)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Service type created",
+		"serviceType": st,
+	})
+}
+
+// Admin: Rename, archive/restore or reorder a service type. A new name is copied onto
+// the attendance, services and sermons that reference the old one.
+func UpdateServiceType(c *gin.Context) {
+	var st models.ServiceType
+	if err := database.DB.First(&st, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service type not found"})
+		return
+	}
+
+	var input struct {
+		Name         *string `json:"name"`
+		DisplayOrder *int    `json:"displayOrder"`
+		Active       *bool   `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	oldName := st.Name
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		var clash int64
+		database.DB.Model(&models.ServiceType{}).Where("LOWER(name) = ? AND id <> ?", strings.ToLower(name), st.ID).Count(&clash)
+		if clash > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A service type with this name already exists. Delete this one with mergeInto to combine them"})
+			return
+		}
+		st.Name = name
+	}
+	if input.DisplayOrder != nil {
+		st.DisplayOrder = *input.DisplayOrder
+	}
+	if input.Active != nil {
+		st.Active = *input.Active
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&st).Select("name", "display_order", "active").Updates(&st).Error; err != nil {
+			return err
+		}
+		if st.Name == oldName {
+			return nil
+		}
+		if err := tx.Model(&models.Attendance{}).Where("service_type_id = ?", st.ID).
+			Update("service_type", st.Name).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ServiceOccurrence{}).Where("service_type_id = ?", st.ID).
+			Update("service_type", st.Name).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Sermon{}).Where("LOWER(TRIM(service)) = ?", strings.ToLower(oldName)).
+			Update("service", st.Name).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update service type"})
+		return
+	}
+
+	details := st.Name
+	if st.Name != oldName {
+		details = oldName + " renamed to " + st.Name
+	}
+	middleware.LogActivity(c, "Updated service type", details)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Service type updated",
+		"serviceType": st,
+	})
+}
+
+// Admin: Set the display order of service types in one go. ids lists types in their new order.
+func ReorderServiceTypes(c *gin.Context) {
+	var input struct {
+		IDs []uint `json:"ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seen := make(map[uint]bool, len(input.IDs))
+	for _, id := range input.IDs {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids must not repeat"})
+			return
+		}
+		seen[id] = true
+	}
+
+	var found int64
+	database.DB.Model(&models.ServiceType{}).Where("id IN ?", input.IDs).Count(&found)
+	if int(found) != len(input.IDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids contains an unknown service type"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range input.IDs {
+			if err := tx.Model(&models.ServiceType{}).Where("id = ?", id).Update("display_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder service types"})
+		return
+	}
+
+	middleware.LogActivity(c, "Reordered service types", strconv.Itoa(len(input.IDs))+" types")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service types reordered"})
+}
+
+// errMergeClash means both service types recorded attendance on the same date
+var errMergeClash = errors.New("both service types have attendance on the same date")
+
+// Admin: Delete a service type (superadmin only)
+// A type that is still referenced is refused unless ?mergeInto=<id> is given, in which
+// case every reference moves to that type first. Archiving is usually the better option.
+func DeleteServiceType(c *gin.Context) {
+	var st models.ServiceType
+	if err := database.DB.First(&st, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service type not found"})
+		return
+	}
+
+	usage := serviceTypeUsage(database.DB, st)
+	inUse := false
+	for _, n := range usage {
+		inUse = inUse || n > 0
+	}
+
+	mergeInto := c.Query("mergeInto")
+	if inUse && mergeInto == "" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "This service type is still in use. Archive it, or delete with mergeInto to move its records to another type",
+			"usage": usage,
+		})
+		return
+	}
+
+	var target models.ServiceType
+	if mergeInto != "" {
+		if err := database.DB.First(&target, mergeInto).Error; err != nil || target.ID == st.ID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mergeInto must be another existing service type"})
+			return
+		}
+	}
+
+	var clashes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if target.ID != 0 {
+			if err := tx.Raw(`SELECT TO_CHAR(a.date, 'YYYY-MM-DD') FROM attendances a
+				WHERE a.service_type_id = ? AND EXISTS (
+					SELECT 1 FROM attendances b WHERE b.service_type_id = ? AND b.date = a.date
+				) ORDER BY a.date`, st.ID, target.ID).Scan(&clashes).Error; err != nil {
+				return err
+			}
+			if len(clashes) > 0 {
+				return errMergeClash
+			}
+			if err := mergeServiceType(tx, st, target); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&st).Error
+	})
+	switch {
+	case errors.Is(err, errMergeClash):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Both service types have attendance on the same dates. Resolve these first",
+			"dates": clashes,
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete service type"})
+		return
+	}
+
+	if target.ID != 0 {
+		middleware.LogActivity(c, "Merged service type", st.Name+" into "+target.Name)
+	} else {
+		middleware.LogActivity(c, "Deleted service type", st.Name)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service type deleted", "moved": usage})
+}
+
+// mergeServiceType moves every reference to from onto into. Services held by both
+// on the same date are combined into the one belonging to into.
+func mergeServiceType(tx *gorm.DB, from, into models.ServiceType) error {
+	for _, table := range []string{"attendances", "sermons", "first_timers", "first_timer_visits", "offerings"} {
+		if err := tx.Exec(`UPDATE `+table+` x SET service_occurrence_id = t.id
+			FROM service_occurrences f JOIN service_occurrences t ON t.date = f.date AND t.service_type_id = ?
+			WHERE f.service_type_id = ? AND x.service_occurrence_id = f.id`, into.ID, from.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	statements := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`DELETE FROM service_occurrences f WHERE f.service_type_id = ? AND EXISTS (
+			SELECT 1 FROM service_occurrences t WHERE t.service_type_id = ? AND t.date = f.date)`,
+			[]interface{}{from.ID, into.ID}},
+		{`UPDATE service_occurrences SET service_type_id = ?, service_type = ? WHERE service_type_id = ?`,
+			[]interface{}{into.ID, into.Name, from.ID}},
+		{`UPDATE attendances SET service_type_id = ?, service_type = ? WHERE service_type_id = ?`,
+			[]interface{}{into.ID, into.Name, from.ID}},
+		{`UPDATE sermons SET service = ? WHERE LOWER(TRIM(service)) = ?`,
+			[]interface{}{into.Name, strings.ToLower(from.Name)}},
+		{`UPDATE first_timer_visits SET service_type_id = ? WHERE service_type_id = ?`,
+			[]interface{}{into.ID, from.ID}},
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s.sql, s.args...).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// internal/handlers/sms.go
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/sms"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// smsWebhookAllowed checks the ?token= the gateway was given in the webhook URL
+// against SMS_WEBHOOK_SECRET. Webhooks are off while the secret is not set.
+func smsWebhookAllowed(c *gin.Context) bool {
+	secret := os.Getenv("SMS_WEBHOOK_SECRET")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(secret)) != 1 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return false
+	}
+	return true
+}
+
+// smsWebhookFields reads a webhook body, which Termii sends as JSON and Twilio as
+// a form
+func smsWebhookFields(c *gin.Context) map[string]string {
+	fields := map[string]string{}
+	if c.ContentType() == "application/json" {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err == nil {
+			for k, v := range body {
+				switch v := v.(type) {
+				case string:
+					fields[k] = v
+				case float64:
+					fields[k] = strconv.FormatFloat(v, 'f', -1, 64)
+				}
+			}
+		}
+		return fields
+	}
+	if err := c.Request.ParseForm(); err == nil {
+		for k := range c.Request.PostForm {
+			fields[k] = c.Request.PostForm.Get(k)
+		}
+	}
+	return fields
+}
+
+// firstField is the first of keys that is set
+func firstField(fields map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := fields[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Public: Delivery report from the SMS gateway
+// POST /api/sms/status?token=
+func SMSStatusWebhook(c *gin.Context) {
+	if !smsWebhookAllowed(c) {
+		return
+	}
+	fields := smsWebhookFields(c)
+	report := sms.Report{
+		ProviderID: firstField(fields, "message_id", "MessageSid", "SmsSid", "id"),
+		Status:     firstField(fields, "status", "MessageStatus", "SmsStatus"),
+	}
+	if cost := firstField(fields, "cost", "Price", "price"); cost != "" {
+		report.Cost = &cost
+		report.Currency = firstField(fields, "currency", "PriceUnit", "price_unit")
+	}
+	if report.ProviderID == "" || report.Status == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "message id and status are required"})
+		return
+	}
+
+	err := sms.ApplyReport(database.DB, report)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record the report"})
+		return
+	}
+	// Unknown messages are acknowledged too, or the gateway keeps retrying them
+	c.JSON(http.StatusOK, gin.H{"message": "Report received"})
+}
+
+// Public: A text sent back to us. STOP opts the number out of messages and START
+// opts it back in.
+// POST /api/sms/inbound?token=
+func SMSInboundWebhook(c *gin.Context) {
+	if !smsWebhookAllowed(c) {
+		return
+	}
+	fields := smsWebhookFields(c)
+	from := firstField(fields, "From", "from", "sender")
+	body := firstField(fields, "Body", "body", "message", "sms", "text")
+
+	changed, err := sms.HandleReply(database.DB, from, body)
+	if errors.Is(err, sms.ErrInvalidPhone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from is not a valid phone number"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to handle the reply"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reply received", "changed": changed})
+}
+
+// Admin: Text messages sent or waiting to be sent, latest first, with their total
+// cost (superadmin only)
+// GET /api/admin/sms-messages?status=&type=&recipient=&entityType=&entityId=&limit=
+func AdminGetSMSMessages(c *gin.Context) {
+	db := database.DB.Model(&models.SMSMessage{})
+	for param, column := range map[string]string{
+		"status": "status", "type": "type", "entityType": "entity_type", "entityId": "entity_id",
+	} {
+		if value := c.Query(param); value != "" {
+			db = db.Where(column+" = ?", value)
+		}
+	}
+	if recipient := c.Query("recipient"); recipient != "" {
+		if phone, err := sms.Normalize(recipient); err == nil {
+			recipient = phone
+		}
+		db = db.Where("recipient = ?", recipient)
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	var totals []struct {
+		Currency   string `json:"currency"`
+		Messages   int64  `json:"messages"`
+		Segments   int64  `json:"segments"`
+		CostMicros int64  `json:"costMicros"`
+	}
+	if err := db.Session(&gorm.Session{}).
+		Select("currency, COUNT(*) AS messages, COALESCE(SUM(segments), 0) AS segments, COALESCE(SUM(cost_micros), 0) AS cost_micros").
+		Group("currency").Order("currency").Scan(&totals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load messages"})
+		return
+	}
+
+	var messages []models.SMSMessage
+	db.Order("created_at DESC, id DESC").Limit(limit).Find(&messages)
+	c.JSON(http.StatusOK, gin.H{"data": messages, "totals": totals})
+}
+
+// Admin: Numbers that asked not to be sent text messages (superadmin only)
+// GET /api/admin/sms-opt-outs
+func AdminGetSMSOptOuts(c *gin.Context) {
+	var optOuts []models.SMSOptOut
+	database.DB.Order("created_at DESC").Find(&optOuts)
+	c.JSON(http.StatusOK, gin.H{"data": optOuts})
+}
+
+// Admin: Stop text messages to a number, e.g. when someone asks in person (superadmin only)
+// POST /api/admin/sms-opt-outs
+func CreateSMSOptOut(c *gin.Context) {
+	var input struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	phone, err := sms.Normalize(input.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "phone is not a valid phone number"})
+		return
+	}
+	if err := sms.OptOut(database.DB, phone, c.GetString("adminEmail")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save opt-out"})
+		return
+	}
+
+	middleware.LogActivity(c, "Opted phone out of SMS", phone)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Number opted out", "phone": phone})
+}
+
+// Admin: Allow text messages to a number again (superadmin only)
+// DELETE /api/admin/sms-opt-outs/:phone
+func DeleteSMSOptOut(c *gin.Context) {
+	phone, err := sms.Normalize(c.Param("phone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "phone is not a valid phone number"})
+		return
+	}
+	if err := sms.OptIn(database.DB, phone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove opt-out"})
+		return
+	}
+
+	middleware.LogActivity(c, "Opted phone back in to SMS", phone)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Number opted back in", "phone": phone})
+}
@@ -2,43 +2,241 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
 	"rccg-salvation-centre-backend/internal/middleware"
 	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/recurrence"
+	"rccg-salvation-centre-backend/internal/slug"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// Public: Get published special events (latest first)
+// specialEventScheduleInput is when an event runs and takes registrations. Times are
+// either startsAt/endsAt timestamps, or date and endDate with local start and end
+// times ("18:00", "6pm - 9pm").
+type specialEventScheduleInput struct {
+	Date      *string    `json:"date"`    // YYYY-MM-DD; required on create unless startsAt is sent
+	EndDate   *string    `json:"endDate"` // YYYY-MM-DD last day; "" for a one-day event
+	StartsAt  *time.Time `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt"`
+	StartTime *string    `json:"startTime"`
+	EndTime   *string    `json:"endTime"`
+
+	RegistrationOpensAt  *string `json:"registrationOpensAt"`  // RFC 3339; "" takes no registrations
+	RegistrationClosesAt *string `json:"registrationClosesAt"` // RFC 3339; "" closes when the event starts
+}
+
+// Longest span of a multi-day event
+const maxSpecialEventDays = 31
+
+// apply sets the event's days, times and registration window. Fields not sent are
+// kept; moving the date without an endDate keeps the event's length.
+func (input specialEventScheduleInput) apply(event *models.SpecialEvent) error {
+	start, end := event.StartTime, event.EndTime
+
+	if input.Date != nil {
+		date, err := localtime.ParseDate(*input.Date)
+		if err != nil {
+			return errors.New("Invalid date format. Use YYYY-MM-DD")
+		}
+		if !event.Date.IsZero() {
+			days := int(event.LastDay().Sub(event.Date.Time).Hours() / 24)
+			event.EndDate = date.AddDays(days)
+		}
+		event.Date = date
+	}
+	if input.StartsAt != nil {
+		startsAt := input.StartsAt.In(localtime.Location())
+		event.Date, event.EndDate = localtime.DateOf(startsAt), localtime.DateOf(startsAt)
+		start, end = startsAt.Format("15:04"), ""
+		if input.EndsAt != nil {
+			endsAt := input.EndsAt.In(localtime.Location())
+			if !endsAt.After(startsAt) {
+				return errors.New("endsAt must be after startsAt")
+			}
+			event.EndDate = localtime.DateOf(endsAt)
+			end = endsAt.Format("15:04")
+		}
+	} else if input.EndsAt != nil {
+		return errors.New("endsAt needs startsAt")
+	}
+	if input.EndDate != nil {
+		event.EndDate = event.Date
+		if *input.EndDate != "" {
+			endDate, err := localtime.ParseDate(*input.EndDate)
+			if err != nil {
+				return errors.New("Invalid endDate format. Use YYYY-MM-DD")
+			}
+			event.EndDate = endDate
+		}
+	}
+
+	if event.Date.IsZero() {
+		return errors.New("date is required. Use YYYY-MM-DD")
+	}
+	if event.EndDate.IsZero() {
+		event.EndDate = event.Date
+	}
+	if event.EndDate.Before(event.Date) {
+		return errors.New("endDate must not be before date")
+	}
+	if event.EndDate.After(event.Date.AddDays(maxSpecialEventDays - 1)) {
+		return errors.New("An event cannot run for more than 31 days")
+	}
+
+	if input.StartTime != nil {
+		start, end = "", ""
+		if strings.TrimSpace(*input.StartTime) != "" {
+			var err error
+			if start, end, err = recurrence.ParseTimeRange(*input.StartTime); err != nil {
+				return errors.New("Could not read startTime. Use HH:MM")
+			}
+		}
+	}
+	if input.EndTime != nil {
+		end = ""
+		if strings.TrimSpace(*input.EndTime) != "" {
+			clock, _, err := recurrence.ParseTimeRange(*input.EndTime)
+			if err != nil {
+				return errors.New("Could not read endTime. Use HH:MM")
+			}
+			end = clock
+		}
+	}
+	if err := event.SetTimes(start, end); err != nil {
+		return err
+	}
+
+	var err error
+	if input.RegistrationOpensAt != nil {
+		if event.RegistrationOpensAt, err = parseOptionalTimestamp("registrationOpensAt", *input.RegistrationOpensAt); err != nil {
+			return err
+		}
+	}
+	if input.RegistrationClosesAt != nil {
+		if event.RegistrationClosesAt, err = parseOptionalTimestamp("registrationClosesAt", *input.RegistrationClosesAt); err != nil {
+			return err
+		}
+	}
+	if event.RegistrationOpensAt != nil {
+		if !event.RegistrationCloses().After(*event.RegistrationOpensAt) {
+			return errors.New("Registration must open before it closes")
+		}
+		if _, finish := event.Span(); event.RegistrationCloses().After(finish) {
+			return errors.New("Registration cannot close after the event ends")
+		}
+	}
+	return nil
+}
+
+// parseOptionalTimestamp reads an RFC 3339 timestamp; an empty string clears it
+func parseOptionalTimestamp(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("Invalid " + field + ". Use an RFC 3339 timestamp such as 2025-03-01T09:00:00+01:00")
+	}
+	return localtime.In(&parsed), nil
+}
+
+// Public: Get published special events that are ongoing or still to come (soonest first).
+// A multi-day event stays listed until its last day is over.
 func GetSpecialEvents(c *gin.Context) {
 	var events []models.SpecialEvent
-	database.DB.Where("published = ?", true).
-		Order("date DESC, start_time DESC").
+	database.DB.Scopes(models.Visible(time.Now())).Where("end_date >= ?", localtime.Today()).
+		Order("date ASC, starts_at ASC").
 		Find(&events)
 	c.JSON(http.StatusOK, gin.H{"data": events})
 }
 
-// Admin: Get all special events (latest first)
+// Public: Get published special events that have passed (latest first, ?year= to narrow)
+func GetSpecialEventsArchive(c *gin.Context) {
+	db := database.DB.Scopes(models.Visible(time.Now())).Where("end_date < ?", localtime.Today())
+	if year := c.Query("year"); year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil || y < 1900 || y > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		db = db.Where("date >= ? AND date < ?", localtime.NewDate(y, 1, 1), localtime.NewDate(y+1, 1, 1))
+	}
+
+	var events []models.SpecialEvent
+	db.Order("date DESC, starts_at DESC").Find(&events)
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
+
+// Public: Get a published special event by slug; retired slugs redirect to the current one
+func GetSpecialEventBySlug(c *gin.Context) {
+	s := c.Param("slug")
+
+	var event models.SpecialEvent
+	err := database.DB.Preload("Sessions", orderSessions).Preload("RegistrationFields", orderRegistrationFields).
+		Scopes(models.Visible(time.Now())).Where("slug = ?", s).First(&event).Error
+	if err == nil {
+		if event.PlacesLeft, err = placesLeft(database.DB, event); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load event"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": event})
+		return
+	}
+
+	current, err := slug.Resolve(database.DB, &models.SpecialEvent{}, models.SlugEntitySpecialEvent, s)
+	if err == nil {
+		err = database.DB.Scopes(models.Visible(time.Now())).Where("slug = ?", current).First(&event).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	c.Redirect(http.StatusMovedPermanently, "/api/special-events/"+current)
+}
+
+// Admin: Get all special events (latest first, ?publishState= to narrow)
 func AdminGetSpecialEvents(c *gin.Context) {
+	db, ok := filterPublishState(c, database.DB)
+	if !ok {
+		return
+	}
 	var events []models.SpecialEvent
-	database.DB.Order("date DESC, start_time DESC").Find(&events)
+	db.Order("date DESC, starts_at DESC").Find(&events)
 	c.JSON(http.StatusOK, gin.H{"data": events})
 }
 
+// Admin: Get a single special event
+func AdminGetSpecialEvent(c *gin.Context) {
+	var event models.SpecialEvent
+	if err := database.DB.Preload("Sessions", orderSessions).Preload("RegistrationFields", orderRegistrationFields).
+		First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": event})
+}
+
 // Admin: Create special event
 func CreateSpecialEvent(c *gin.Context) {
 	var input struct {
 		Title       string `json:"title" binding:"required"`
 		Type        string `json:"type" binding:"required"`
 		Description string `json:"description"`
-		Date        string `json:"date" binding:"required"`
-		StartTime   string `json:"startTime"`
-		EndTime     string `json:"endTime"`
 		Location    string `json:"location"`
 		Published   bool   `json:"published"`
+		publishingInput
+		specialEventScheduleInput
+		specialEventRegistrationInput
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -46,24 +244,40 @@ func CreateSpecialEvent(c *gin.Context) {
 		return
 	}
 
-	parsedDate, err := time.Parse("2006-01-02", input.Date)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
-		return
-	}
-
 	event := models.SpecialEvent{
 		Title:       input.Title,
 		Type:        input.Type,
 		Description: input.Description,
-		Date:        parsedDate,
-		StartTime:   input.StartTime,
-		EndTime:     input.EndTime,
 		Location:    input.Location,
-		Published:   input.Published,
+	}
+	if err := input.publishingInput.apply(&event.Publishing, &input.Published, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.specialEventScheduleInput.apply(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	event.MaxPlaces = 1
+	fields, err := input.specialEventRegistrationInput.apply(&event)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := database.DB.Create(&event).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		s, err := slug.Assign(tx, &models.SpecialEvent{}, models.SlugEntitySpecialEvent, 0, "", event.Title, event.Date.Time)
+		if err != nil {
+			return err
+		}
+		event.Slug = s
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		event.RegistrationFields = fields
+		return saveRegistrationFields(tx, event.ID, fields)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
 		return
 	}
@@ -90,11 +304,11 @@ func UpdateSpecialEvent(c *gin.Context) {
 		Title       *string `json:"title"`
 		Type        *string `json:"type"`
 		Description *string `json:"description"`
-		Date        *string `json:"date"`
-		StartTime   *string `json:"startTime"`
-		EndTime     *string `json:"endTime"`
 		Location    *string `json:"location"`
 		Published   *bool   `json:"published"`
+		publishingInput
+		specialEventScheduleInput
+		specialEventRegistrationInput
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -111,25 +325,58 @@ func UpdateSpecialEvent(c *gin.Context) {
 	if input.Description != nil {
 		event.Description = *input.Description
 	}
-	if input.Date != nil {
-		if parsed, err := time.Parse("2006-01-02", *input.Date); err == nil {
-			event.Date = parsed
-		}
+	if err := input.specialEventScheduleInput.apply(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if input.StartTime != nil {
-		event.StartTime = *input.StartTime
+	stranded, err := sessionsOutside(database.DB, event.ID, event.Date, event.LastDay())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check sessions"})
+		return
 	}
-	if input.EndTime != nil {
-		event.EndTime = *input.EndTime
+	if len(stranded) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "Some sessions would fall outside the new dates. Move or delete them first",
+			"sessions": stranded,
+		})
+		return
 	}
 	if input.Location != nil {
 		event.Location = *input.Location
 	}
-	if input.Published != nil {
-		event.Published = *input.Published
+	if err := input.publishingInput.apply(&event.Publishing, input.Published, time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fields, err := input.specialEventRegistrationInput.apply(&event)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := database.DB.Save(&event).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock out registrations while the capacity may change
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.SpecialEvent{}, event.ID).Error; err != nil {
+			return err
+		}
+		s, err := slug.Assign(tx, &models.SpecialEvent{}, models.SlugEntitySpecialEvent, event.ID, event.Slug, event.Title, event.Date.Time)
+		if err != nil {
+			return err
+		}
+		event.Slug = s
+		if err := tx.Save(&event).Error; err != nil {
+			return err
+		}
+		if fields != nil {
+			if err := saveRegistrationFields(tx, event.ID, fields); err != nil {
+				return err
+			}
+		}
+		// A larger capacity, or turning the limit off, frees places for the waitlist
+		_, err = promoteWaitlist(tx, event)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
 		return
 	}
@@ -152,8 +399,49 @@ func DeleteSpecialEvent(c *gin.Context) {
 		return
 	}
 
-	database.DB.Delete(&event)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("entity = ? AND target_id = ?", models.SlugEntitySpecialEvent, event.ID).
+			Delete(&models.SlugRedirect{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&event).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
+		return
+	}
 	middleware.LogActivity(c, "Deleted special event", event.Title)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Special event deleted"})
 }
+
+// Admin: Publish, unpublish or delete many special events at once
+func BulkUpdateSpecialEvents(c *gin.Context) {
+	input, ok := bindBulkRequest(c)
+	if !ok {
+		return
+	}
+
+	switch input.Action {
+	case "publish", "unpublish":
+		published := input.Action == "publish"
+		action := "Published special event"
+		if !published {
+			action = "Unpublished special event"
+		}
+		runBulk(c, input.IDs, func(tx *gorm.DB, event *models.SpecialEvent) (bulkChange, error) {
+			event.SetPublished(published, time.Now())
+			return bulkChange{action, event.Title}, tx.Save(event).Error
+		})
+	case "delete":
+		runBulk(c, input.IDs, func(tx *gorm.DB, event *models.SpecialEvent) (bulkChange, error) {
+			if err := tx.Where("entity = ? AND target_id = ?", models.SlugEntitySpecialEvent, event.ID).
+				Delete(&models.SlugRedirect{}).Error; err != nil {
+				return bulkChange{}, err
+			}
+			return bulkChange{"Deleted special event", event.Title}, tx.Delete(event).Error
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action. Use 'publish', 'unpublish' or 'delete'"})
+	}
+}
@@ -0,0 +1,217 @@
+// internal/handlers/special_event_session.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// orderSessions lists an event's sessions in running order when preloading
+func orderSessions(db *gorm.DB) *gorm.DB {
+	return db.Order("starts_at ASC, id ASC")
+}
+
+type specialEventSessionInput struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Speaker     *string `json:"speaker"`
+	Venue       *string `json:"venue"`
+	Date        *string `json:"date"`      // YYYY-MM-DD; defaults to the event's first day
+	StartTime   *string `json:"startTime"` // HH:MM local time (required on create)
+	EndTime     *string `json:"endTime"`   // HH:MM; "" for no set end
+}
+
+// apply copies the given fields onto s, keeping the day and times not sent
+func (input specialEventSessionInput) apply(s *models.SpecialEventSession, event models.SpecialEvent) error {
+	if input.Title != nil {
+		s.Title = *input.Title
+	}
+	if input.Description != nil {
+		s.Description = *input.Description
+	}
+	if input.Speaker != nil {
+		s.Speaker = *input.Speaker
+	}
+	if input.Venue != nil {
+		s.Venue = *input.Venue
+	}
+
+	day, start, end := event.Date, "", ""
+	if !s.StartsAt.IsZero() {
+		day, start = localtime.DateOf(s.StartsAt), s.StartsAt.In(localtime.Location()).Format("15:04")
+	}
+	if s.EndsAt != nil {
+		end = s.EndsAt.In(localtime.Location()).Format("15:04")
+	}
+	if input.Date != nil {
+		date, err := localtime.ParseDate(*input.Date)
+		if err != nil {
+			return errors.New("Invalid date format. Use YYYY-MM-DD")
+		}
+		day = date
+	}
+	if input.StartTime != nil {
+		start = *input.StartTime
+	}
+	if input.EndTime != nil {
+		end = *input.EndTime
+	}
+
+	if start == "" {
+		return errors.New("startTime is required")
+	}
+	if !validClock(start) || !validClock(end) {
+		return errors.New("startTime and endTime must be HH:MM")
+	}
+	startsAt, _ := day.At(start)
+	s.StartsAt, s.EndsAt = startsAt, nil
+	if end != "" {
+		endsAt, _ := day.At(end)
+		if !endsAt.After(startsAt) {
+			endsAt = endsAt.AddDate(0, 0, 1) // Runs past midnight
+		}
+		s.EndsAt = &endsAt
+	}
+	return nil
+}
+
+// validateSpecialEventSession checks the session has a title and starts during the event
+func validateSpecialEventSession(event models.SpecialEvent, s models.SpecialEventSession) error {
+	if s.Title == "" {
+		return errors.New("title is required")
+	}
+	day := localtime.DateOf(s.StartsAt)
+	if day.Before(event.Date) || day.After(event.LastDay()) {
+		return errors.New("The session must start between " + event.Date.String() + " and " + event.LastDay().String())
+	}
+	return nil
+}
+
+// findSpecialEventSession loads the event and one of its sessions, responding 404 if either is missing
+func findSpecialEventSession(c *gin.Context) (models.SpecialEvent, models.SpecialEventSession, bool) {
+	var event models.SpecialEvent
+	var session models.SpecialEventSession
+	if err := database.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return event, session, false
+	}
+	if err := database.DB.Where("event_id = ?", event.ID).First(&session, c.Param("sessionId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return event, session, false
+	}
+	return event, session, true
+}
+
+// Admin: List an event's sessions in running order
+func AdminGetSpecialEventSessions(c *gin.Context) {
+	var event models.SpecialEvent
+	if err := database.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	var sessions []models.SpecialEventSession
+	orderSessions(database.DB.Where("event_id = ?", event.ID)).Find(&sessions)
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// Admin: Add a session to an event
+func CreateSpecialEventSession(c *gin.Context) {
+	var event models.SpecialEvent
+	if err := database.DB.First(&event, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	var input specialEventSessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session := models.SpecialEventSession{EventID: event.ID}
+	if err := input.apply(&session, event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSpecialEventSession(event, session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+		return
+	}
+
+	middleware.LogActivity(c, "Added event session", event.Title+": "+session.Title)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Session added",
+		"session": session,
+	})
+}
+
+// Admin: Update a session
+func UpdateSpecialEventSession(c *gin.Context) {
+	event, session, ok := findSpecialEventSession(c)
+	if !ok {
+		return
+	}
+
+	var input specialEventSessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := input.apply(&session, event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateSpecialEventSession(event, session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Save(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		return
+	}
+
+	middleware.LogActivity(c, "Updated event session", event.Title+": "+session.Title)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session updated",
+		"session": session,
+	})
+}
+
+// Admin: Remove a session
+func DeleteSpecialEventSession(c *gin.Context) {
+	event, session, ok := findSpecialEventSession(c)
+	if !ok {
+		return
+	}
+
+	database.DB.Delete(&session)
+	middleware.LogActivity(c, "Deleted event session", event.Title+": "+session.Title)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session deleted"})
+}
+
+// sessionsOutside lists sessions that would no longer start during the event if it
+// ran from first to last
+func sessionsOutside(tx *gorm.DB, eventID uint, first, last localtime.Date) ([]models.SpecialEventSession, error) {
+	var sessions []models.SpecialEventSession
+	err := tx.Where("event_id = ? AND (starts_at < ? OR starts_at >= ?)", eventID, first.Start(), last.AddDays(1).Start()).
+		Find(&sessions).Error
+	return sessions, err
+}
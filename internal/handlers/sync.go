@@ -0,0 +1,417 @@
+// internal/handlers/sync.go
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/auth"
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/occurrence"
+	"rccg-salvation-centre-backend/internal/welcome"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Largest batch a device may send in one request
+const maxSyncOperations = 200
+
+// Operation types accepted by POST /api/sync
+const (
+	syncFirstTimerCreate  = "first_timer.create"
+	syncFirstTimerCheckIn = "first_timer.check_in"
+	syncCountSheetCreate  = "count_sheet.create"
+	syncEventCheckIn      = "event.check_in"
+)
+
+// Results that are not stored, so the device retries or fixes them
+const (
+	syncStatusError     = "error"
+	syncStatusDuplicate = "duplicate_id"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// errSyncNotApplied rolls back partial writes of an operation that ended in a conflict or rejection
+var errSyncNotApplied = errors.New("sync operation not applied")
+
+type syncOperationInput struct {
+	ID        string          `json:"id"` // UUID generated on the device
+	Type      string          `json:"type"`
+	CreatedAt *time.Time      `json:"createdAt"` // When the work was queued on the device
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type syncResult struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Status     string `json:"status"` // applied, conflict, rejected, duplicate_id or error
+	Replayed   bool   `json:"replayed,omitempty"`
+	ResultID   *uint  `json:"resultId,omitempty"`
+	ExistingID *uint  `json:"existingId,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// syncOutcome is what applying one operation produced
+type syncOutcome struct {
+	status     string
+	resultID   *uint
+	existingID *uint
+	message    string
+}
+
+func applied(id uint) syncOutcome {
+	return syncOutcome{status: models.SyncStatusApplied, resultID: &id}
+}
+
+func conflict(existingID uint, message string) syncOutcome {
+	return syncOutcome{status: models.SyncStatusConflict, existingID: &existingID, message: message}
+}
+
+func rejected(message string) syncOutcome {
+	return syncOutcome{status: models.SyncStatusRejected, message: message}
+}
+
+type syncApplyFunc func(tx *gorm.DB, device models.Device, payload json.RawMessage) (syncOutcome, error)
+
+// syncOperationTypes lists what each operation does and which devices may send it
+var syncOperationTypes = map[string]struct {
+	purposes []string
+	apply    syncApplyFunc
+}{
+	syncFirstTimerCreate:  {[]string{models.DevicePurposeWelcomeDesk}, syncCreateFirstTimer},
+	syncFirstTimerCheckIn: {[]string{models.DevicePurposeWelcomeDesk, models.DevicePurposeUsher}, syncCheckInFirstTimer},
+	syncCountSheetCreate:  {[]string{models.DevicePurposeUsher}, syncCreateCountSheet},
+	syncEventCheckIn:      {[]string{models.DevicePurposeUsher}, syncCheckInEvent},
+}
+
+// Device: Apply a batch of queued operations (POST /api/sync, X-Device-Key header)
+//
+// Operations are applied in order, each in its own transaction, and every one gets a
+// result. Sending an operation id again returns the stored result instead of applying
+// it twice, so a device can safely resend a batch after a dropped connection.
+func SyncDeviceOperations(c *gin.Context) {
+	device := c.MustGet("device").(models.Device)
+
+	var input struct {
+		Operations []syncOperationInput `json:"operations" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.Operations) == 0 || len(input.Operations) > maxSyncOperations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Send between 1 and %d operations", maxSyncOperations)})
+		return
+	}
+
+	results := make([]syncResult, 0, len(input.Operations))
+	tally := map[string]int{}
+	for _, op := range input.Operations {
+		result := applySyncOperation(device, op)
+		results = append(results, result)
+		if !result.Replayed {
+			tally[result.Status]++
+		}
+	}
+
+	if tally[models.SyncStatusApplied] > 0 {
+		database.DB.Create(&models.ActivityLog{
+			AdminEmail: deviceActor(device),
+			Action:     "Synced device operations",
+			Details: fmt.Sprintf("%d applied, %d conflicts, %d rejected",
+				tally[models.SyncStatusApplied], tally[models.SyncStatusConflict], tally[models.SyncStatusRejected]),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"applied":   tally[models.SyncStatusApplied],
+		"conflicts": tally[models.SyncStatusConflict],
+		"rejected":  tally[models.SyncStatusRejected],
+		"failed":    tally[syncStatusError] + tally[syncStatusDuplicate],
+		"results":   results,
+	})
+}
+
+func deviceActor(device models.Device) string {
+	return "device:" + device.Name
+}
+
+func applySyncOperation(device models.Device, op syncOperationInput) syncResult {
+	result := syncResult{ID: op.ID, Type: op.Type}
+
+	if !uuidPattern.MatchString(op.ID) {
+		result.Status, result.Error = models.SyncStatusRejected, "id must be a UUID"
+		return result
+	}
+	op.ID = strings.ToLower(op.ID)
+	result.ID = op.ID
+
+	spec, known := syncOperationTypes[op.Type]
+	if !known {
+		result.Status, result.Error = models.SyncStatusRejected, "unknown operation type"
+		return result
+	}
+	permitted := false
+	for _, p := range spec.purposes {
+		permitted = permitted || p == device.Purpose
+	}
+	if !permitted {
+		result.Status, result.Error = models.SyncStatusRejected, "this device cannot send "+op.Type
+		return result
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, op.Payload); err != nil || compact.Len() == 0 {
+		result.Status, result.Error = models.SyncStatusRejected, "payload must be a JSON object"
+		return result
+	}
+	sum := sha256.Sum256(append([]byte(op.Type+"\n"), compact.Bytes()...))
+	hash := hex.EncodeToString(sum[:])
+
+	var previous models.SyncOperation
+	err := database.DB.Where("client_id = ?", op.ID).First(&previous).Error
+	if err == nil {
+		if previous.DeviceID != device.ID || previous.PayloadHash != hash {
+			result.Status, result.Error = syncStatusDuplicate, "id was already used for a different operation"
+			return result
+		}
+		result.Status, result.Replayed = previous.Status, true
+		result.ResultID, result.ExistingID, result.Error = previous.ResultID, previous.ExistingID, previous.Message
+		return result
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		result.Status, result.Error = syncStatusError, "temporary failure, retry later"
+		return result
+	}
+
+	var outcome syncOutcome
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Transaction(func(inner *gorm.DB) error {
+			var err error
+			outcome, err = spec.apply(inner, device, compact.Bytes())
+			if err != nil {
+				return err
+			}
+			if outcome.status != models.SyncStatusApplied {
+				return errSyncNotApplied
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errSyncNotApplied) {
+			return err
+		}
+
+		return tx.Create(&models.SyncOperation{
+			ClientID:        op.ID,
+			DeviceID:        device.ID,
+			Type:            op.Type,
+			PayloadHash:     hash,
+			Status:          outcome.status,
+			ResultID:        outcome.resultID,
+			ExistingID:      outcome.existingID,
+			Message:         outcome.message,
+			ClientCreatedAt: op.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("Sync operation %s from device %d failed: %v", op.ID, device.ID, err)
+		result.Status, result.Error = syncStatusError, "temporary failure, retry later"
+		return result
+	}
+
+	result.Status, result.ResultID, result.ExistingID, result.Error = outcome.status, outcome.resultID, outcome.existingID, outcome.message
+	return result
+}
+
+// syncCreateFirstTimer saves a welcome card. A card with the same phone or email for
+// the same visit date is reported as a conflict rather than saved twice.
+func syncCreateFirstTimer(tx *gorm.DB, device models.Device, payload json.RawMessage) (syncOutcome, error) {
+	var input firstTimerInput
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return rejected("invalid first-timer payload"), nil
+	}
+	firstTimer, err := input.build()
+	if err != nil {
+		return rejected(err.Error()), nil
+	}
+
+	phone, email := strings.TrimSpace(firstTimer.Phone), strings.ToLower(strings.TrimSpace(firstTimer.Email))
+	if phone != "" || email != "" {
+		var existing models.FirstTimer
+		err := tx.Where("visit_date = ?", firstTimer.VisitDate).
+			Where("(? <> '' AND phone = ?) OR (? <> '' AND LOWER(email) = ?)", phone, phone, email, email).
+			First(&existing).Error
+		if err == nil {
+			return conflict(existing.ID, "a first-timer with this phone or email was already recorded for this visit"), nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return syncOutcome{}, err
+		}
+	}
+
+	if firstTimer.ServiceOccurrenceID, err = occurrence.OnlyOnDate(tx, firstTimer.VisitDate); err != nil {
+		return syncOutcome{}, err
+	}
+	if err := tx.Create(&firstTimer).Error; err != nil {
+		return syncOutcome{}, err
+	}
+	if err := notifyFirstTimer(tx, firstTimer); err != nil {
+		return syncOutcome{}, err
+	}
+	if err := welcome.Start(tx, firstTimer); err != nil {
+		return syncOutcome{}, err
+	}
+	return applied(firstTimer.ID), nil
+}
+
+// syncCheckInFirstTimer records a first-timer returning for another service. The person
+// can be given by id, or by the id of the sync operation that created them on the device.
+func syncCheckInFirstTimer(tx *gorm.DB, device models.Device, payload json.RawMessage) (syncOutcome, error) {
+	var input struct {
+		FirstTimerID  uint   `json:"firstTimerId"`
+		FirstTimerRef string `json:"firstTimerRef"` // Operation id of a first_timer.create
+		VisitDate     string `json:"visitDate"`     // YYYY-MM-DD
+		ServiceTypeID *uint  `json:"serviceTypeId"`
+	}
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return rejected("invalid check-in payload"), nil
+	}
+
+	visitDate, err := localtime.ParseDate(input.VisitDate)
+	if err != nil {
+		return rejected("Invalid visit date format. Use YYYY-MM-DD"), nil
+	}
+
+	firstTimerID := input.FirstTimerID
+	if firstTimerID == 0 && input.FirstTimerRef != "" {
+		var created models.SyncOperation
+		if err := tx.Where("client_id = ? AND type = ?", strings.ToLower(input.FirstTimerRef), syncFirstTimerCreate).
+			First(&created).Error; err != nil {
+			return rejected("firstTimerRef does not match a synced first-timer"), nil
+		}
+		switch {
+		case created.ResultID != nil:
+			firstTimerID = *created.ResultID
+		case created.ExistingID != nil:
+			firstTimerID = *created.ExistingID
+		}
+	}
+	if firstTimerID == 0 {
+		return rejected("firstTimerId or firstTimerRef is required"), nil
+	}
+
+	var firstTimer models.FirstTimer
+	if err := tx.First(&firstTimer, firstTimerID).Error; err != nil {
+		return rejected("First-timer not found"), nil
+	}
+	var serviceOccurrenceID *uint
+	if input.ServiceTypeID != nil {
+		serviceType, err := resolveServiceType(tx, *input.ServiceTypeID, "")
+		if err != nil {
+			return rejected(err.Error()), nil
+		}
+		occ, err := occurrence.Ensure(tx, visitDate, serviceType.ID, serviceType.Name)
+		if err != nil {
+			return syncOutcome{}, err
+		}
+		serviceOccurrenceID = &occ.ID
+	}
+
+	if firstTimer.VisitDate.String() == input.VisitDate {
+		return conflict(firstTimer.ID, "this is the first-timer's first visit"), nil
+	}
+	var existing models.FirstTimerVisit
+	err = tx.Where("first_timer_id = ? AND visit_date = ?", firstTimer.ID, visitDate).First(&existing).Error
+	if err == nil {
+		return conflict(existing.ID, "already checked in for this date"), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return syncOutcome{}, err
+	}
+
+	visit := models.FirstTimerVisit{
+		FirstTimerID:        firstTimer.ID,
+		VisitDate:           visitDate,
+		ServiceTypeID:       input.ServiceTypeID,
+		ServiceOccurrenceID: serviceOccurrenceID,
+		RecordedBy:          deviceActor(device),
+	}
+	if err := tx.Create(&visit).Error; err != nil {
+		return syncOutcome{}, err
+	}
+	return applied(visit.ID), nil
+}
+
+// syncCreateCountSheet submits an usher's count. Unlike the admin endpoint, an existing
+// sheet for the section is never overwritten from a device; it is reported as a conflict.
+func syncCreateCountSheet(tx *gorm.DB, device models.Device, payload json.RawMessage) (syncOutcome, error) {
+	var input countSheetInput
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return rejected("invalid count sheet payload"), nil
+	}
+
+	sheet, attendance, _, err := submitCountSheet(tx, input, deviceActor(device), false)
+	switch {
+	case err == nil:
+		return applied(sheet.ID), nil
+	case errors.Is(err, errCountSheetExists):
+		return conflict(sheet.ID, err.Error()), nil
+	case errors.Is(err, errAttendanceLocked), errors.Is(err, errAttendanceEnteredAll):
+		return conflict(attendance.ID, err.Error()), nil
+	case errors.Is(err, models.ErrInvalidAttendance), errors.Is(err, errUnknownSection):
+		return rejected(err.Error()), nil
+	default:
+		return syncOutcome{}, err
+	}
+}
+
+// syncCheckInEvent checks in an event ticket scanned while the device was offline. A
+// ticket already checked in, by this or another device, is reported as a conflict.
+func syncCheckInEvent(tx *gorm.DB, device models.Device, payload json.RawMessage) (syncOutcome, error) {
+	var input struct {
+		EventID     uint       `json:"eventId"`
+		Ticket      string     `json:"ticket"`      // Scanned QR payload
+		Code        string     `json:"code"`        // Or the confirmation code typed in
+		CheckedInAt *time.Time `json:"checkedInAt"` // When it was scanned; defaults to now
+	}
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return rejected("invalid check-in payload"), nil
+	}
+
+	at := time.Now()
+	if input.CheckedInAt != nil && input.CheckedInAt.Before(at) {
+		at = *input.CheckedInAt
+	}
+
+	registration, err := findTicket(tx, input.EventID, input.Ticket, input.Code)
+	if err == nil {
+		err = checkInRegistration(tx, &registration, deviceActor(device), at)
+	}
+	switch {
+	case err == nil:
+		return applied(registration.ID), nil
+	case errors.Is(err, errAlreadyCheckedIn), errors.Is(err, errNotConfirmed):
+		return conflict(registration.ID, err.Error()), nil
+	case errors.Is(err, auth.ErrInvalidTicket):
+		return rejected("This ticket is not valid"), nil
+	case errors.Is(err, errTicketOtherEvent), errors.Is(err, errTicketNotFound), errors.Is(err, errTicketOrCodeEmpty):
+		return rejected(err.Error()), nil
+	default:
+		return syncOutcome{}, err
+	}
+}
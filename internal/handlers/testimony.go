@@ -6,10 +6,12 @@ import (
 	"time"
 
 	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/email"
 	"rccg-salvation-centre-backend/internal/middleware"
 	"rccg-salvation-centre-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Public: Get approved testimonies, sorted by approved date (latest first)
@@ -23,11 +25,27 @@ func GetTestimonies(c *gin.Context) {
 
 // Admin: Get all testimonies, sorted by submission date (latest first)
 func AdminGetTestimonies(c *gin.Context) {
+	db, err := filterTestimonies(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var testimonies []models.Testimony
-	database.DB.Order("submitted_at DESC").Find(&testimonies)
+	db.Order("submitted_at DESC").Find(&testimonies)
 	c.JSON(http.StatusOK, gin.H{"data": testimonies})
 }
 
+// Admin: Get a single testimony
+func AdminGetTestimony(c *gin.Context) {
+	var testimony models.Testimony
+	if err := database.DB.First(&testimony, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Testimony not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": testimony})
+}
+
 // Public: Submit new testimony (status = pending)
 func CreateTestimony(c *gin.Context) {
 	var input struct {
@@ -52,7 +70,18 @@ func CreateTestimony(c *gin.Context) {
 		Status:  models.Pending,
 	}
 
-	if err := database.DB.Create(&testimony).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&testimony).Error; err != nil {
+			return err
+		}
+		return email.NotifyRole(tx, "secretariat", email.Notification{
+			Type:       email.TestimonySubmitted,
+			EntityType: "testimony",
+			EntityID:   testimony.ID,
+			Data:       map[string]string{"Name": testimony.Name, "Title": testimony.Title, "Message": testimony.Message},
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit testimony"})
 		return
 	}
@@ -81,17 +110,7 @@ func UpdateTestimony(c *gin.Context) {
 		return
 	}
 
-	now := time.Now()
-	switch input.Status {
-	case "approved":
-		testimony.Status = models.Approved
-		testimony.ApprovedAt = &now
-		testimony.RejectedAt = nil
-	case "rejected":
-		testimony.Status = models.Rejected
-		testimony.RejectedAt = &now
-		testimony.ApprovedAt = nil
-	default:
+	if !setTestimonyStatus(&testimony, input.Status) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Use 'approved' or 'rejected'"})
 		return
 	}
@@ -128,3 +147,53 @@ func DeleteTestimony(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Testimony deleted"})
 }
+
+// Admin: Approve, reject or delete many testimonies at once
+func BulkUpdateTestimonies(c *gin.Context) {
+	input, ok := bindBulkRequest(c)
+	if !ok {
+		return
+	}
+
+	switch input.Action {
+	case "approve", "reject":
+		status := "approved"
+		action := "Approved testimony"
+		if input.Action == "reject" {
+			status = "rejected"
+			action = "Rejected testimony"
+		}
+		runBulk(c, input.IDs, func(tx *gorm.DB, testimony *models.Testimony) (bulkChange, error) {
+			setTestimonyStatus(testimony, status)
+			return bulkChange{action, testimony.Title}, tx.Save(testimony).Error
+		})
+	case "delete":
+		if !middleware.HasRole(c, "superadmin") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Insufficient permissions"})
+			return
+		}
+		runBulk(c, input.IDs, func(tx *gorm.DB, testimony *models.Testimony) (bulkChange, error) {
+			return bulkChange{"Deleted testimony", testimony.Title}, tx.Delete(testimony).Error
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action. Use 'approve', 'reject' or 'delete'"})
+	}
+}
+
+// setTestimonyStatus moves a testimony to "approved" or "rejected", stamping the decision time
+func setTestimonyStatus(testimony *models.Testimony, status string) bool {
+	now := time.Now()
+	switch status {
+	case "approved":
+		testimony.Status = models.Approved
+		testimony.ApprovedAt = &now
+		testimony.RejectedAt = nil
+	case "rejected":
+		testimony.Status = models.Rejected
+		testimony.RejectedAt = &now
+		testimony.ApprovedAt = nil
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+// internal/handlers/welcome.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/middleware"
+	"rccg-salvation-centre-backend/internal/welcome"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin: List the steps of the first-timer welcome sequence. Their email wording is
+// edited under email templates, by each step's emailType; the text message sent to
+// first-timers without an email address is the step's smsText.
+// GET /api/admin/welcome-sequence
+func AdminGetWelcomeSequence(c *gin.Context) {
+	steps, err := welcome.Steps(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the welcome sequence"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": steps})
+}
+
+// Admin: Turn a welcome step on or off, or change when it is sent or its text
+// message. First-timers already scheduled keep their times.
+// PUT /api/admin/welcome-sequence/:step
+func UpdateWelcomeStep(c *gin.Context) {
+	var input struct {
+		Enabled  *bool   `json:"enabled"`
+		Days     *int    `json:"days"`     // After the visit, or before the Sunday
+		SendTime *string `json:"sendTime"` // HH:MM church time
+		SMSText  *string `json:"smsText"`  // "" for the built-in text
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	steps, err := welcome.Steps(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load the welcome sequence"})
+		return
+	}
+	var step *welcome.Step
+	for i := range steps {
+		if steps[i].Key == c.Param("step") {
+			step = &steps[i]
+		}
+	}
+	if step == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Welcome step not found"})
+		return
+	}
+
+	settings := step.WelcomeStep
+	if input.Enabled != nil {
+		settings.Enabled = *input.Enabled
+	}
+	if input.Days != nil {
+		settings.Days = *input.Days
+	}
+	if input.SendTime != nil {
+		settings.SendTime = *input.SendTime
+	}
+	if input.SMSText != nil {
+		settings.SMSText = *input.SMSText
+	}
+	settings.UpdatedBy = c.GetString("adminEmail")
+
+	saved, err := welcome.Configure(database.DB, settings)
+	if errors.Is(err, welcome.ErrInvalidStep) || errors.Is(err, welcome.ErrInvalidSMS) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save the welcome step"})
+		return
+	}
+
+	middleware.LogActivity(c, "Updated welcome step", saved.Name)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Welcome step updated",
+		"step":    saved,
+	})
+}
+
+// Admin: Go back to a welcome step's built-in settings
+// DELETE /api/admin/welcome-sequence/:step
+func ResetWelcomeStep(c *gin.Context) {
+	err := welcome.Reset(database.DB, c.Param("step"))
+	if errors.Is(err, welcome.ErrUnknownStep) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Welcome step not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset the welcome step"})
+		return
+	}
+
+	middleware.LogActivity(c, "Reset welcome step", c.Param("step"))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Welcome step reset"})
+}
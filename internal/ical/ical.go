@@ -0,0 +1,157 @@
+// internal/ical/ical.go
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UIDDomain makes UIDs globally unique; it must never change or subscribers see duplicates
+const UIDDomain = "rccgsalvationcentre.org"
+
+// ContentType is the MIME type of a calendar
+const ContentType = "text/calendar; charset=utf-8"
+
+// UID returns the stable identifier for an entity, e.g. UID("event", 12)
+func UID(kind string, id uint) string {
+	return fmt.Sprintf("%s-%d@%s", kind, id, UIDDomain)
+}
+
+// Event is one VEVENT. A recurring event carries RRule; changed instances of it are
+// separate Events with the same UID and RecurrenceID set to the instance's usual start.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time // Zero for no end
+	AllDay       bool
+	RRule        string      // Without the "RRULE:" prefix
+	Until        time.Time   // Optional last moment of the series (inclusive)
+	ExDates      []time.Time // Instances removed from the series
+	RecurrenceID *time.Time
+	Modified     time.Time // Last edit; also used as DTSTAMP so output is stable
+}
+
+// Calendar is a VCALENDAR with a display name
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Bytes renders the calendar. Output only changes when the events do, so it can be
+// hashed for an ETag.
+func (c Calendar) Bytes() []byte {
+	var b bytes.Buffer
+	w := &writer{buf: &b}
+
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:-//RCCG Salvation Centre//Calendar//EN")
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		w.prop("X-WR-CALNAME", escape(c.Name))
+	}
+	// Ask subscribed clients to poll hourly so admin edits show up
+	w.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	w.line("X-PUBLISHED-TTL:PT1H")
+
+	for _, z := range c.zones() {
+		writeTimezone(w, z)
+	}
+	for _, e := range c.Events {
+		writeEvent(w, e)
+	}
+
+	w.line("END:VCALENDAR")
+	return b.Bytes()
+}
+
+func writeEvent(w *writer, e Event) {
+	w.line("BEGIN:VEVENT")
+	w.prop("UID", e.UID)
+	stamp := e.Modified
+	if stamp.IsZero() {
+		stamp = time.Unix(0, 0)
+	}
+	w.prop("DTSTAMP", stamp.UTC().Format("20060102T150405Z"))
+	w.prop("LAST-MODIFIED", stamp.UTC().Format("20060102T150405Z"))
+	if e.RecurrenceID != nil {
+		w.line(dateProp("RECURRENCE-ID", *e.RecurrenceID, e.AllDay))
+	}
+	w.line(dateProp("DTSTART", e.Start, e.AllDay))
+	switch {
+	case !e.End.IsZero():
+		w.line(dateProp("DTEND", e.End, e.AllDay))
+	case e.AllDay:
+		w.line(dateProp("DTEND", e.Start.AddDate(0, 0, 1), true))
+	}
+	if e.RRule != "" {
+		rule := e.RRule
+		switch {
+		case e.Until.IsZero():
+		case e.AllDay:
+			rule += ";UNTIL=" + e.Until.Format("20060102")
+		default:
+			rule += ";UNTIL=" + e.Until.UTC().Format("20060102T150405Z")
+		}
+		w.prop("RRULE", rule)
+	}
+	for _, ex := range e.ExDates {
+		w.line(dateProp("EXDATE", ex, e.AllDay))
+	}
+	w.prop("SUMMARY", escape(e.Summary))
+	if e.Description != "" {
+		w.prop("DESCRIPTION", escape(e.Description))
+	}
+	if e.Location != "" {
+		w.prop("LOCATION", escape(e.Location))
+	}
+	w.prop("STATUS", "CONFIRMED")
+	w.line("END:VEVENT")
+}
+
+// dateProp formats a date-time property in its own timezone where that has a
+// VTIMEZONE, otherwise in UTC; all-day values are plain dates
+func dateProp(name string, t time.Time, allDay bool) string {
+	if allDay {
+		return name + ";VALUE=DATE:" + t.Format("20060102")
+	}
+	if namedZone(t.Location()) {
+		return name + ";TZID=" + t.Location().String() + ":" + t.Format("20060102T150405")
+	}
+	return name + ":" + t.UTC().Format("20060102T150405Z")
+}
+
+// escape applies RFC 5545 TEXT escaping
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// writer emits CRLF-terminated content lines folded at 75 octets
+type writer struct {
+	buf *bytes.Buffer
+}
+
+func (w *writer) prop(name, value string) {
+	w.line(name + ":" + value)
+}
+
+func (w *writer) line(s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		// Do not split a multi-byte UTF-8 character
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.buf.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	w.buf.WriteString(s + "\r\n")
+}
@@ -0,0 +1,224 @@
+// internal/ical/timezone.go
+package ical
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// How many years past the last event a VTIMEZONE is worked out for. Its daylight
+// saving rules must hold for all of them to be written as yearly rules.
+const zoneYearsAhead = 10
+
+// zone is a named location the calendar's times are written in, and the years
+// those times fall in
+type zone struct {
+	loc         *time.Location
+	first, last int
+}
+
+// zones lists the named locations used, each needing a VTIMEZONE
+func (c Calendar) zones() []zone {
+	seen := map[string]*zone{}
+	for _, e := range c.Events {
+		if e.AllDay || !namedZone(e.Start.Location()) {
+			continue
+		}
+		times := append([]time.Time{e.Start, e.End, e.Until}, e.ExDates...)
+		if e.RecurrenceID != nil {
+			times = append(times, *e.RecurrenceID)
+		}
+		name := e.Start.Location().String()
+		z, ok := seen[name]
+		if !ok {
+			z = &zone{loc: e.Start.Location(), first: e.Start.Year(), last: e.Start.Year()}
+			seen[name] = z
+		}
+		for _, t := range times {
+			if t.IsZero() {
+				continue
+			}
+			if year := t.In(z.loc).Year(); year < z.first {
+				z.first = year
+			} else if year > z.last {
+				z.last = year
+			}
+		}
+	}
+
+	zones := make([]zone, 0, len(seen))
+	for _, z := range seen {
+		zones = append(zones, *z)
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].loc.String() < zones[j].loc.String() })
+	return zones
+}
+
+// namedZone reports whether times in loc are written with its TZID and a VTIMEZONE.
+// UTC, and the server's own unnamed zone, are written in UTC instead.
+func namedZone(loc *time.Location) bool {
+	return loc != time.UTC && loc != time.Local && loc.String() != "" && loc.String() != "UTC"
+}
+
+// transition is a change of UTC offset, such as the start of daylight saving
+type transition struct {
+	at       time.Time // The instant it happens
+	from, to int       // UTC offsets in seconds
+	name     string    // Abbreviation after the change, e.g. "BST"
+	dst      bool      // Whether it starts daylight saving
+}
+
+// wall is the local time the change happens at, as clocks showed it just before
+func (t transition) wall() time.Time {
+	return t.at.In(time.FixedZone("", t.from))
+}
+
+// transitions finds the offset changes in loc between from and to
+func transitions(loc *time.Location, from, to time.Time) []transition {
+	offsetAt := func(unix int64) int {
+		_, offset := time.Unix(unix, 0).In(loc).Zone()
+		return offset
+	}
+
+	var changes []transition
+	offset := offsetAt(from.Unix())
+	for day := from.Unix(); day < to.Unix(); day += 24 * 60 * 60 {
+		next := day + 24*60*60
+		if offsetAt(next) == offset {
+			continue
+		}
+		// Narrow it down to the second
+		lo, hi := day, next
+		for hi-lo > 1 {
+			if mid := lo + (hi-lo)/2; offsetAt(mid) == offset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		after := time.Unix(hi, 0).In(loc)
+		name, to := after.Zone()
+		changes = append(changes, transition{at: after.UTC(), from: offset, to: to, name: name, dst: after.IsDST()})
+		offset = to
+	}
+	return changes
+}
+
+// writeTimezone describes z's offsets. Daylight saving is written as yearly rules
+// (e.g. the last Sunday of March) so recurring events keep their local time for
+// as long as they run. Changes from years before the current rules took effect
+// are listed one by one.
+func writeTimezone(w *writer, z zone) {
+	from := time.Date(z.first-1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(z.last+zoneYearsAhead+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	changes := transitions(z.loc, from, to)
+
+	w.line("BEGIN:VTIMEZONE")
+	w.prop("TZID", z.loc.String())
+	if len(changes) == 0 {
+		name, offset := from.In(z.loc).Zone()
+		writeObservance(w, false, "19700101T000000", offset, offset, name, "")
+	}
+	for len(changes) > 0 {
+		if rules, ok := yearlyRules(changes, changes[0].wall().Year(), to.Year()-1); ok {
+			for _, r := range rules {
+				writeObservance(w, r.dst, r.wall().Format("20060102T150405"), r.from, r.to, r.name, r.rule)
+			}
+			break
+		}
+		t := changes[0]
+		writeObservance(w, t.dst, t.wall().Format("20060102T150405"), t.from, t.to, t.name, "")
+		changes = changes[1:]
+	}
+	w.line("END:VTIMEZONE")
+}
+
+func writeObservance(w *writer, dst bool, start string, from, to int, name, rule string) {
+	kind := "STANDARD"
+	if dst {
+		kind = "DAYLIGHT"
+	}
+	w.line("BEGIN:" + kind)
+	w.prop("DTSTART", start)
+	if rule != "" {
+		w.prop("RRULE", rule)
+	}
+	w.prop("TZOFFSETFROM", formatOffset(from))
+	w.prop("TZOFFSETTO", formatOffset(to))
+	w.prop("TZNAME", name)
+	w.line("END:" + kind)
+}
+
+// yearlyRule is a change that happens every year on the same weekday of the same month
+type yearlyRule struct {
+	transition // The first time it happens
+	rule       string
+}
+
+// yearlyRules describes changes as one rule for going onto daylight saving and one
+// for coming off it. It reports false unless each happens once in every year from
+// first to last, at the same local time, offsets and weekday of the month.
+func yearlyRules(changes []transition, first, last int) ([]yearlyRule, bool) {
+	var rules []yearlyRule
+	for _, dst := range []bool{false, true} {
+		var group []transition
+		for _, t := range changes {
+			if t.dst == dst {
+				group = append(group, t)
+			}
+		}
+		if len(group) != last-first+1 {
+			return nil, false
+		}
+
+		start := group[0]
+		candidates := byDay(start.wall())
+		for i, t := range group {
+			wall := t.wall()
+			if wall.Year() != first+i || wall.Month() != start.wall().Month() ||
+				wall.Format("150405") != start.wall().Format("150405") ||
+				t.from != start.from || t.to != start.to || t.name != start.name {
+				return nil, false
+			}
+			candidates = intersect(candidates, byDay(wall))
+		}
+		if len(candidates) == 0 {
+			return nil, false
+		}
+		rules = append(rules, yearlyRule{start, fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%s", start.wall().Month(), candidates[0])})
+	}
+	return rules, true
+}
+
+// byDay gives the BYDAY values that pick out t's day in its month, e.g. "2SU" for
+// the second Sunday, and also "-1SU" if it is the last one
+func byDay(t time.Time) []string {
+	day := strings.ToUpper(t.Weekday().String()[:2])
+	days := []string{fmt.Sprintf("%d%s", (t.Day()-1)/7+1, day)}
+	if t.AddDate(0, 0, 7).Month() != t.Month() {
+		days = append(days, "-1"+day)
+	}
+	return days
+}
+
+func intersect(a, b []string) []string {
+	var both []string
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				both = append(both, x)
+			}
+		}
+	}
+	return both
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
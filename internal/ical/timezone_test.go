@@ -0,0 +1,99 @@
+// internal/ical/timezone_test.go
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone data for %s is not available: %v", name, err)
+	}
+	return loc
+}
+
+// weekly renders a calendar with one weekly Sunday service at 9am in loc
+func weekly(loc *time.Location, year int) string {
+	start := time.Date(year, time.January, 5, 9, 0, 0, 0, loc)
+	for start.Weekday() != time.Sunday {
+		start = start.AddDate(0, 0, 1)
+	}
+	cal := Calendar{Events: []Event{{
+		UID:     UID("program", 1),
+		Summary: "Sunday Service",
+		Start:   start,
+		End:     start.Add(2 * time.Hour),
+		RRule:   "FREQ=WEEKLY;BYDAY=SU",
+	}}}
+	return strings.ReplaceAll(string(cal.Bytes()), "\r\n", "\n")
+}
+
+func TestTimezoneRules(t *testing.T) {
+	cases := []struct {
+		zone string
+		want []string
+	}{
+		{"Europe/London", []string{
+			"DTSTART;TZID=Europe/London:20250105T090000",
+			"BEGIN:DAYLIGHT\nDTSTART:20240331T010000\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\nTZOFFSETFROM:+0000\nTZOFFSETTO:+0100\nTZNAME:BST\nEND:DAYLIGHT",
+			"BEGIN:STANDARD\nDTSTART:20241027T020000\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU\nTZOFFSETFROM:+0100\nTZOFFSETTO:+0000\nTZNAME:GMT\nEND:STANDARD",
+		}},
+		{"America/New_York", []string{
+			"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+			"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+		}},
+		{"Australia/Sydney", []string{
+			"DTSTART:20241006T020000\nRRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=1SU\nTZOFFSETFROM:+1000\nTZOFFSETTO:+1100",
+			"DTSTART:20240407T030000\nRRULE:FREQ=YEARLY;BYMONTH=4;BYDAY=1SU\nTZOFFSETFROM:+1100\nTZOFFSETTO:+1000",
+		}},
+		{"Africa/Lagos", []string{
+			"DTSTART;TZID=Africa/Lagos:20250105T090000",
+			"BEGIN:STANDARD\nDTSTART:19700101T000000\nTZOFFSETFROM:+0100\nTZOFFSETTO:+0100\nTZNAME:WAT\nEND:STANDARD",
+		}},
+	}
+	for _, tc := range cases {
+		out := weekly(mustLoad(t, tc.zone), 2025)
+		if !strings.Contains(out, "TZID:"+tc.zone+"\n") {
+			t.Errorf("%s: no VTIMEZONE in\n%s", tc.zone, out)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: missing\n%s\nin\n%s", tc.zone, want, out)
+			}
+		}
+		if strings.Contains(out, "DTSTART:2025") {
+			t.Errorf("%s: event written in UTC:\n%s", tc.zone, out)
+		}
+	}
+}
+
+// Before 2007 the US changed clocks in April and October. Those years are listed
+// one by one, and the rules in force since then still carry the series forward.
+func TestTimezoneRulesChanged(t *testing.T) {
+	out := weekly(mustLoad(t, "America/New_York"), 2005)
+
+	for _, want := range []string{
+		"BEGIN:DAYLIGHT\nDTSTART:20050403T020000\nTZOFFSETFROM:-0500",
+		"BEGIN:STANDARD\nDTSTART:20061029T020000\nTZOFFSETFROM:-0400",
+		"BEGIN:DAYLIGHT\nDTSTART:20070311T020000\nRRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+		"BEGIN:STANDARD\nDTSTART:20071104T020000\nRRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing\n%s\nin\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "RRULE:FREQ=YEARLY"); n != 2 {
+		t.Errorf("got %d yearly rules, want 2", n)
+	}
+}
+
+func TestUTCHasNoTimezone(t *testing.T) {
+	out := weekly(time.UTC, 2025)
+	if strings.Contains(out, "BEGIN:VTIMEZONE") || !strings.Contains(out, "DTSTART:20250105T090000Z") {
+		t.Errorf("UTC calendar:\n%s", out)
+	}
+}
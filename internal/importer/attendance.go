@@ -0,0 +1,200 @@
+// internal/importer/attendance.go
+package importer
+
+import (
+	"strconv"
+	"strings"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/occurrence"
+
+	"gorm.io/gorm"
+)
+
+var attendanceKind = kindSpec{
+	fields: []string{
+		"date", "serviceType", "adults", "children", "total",
+		"firstTimers", "visitors", "members", "notes", "recordedBy",
+	},
+	extraFields: customCategoryFields,
+	validate:    validateAttendance,
+}
+
+// legacyCountFields maps the original column names onto built-in headcount categories
+var legacyCountFields = map[string]string{
+	"adults":      models.HeadcountAdults,
+	"children":    models.HeadcountChildren,
+	"firstTimers": models.HeadcountFirstTimers,
+	"visitors":    models.HeadcountVisitors,
+	"members":     models.HeadcountMembers,
+}
+
+// customCategoryFields lets each admin-defined headcount category be imported under its key
+func customCategoryFields(db *gorm.DB) ([]string, error) {
+	var keys []string
+	err := db.Model(&models.HeadcountCategory{}).
+		Where("built_in = ?", false).
+		Order("display_order, id").
+		Pluck("key", &keys).Error
+	return keys, err
+}
+
+type attendanceRows []models.Attendance
+
+func (rows attendanceRows) Len() int { return len(rows) }
+
+func (rows attendanceRows) Insert(tx *gorm.DB, batchID uint) error {
+	for i := range rows {
+		rows[i].ImportBatchID = &batchID
+		occ, err := occurrence.Ensure(tx, rows[i].Date, rows[i].ServiceTypeID, rows[i].ServiceType)
+		if err != nil {
+			return err
+		}
+		rows[i].ServiceOccurrenceID = &occ.ID
+	}
+	return tx.CreateInBatches([]models.Attendance(rows), 500).Error
+}
+
+func attendanceKey(date localtime.Date, serviceTypeID uint) string {
+	return date.String() + "|" + strconv.FormatUint(uint64(serviceTypeID), 10)
+}
+
+func validateAttendance(db *gorm.DB, records []record, opts Options, report *Report) (pendingRows, error) {
+	var serviceTypes []models.ServiceType
+	if err := db.Find(&serviceTypes).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.ServiceType, len(serviceTypes))
+	for _, st := range serviceTypes {
+		byName[strings.ToLower(st.Name)] = st
+	}
+
+	var categories []models.HeadcountCategory
+	if err := db.Order("display_order, id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	countFields := make(map[string]string, len(legacyCountFields)+len(categories))
+	for field, key := range legacyCountFields {
+		countFields[field] = key
+	}
+	for _, cat := range categories {
+		if !cat.BuiltIn {
+			countFields[cat.Key] = cat.Key
+		}
+	}
+
+	var rows attendanceRows
+	rowOf := make(map[string]int)
+	var minDate, maxDate localtime.Date
+
+	for _, rec := range records {
+		v := rec.values
+		fail := func(field, message string) {
+			report.Errors = append(report.Errors, RowError{Row: rec.row, Field: field, Message: message})
+		}
+		before := len(report.Errors)
+
+		var date localtime.Date
+		if v["date"] == "" {
+			fail("date", "date is required")
+		} else if parsed, err := parseDate(v["date"], opts.DateOrder); err != nil {
+			fail("date", err.Error())
+		} else {
+			date = localtime.Date{Time: parsed}
+		}
+
+		serviceType, known := byName[strings.ToLower(v["serviceType"])]
+		if v["serviceType"] == "" {
+			fail("serviceType", "serviceType is required")
+		} else if !known {
+			fail("serviceType", "unknown service type \""+v["serviceType"]+"\"")
+		}
+
+		counts := map[string]int{}
+		for field, key := range countFields {
+			if v[field] == "" {
+				continue
+			}
+			n, err := parseCount(v[field])
+			if err != nil {
+				fail(field, err.Error())
+				continue
+			}
+			counts[key] = n
+		}
+		suppliedTotal, err := parseCount(v["total"])
+		if err != nil {
+			fail("total", err.Error())
+		}
+
+		attendance := models.Attendance{
+			Date:          date,
+			ServiceTypeID: serviceType.ID,
+			ServiceType:   serviceType.Name,
+			Notes:         v["notes"],
+			RecordedBy:    v["recordedBy"],
+		}
+		if attendance.RecordedBy == "" {
+			attendance.RecordedBy = opts.CreatedBy
+		}
+
+		// Total is always computed; a supplied total is only used as a cross-check
+		if err := attendance.ApplyCounts(counts, categories); err != nil {
+			fail("", err.Error())
+		} else if v["total"] != "" && suppliedTotal != attendance.Total {
+			fail("total", "total does not equal the sum of the counted categories")
+		}
+
+		if len(report.Errors) > before {
+			continue
+		}
+
+		key := attendanceKey(date, serviceType.ID)
+		if first, ok := rowOf[key]; ok {
+			report.Duplicates = append(report.Duplicates, Duplicate{
+				Row: rec.row, Reason: "same date and service type as an earlier row", DuplicateOfRow: first,
+			})
+			continue
+		}
+		rowOf[key] = rec.row
+
+		rows = append(rows, attendance)
+		if minDate.IsZero() || date.Before(minDate) {
+			minDate = date
+		}
+		if date.After(maxDate) {
+			maxDate = date
+		}
+	}
+
+	if len(rows) == 0 {
+		return rows, nil
+	}
+
+	// Drop rows that already exist in the database
+	var existing []models.Attendance
+	if err := db.Select("id, date, service_type_id").
+		Where("date >= ? AND date < ?", minDate, maxDate.AddDays(1)).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	existingID := make(map[string]uint, len(existing))
+	for _, a := range existing {
+		existingID[attendanceKey(a.Date, a.ServiceTypeID)] = a.ID
+	}
+
+	kept := rows[:0]
+	for _, a := range rows {
+		key := attendanceKey(a.Date, a.ServiceTypeID)
+		if id, ok := existingID[key]; ok {
+			report.Duplicates = append(report.Duplicates, Duplicate{
+				Row: rowOf[key], Reason: "attendance already recorded for this date and service type", ExistingID: id,
+			})
+			continue
+		}
+		kept = append(kept, a)
+	}
+
+	return kept, nil
+}
@@ -0,0 +1,200 @@
+// internal/importer/first_timer.go
+package importer
+
+import (
+	"net/mail"
+	"strings"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/occurrence"
+
+	"gorm.io/gorm"
+)
+
+var firstTimerKind = kindSpec{
+	fields: []string{
+		"firstName", "lastName", "email", "phone", "address", "city", "state",
+		"dateOfBirth", "gender", "maritalStatus", "occupation", "visitDate",
+		"howDidYouHear", "prayerRequest", "interestedInMembership", "followUpStatus", "status",
+	},
+	validate: validateFirstTimers,
+}
+
+type firstTimerRows []models.FirstTimer
+
+func (rows firstTimerRows) Len() int { return len(rows) }
+
+func (rows firstTimerRows) Insert(tx *gorm.DB, batchID uint) error {
+	services := make(map[localtime.Date]*uint)
+	for i := range rows {
+		rows[i].ImportBatchID = &batchID
+		id, seen := services[rows[i].VisitDate]
+		if !seen {
+			var err error
+			if id, err = occurrence.OnlyOnDate(tx, rows[i].VisitDate); err != nil {
+				return err
+			}
+			services[rows[i].VisitDate] = id
+		}
+		rows[i].ServiceOccurrenceID = id
+	}
+	return tx.CreateInBatches([]models.FirstTimer(rows), 500).Error
+}
+
+// A visitor is considered the same person on the same day by name, or by email when given
+func firstTimerKeys(f models.FirstTimer) []string {
+	day := f.VisitDate.String()
+	keys := []string{"name|" + day + "|" + strings.ToLower(f.FirstName) + "|" + strings.ToLower(f.LastName)}
+	if f.Email != "" {
+		keys = append(keys, "email|"+day+"|"+strings.ToLower(f.Email))
+	}
+	return keys
+}
+
+func validateFirstTimers(db *gorm.DB, records []record, opts Options, report *Report) (pendingRows, error) {
+	var rows firstTimerRows
+	var rowNumbers []int
+	rowOf := make(map[string]int)
+	var minDate, maxDate localtime.Date
+
+	for _, rec := range records {
+		v := rec.values
+		fail := func(field, message string) {
+			report.Errors = append(report.Errors, RowError{Row: rec.row, Field: field, Message: message})
+		}
+		before := len(report.Errors)
+
+		if v["firstName"] == "" {
+			fail("firstName", "firstName is required")
+		}
+		if v["lastName"] == "" {
+			fail("lastName", "lastName is required")
+		}
+
+		var visitDate localtime.Date
+		if v["visitDate"] == "" {
+			fail("visitDate", "visitDate is required")
+		} else if parsed, err := parseDate(v["visitDate"], opts.DateOrder); err != nil {
+			fail("visitDate", err.Error())
+		} else {
+			visitDate = localtime.Date{Time: parsed}
+		}
+
+		dateOfBirth := ""
+		if v["dateOfBirth"] != "" {
+			if parsed, err := parseDate(v["dateOfBirth"], opts.DateOrder); err != nil {
+				fail("dateOfBirth", err.Error())
+			} else {
+				dateOfBirth = parsed.Format("2006-01-02")
+			}
+		}
+
+		if v["email"] != "" {
+			if _, err := mail.ParseAddress(v["email"]); err != nil {
+				fail("email", "invalid email address")
+			}
+		}
+
+		interested, err := parseBool(v["interestedInMembership"])
+		if err != nil {
+			fail("interestedInMembership", err.Error())
+		}
+
+		if len(report.Errors) > before {
+			continue
+		}
+
+		followUpStatus := v["followUpStatus"]
+		if followUpStatus == "" {
+			followUpStatus = "pending"
+		}
+		status := v["status"]
+		if status == "" {
+			status = "new"
+		}
+
+		firstTimer := models.FirstTimer{
+			FirstName:              v["firstName"],
+			LastName:               v["lastName"],
+			Email:                  v["email"],
+			Phone:                  v["phone"],
+			Address:                v["address"],
+			City:                   v["city"],
+			State:                  v["state"],
+			DateOfBirth:            dateOfBirth,
+			Gender:                 v["gender"],
+			MaritalStatus:          v["maritalStatus"],
+			Occupation:             v["occupation"],
+			VisitDate:              visitDate,
+			HowDidYouHear:          v["howDidYouHear"],
+			PrayerRequest:          v["prayerRequest"],
+			InterestedInMembership: interested,
+			FollowUpStatus:         followUpStatus,
+			Status:                 status,
+		}
+
+		duplicate := false
+		for _, key := range firstTimerKeys(firstTimer) {
+			if first, ok := rowOf[key]; ok {
+				report.Duplicates = append(report.Duplicates, Duplicate{
+					Row: rec.row, Reason: "same person and visit date as an earlier row", DuplicateOfRow: first,
+				})
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		for _, key := range firstTimerKeys(firstTimer) {
+			rowOf[key] = rec.row
+		}
+
+		rows = append(rows, firstTimer)
+		rowNumbers = append(rowNumbers, rec.row)
+		if minDate.IsZero() || visitDate.Before(minDate) {
+			minDate = visitDate
+		}
+		if visitDate.After(maxDate) {
+			maxDate = visitDate
+		}
+	}
+
+	if len(rows) == 0 {
+		return rows, nil
+	}
+
+	// Drop people already recorded for the same visit
+	var existing []models.FirstTimer
+	if err := db.Select("id, first_name, last_name, email, visit_date").
+		Where("visit_date >= ? AND visit_date < ?", minDate, maxDate.AddDays(1)).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	existingID := make(map[string]uint, len(existing)*2)
+	for _, f := range existing {
+		for _, key := range firstTimerKeys(f) {
+			existingID[key] = f.ID
+		}
+	}
+
+	var kept firstTimerRows
+	for i, f := range rows {
+		duplicate := false
+		for _, key := range firstTimerKeys(f) {
+			if id, ok := existingID[key]; ok {
+				report.Duplicates = append(report.Duplicates, Duplicate{
+					Row: rowNumbers[i], Reason: "first-timer already recorded for this visit", ExistingID: id,
+				})
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, f)
+		}
+	}
+
+	return kept, nil
+}
@@ -0,0 +1,300 @@
+// internal/importer/importer.go
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MaxRows caps a single import so one upload cannot hold a transaction open for too long
+const MaxRows = 20000
+
+// Options controls how a CSV file is read and whether it is committed
+type Options struct {
+	Kind string
+	// Mapping maps a target field (e.g. "visitDate") to the CSV header holding it.
+	// Fields left out are matched against headers with the same name.
+	Mapping map[string]string
+	// DateOrder is "dmy" (default, 02/01/2006) or "mdy" (01/02/2006) for slash-separated dates
+	DateOrder string
+	DryRun    bool
+	Filename  string
+	CreatedBy string
+}
+
+// RowError describes why a row cannot be imported. Row numbers count the header as row 1.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Duplicate is a row that matches an existing record or an earlier row of the same file
+type Duplicate struct {
+	Row            int    `json:"row"`
+	Reason         string `json:"reason"`
+	ExistingID     uint   `json:"existingId,omitempty"`
+	DuplicateOfRow int    `json:"duplicateOfRow,omitempty"`
+}
+
+// Report is returned for both dry runs and commits
+type Report struct {
+	Kind       string            `json:"kind"`
+	DryRun     bool              `json:"dryRun"`
+	Committed  bool              `json:"committed"`
+	BatchID    uint              `json:"batchId,omitempty"`
+	TotalRows  int               `json:"totalRows"`
+	ValidRows  int               `json:"validRows"`
+	Imported   int               `json:"imported"`
+	Mapping    map[string]string `json:"mapping"`
+	Errors     []RowError        `json:"errors"`
+	Duplicates []Duplicate       `json:"duplicates"`
+}
+
+// ErrInvalidFile is returned when the CSV itself cannot be used (as opposed to bad rows)
+var ErrInvalidFile = errors.New("invalid import file")
+
+// kindSpec describes one importable record type
+type kindSpec struct {
+	fields []string
+	// extraFields adds fields defined at runtime, such as custom headcount categories
+	extraFields func(db *gorm.DB) ([]string, error)
+	validate    func(db *gorm.DB, records []record, opts Options, report *Report) (pendingRows, error)
+}
+
+// pendingRows holds the validated rows of one import, ready to be inserted
+type pendingRows interface {
+	Len() int
+	Insert(tx *gorm.DB, batchID uint) error
+}
+
+// record is one parsed CSV row, keyed by target field
+type record struct {
+	row    int
+	values map[string]string
+}
+
+// Run validates every row of r and, unless opts.DryRun is set or any row is
+// invalid, imports the valid non-duplicate rows in a single transaction tagged
+// with a new ImportBatch.
+func Run(db *gorm.DB, r io.Reader, opts Options) (*Report, error) {
+	var kind kindSpec
+	switch opts.Kind {
+	case models.ImportKindAttendance:
+		kind = attendanceKind
+	case models.ImportKindFirstTimers:
+		kind = firstTimerKind
+	default:
+		return nil, fmt.Errorf("%w: unknown import kind %q", ErrInvalidFile, opts.Kind)
+	}
+	if opts.DateOrder == "" {
+		opts.DateOrder = "dmy"
+	}
+	if opts.DateOrder != "dmy" && opts.DateOrder != "mdy" {
+		return nil, fmt.Errorf("%w: dateOrder must be 'dmy' or 'mdy'", ErrInvalidFile)
+	}
+
+	fields := kind.fields
+	if kind.extraFields != nil {
+		extra, err := kind.extraFields(db)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(append([]string(nil), fields...), extra...)
+	}
+
+	records, mapping, err := readRecords(r, fields, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Kind:       opts.Kind,
+		DryRun:     opts.DryRun,
+		TotalRows:  len(records),
+		Mapping:    mapping,
+		Errors:     []RowError{},
+		Duplicates: []Duplicate{},
+	}
+
+	rows, err := kind.validate(db, records, opts, report)
+	if err != nil {
+		return nil, err
+	}
+	report.ValidRows = rows.Len() + len(report.Duplicates)
+
+	if opts.DryRun || len(report.Errors) > 0 || rows.Len() == 0 {
+		return report, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		batch := models.ImportBatch{
+			Kind:        opts.Kind,
+			Filename:    opts.Filename,
+			RowCount:    rows.Len(),
+			SkippedRows: len(report.Duplicates),
+			CreatedBy:   opts.CreatedBy,
+		}
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+		if err := rows.Insert(tx, batch.ID); err != nil {
+			return err
+		}
+		report.BatchID = batch.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Committed = true
+	report.Imported = rows.Len()
+	return report, nil
+}
+
+// Rollback deletes every row created by an import batch
+func Rollback(db *gorm.DB, batchID uint, by string) (*models.ImportBatch, int64, error) {
+	var batch models.ImportBatch
+	var removed int64
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&batch, batchID).Error; err != nil {
+			return err
+		}
+		if batch.RolledBackAt != nil {
+			return ErrAlreadyRolledBack
+		}
+
+		var model interface{}
+		switch batch.Kind {
+		case models.ImportKindAttendance:
+			model = &models.Attendance{}
+		case models.ImportKindFirstTimers:
+			model = &models.FirstTimer{}
+		default:
+			return fmt.Errorf("unknown import kind %q", batch.Kind)
+		}
+
+		result := tx.Where("import_batch_id = ?", batch.ID).Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+
+		now := time.Now()
+		batch.RolledBackAt = &now
+		batch.RolledBackBy = by
+		return tx.Save(&batch).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return &batch, removed, nil
+}
+
+// ErrAlreadyRolledBack is returned when rolling back a batch twice
+var ErrAlreadyRolledBack = errors.New("import batch already rolled back")
+
+// readRecords reads the whole file and resolves each target field to a column
+func readRecords(r io.Reader, fields []string, mapping map[string]string) ([]record, map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: could not read header row: %v", ErrInvalidFile, err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[normalizeHeader(h)] = i
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, nil, fmt.Errorf("%w: unknown field %q in mapping", ErrInvalidFile, field)
+		}
+	}
+
+	// field -> column index
+	index := make(map[string]int)
+	resolved := make(map[string]string)
+	for _, field := range fields {
+		source := field
+		if mapped, ok := mapping[field]; ok {
+			source = mapped
+		}
+		if source == "" {
+			continue
+		}
+		i, ok := columns[normalizeHeader(source)]
+		if !ok {
+			if _, explicit := mapping[field]; explicit {
+				return nil, nil, fmt.Errorf("%w: column %q mapped to %q not found", ErrInvalidFile, source, field)
+			}
+			continue
+		}
+		index[field] = i
+		resolved[field] = header[i]
+	}
+
+	var records []record
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		// Report the line the row starts on, which is what spreadsheet users see
+		row, _ := reader.FieldPos(0)
+		if blankRow(cells) {
+			continue
+		}
+		if len(records) >= MaxRows {
+			return nil, nil, fmt.Errorf("%w: more than %d rows", ErrInvalidFile, MaxRows)
+		}
+
+		values := make(map[string]string, len(index))
+		for field, i := range index {
+			if i < len(cells) {
+				values[field] = strings.TrimSpace(cells[i])
+			}
+		}
+		records = append(records, record{row: row, values: values})
+	}
+
+	return records, resolved, nil
+}
+
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(h)
+}
+
+func blankRow(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+// internal/importer/parse.go
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var isoLayouts = []string{"2006-01-02", "2006/01/02", "2 Jan 2006", "2 January 2006", "Jan 2, 2006", "January 2, 2006"}
+
+var dayFirstLayouts = []string{"02/01/2006", "2/1/2006", "02-01-2006", "2-1-2006", "02.01.2006", "2/1/2006 15:04:05", "02/01/2006 15:04:05", "2/1/06"}
+
+var monthFirstLayouts = []string{"01/02/2006", "1/2/2006", "01-02-2006", "1-2-2006", "1/2/2006 15:04:05", "01/02/2006 15:04:05", "1/2/06"}
+
+// parseDate accepts ISO dates, common spreadsheet formats and Google Forms timestamps
+func parseDate(value string, order string) (time.Time, error) {
+	layouts := append([]string{}, isoLayouts...)
+	if order == "mdy" {
+		layouts = append(layouts, monthFirstLayouts...)
+	} else {
+		layouts = append(layouts, dayFirstLayouts...)
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", value)
+}
+
+// parseCount reads a non-negative whole number; blank counts as zero
+func parseCount(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.ReplaceAll(value, ",", ""))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a whole number", value)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%q must not be negative", value)
+	}
+	return n, nil
+}
+
+// parseBool understands the yes/no answers forms usually produce; blank is false
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "", "no", "n", "false", "0":
+		return false, nil
+	case "yes", "y", "true", "1":
+		return true, nil
+	}
+	return false, fmt.Errorf("%q is not yes/no", value)
+}
@@ -0,0 +1,141 @@
+// internal/localtime/date.go
+package localtime
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Date is a calendar day with no time of day or zone, such as a service date or a
+// visit date. It is held as midnight UTC, stored in a Postgres date column and
+// written to JSON as "YYYY-MM-DD".
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the given calendar day
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// DateOf is the church-local calendar day on which the instant t falls
+func DateOf(t time.Time) Date {
+	local := t.In(Location())
+	return NewDate(local.Year(), local.Month(), local.Day())
+}
+
+// Today is the current calendar day in the church's timezone
+func Today() Date {
+	return DateOf(time.Now())
+}
+
+// ParseDate reads a "YYYY-MM-DD" day
+func ParseDate(value string) (Date, error) {
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return Date{}, errors.New("date must be YYYY-MM-DD")
+	}
+	return Date{t}, nil
+}
+
+// String formats the day as "YYYY-MM-DD"
+func (d Date) String() string {
+	return d.Format(dateLayout)
+}
+
+// AddDays moves the day forwards (or backwards for negative n)
+func (d Date) AddDays(n int) Date {
+	return Date{d.AddDate(0, 0, n)}
+}
+
+// Before and After compare two days
+func (d Date) Before(other Date) bool { return d.Time.Before(other.Time) }
+func (d Date) After(other Date) bool  { return d.Time.After(other.Time) }
+
+// Start is the moment the day begins in the church's timezone
+func (d Date) Start() time.Time {
+	return d.In(Location())
+}
+
+// In is the moment the day begins in loc
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+}
+
+// At is the moment a "HH:MM" clock time falls on this day in the church's timezone
+func (d Date) At(clock string) (time.Time, error) {
+	hour, minute, err := ParseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), hour, minute, 0, 0, Location()), nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts "YYYY-MM-DD" or, for older clients, an RFC 3339 timestamp
+// (whose own calendar day is kept)
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.New("date must be a string in YYYY-MM-DD form")
+	}
+	if s == "" {
+		*d = Date{}
+		return nil
+	}
+	if parsed, err := ParseDate(s); err == nil {
+		*d = parsed
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return errors.New("date must be YYYY-MM-DD")
+	}
+	*d = NewDate(t.Year(), t.Month(), t.Day())
+	return nil
+}
+
+// Scan reads a date (or timestamp) column, keeping its calendar day
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v.Year(), v.Month(), v.Day())
+	case string:
+		parsed, err := ParseDate(v[:min(len(v), len(dateLayout))])
+		if err != nil {
+			return err
+		}
+		*d = parsed
+	case []byte:
+		return d.Scan(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into a date", value)
+	}
+	return nil
+}
+
+// Value writes the day as "YYYY-MM-DD" so the database never applies a zone to it
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
+
+// GormDataType makes AutoMigrate create a date column
+func (Date) GormDataType() string {
+	return "date"
+}
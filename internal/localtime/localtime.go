@@ -0,0 +1,61 @@
+// internal/localtime/localtime.go
+package localtime
+
+import (
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultZone is used when CHURCH_TIMEZONE is not set
+const DefaultZone = "Africa/Lagos"
+
+var (
+	loadOnce sync.Once
+	location *time.Location
+)
+
+// Location is the church's timezone, from CHURCH_TIMEZONE (an IANA name).
+// Every "today", "this week" and service time is reckoned in it.
+func Location() *time.Location {
+	loadOnce.Do(func() {
+		name := os.Getenv("CHURCH_TIMEZONE")
+		if name == "" {
+			name = DefaultZone
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("Unknown CHURCH_TIMEZONE %q, using %s: %v", name, DefaultZone, err)
+			if loc, err = time.LoadLocation(DefaultZone); err != nil {
+				loc = time.FixedZone("WAT", 60*60)
+			}
+		}
+		location = loc
+	})
+	return location
+}
+
+// Now is the current time in the church's timezone
+func Now() time.Time {
+	return time.Now().In(Location())
+}
+
+// In shows t in the church's timezone; nil stays nil
+func In(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(Location())
+	return &local
+}
+
+// ParseClock reads a 24-hour "HH:MM" time of day
+func ParseClock(value string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, errors.New("time must be HH:MM")
+	}
+	return t.Hour(), t.Minute(), nil
+}
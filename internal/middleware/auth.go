@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"rccg-salvation-centre-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func AuthRequired() gin.HandlerFunc {
@@ -73,6 +75,17 @@ func RequireRoles(allowed ...string) gin.HandlerFunc {
 	}
 }
 
+// HasRole reports whether the authenticated admin holds one of the given roles
+func HasRole(c *gin.Context, allowed ...string) bool {
+	role := c.GetString("adminRole")
+	for _, r := range allowed {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
 func RequireSuperAdmin() gin.HandlerFunc {
 	return RequireRoles("superadmin")
 }
@@ -85,11 +98,32 @@ func LogActivity(c *gin.Context, action string, details string) {
 		return
 	}
 
-	go database.DB.Create(&models.ActivityLog{
+	// Written before responding, so an entry is not lost if the server stops
+	if err := database.DB.Create(&models.ActivityLog{
+		AdminID:    adminID.(uint),
+		AdminEmail: email.(string),
+		Action:     action,
+		Details:    details,
+		CreatedAt:  time.Now(),
+	}).Error; err != nil {
+		log.Printf("Failed to log activity %q: %v", action, err)
+	}
+}
+
+// LogActivityTx records an audit entry inside tx, so it commits or rolls back with the change it describes
+func LogActivityTx(tx *gorm.DB, c *gin.Context, action string, details string) error {
+	email, _ := c.Get("adminEmail")
+	adminID, _ := c.Get("adminID")
+
+	if email == nil || adminID == nil {
+		return nil
+	}
+
+	return tx.Create(&models.ActivityLog{
 		AdminID:    adminID.(uint),
 		AdminEmail: email.(string),
 		Action:     action,
 		Details:    details,
 		CreatedAt:  time.Now(),
-	})
+	}).Error
 }
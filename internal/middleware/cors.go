@@ -22,8 +22,8 @@ func CORSMiddleware() gin.HandlerFunc {
 
 		// Always allow these headers and methods
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, Accept, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, Accept, X-Requested-With, X-Device-Key, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Type, Idempotent-Replayed")
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 
 		// Handle preflight OPTIONS requests
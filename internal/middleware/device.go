@@ -0,0 +1,48 @@
+// internal/middleware/device.go
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/auth"
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceKeyHeader carries the key of a registered sync device
+const DeviceKeyHeader = "X-Device-Key"
+
+// DeviceAuthRequired authenticates offline devices by their key and stores the device in the context
+func DeviceAuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(DeviceKeyHeader))
+		if key == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Device key missing"})
+			c.Abort()
+			return
+		}
+
+		var device models.Device
+		if err := database.DB.Where("key_hash = ?", auth.HashDeviceKey(key)).First(&device).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: Unknown device key"})
+			c.Abort()
+			return
+		}
+		if device.RevokedAt != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Device has been revoked"})
+			c.Abort()
+			return
+		}
+
+		now := time.Now()
+		database.DB.Model(&device).UpdateColumn("last_seen_at", now)
+		device.LastSeenAt = &now
+
+		c.Set("device", device)
+		c.Next()
+	}
+}
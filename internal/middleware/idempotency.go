@@ -0,0 +1,159 @@
+// internal/middleware/idempotency.go
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/scheduler"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyKeyHeader lets a client retry a submission safely
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// How long a stored response is replayed for
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// idempotentWriter keeps a copy of the response so it can be replayed
+type idempotentWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotentWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotentWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyScope is what a key is unique within: the method and path, and who
+// sent the request, so two clients choosing the same key don't see each other's
+// responses
+func idempotencyScope(c *gin.Context) string {
+	sender := "ip:" + c.ClientIP()
+	if email := c.GetString("adminEmail"); email != "" {
+		sender = "admin:" + email
+	} else if device, ok := c.Get("device"); ok {
+		if d, ok := device.(models.Device); ok {
+			sender = fmt.Sprintf("device:%d", d.ID)
+		}
+	}
+	return c.Request.Method + " " + c.Request.URL.Path + " " + sender
+}
+
+// Idempotent makes a public POST safe to retry. A request sent with an
+// Idempotency-Key header that the same client already used on the same path gets
+// the first response again instead of being processed twice. Requests without the
+// header are processed as usual.
+func Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		record := models.IdempotencyKey{
+			Scope:       idempotencyScope(c),
+			Key:         key,
+			RequestHash: hex.EncodeToString(sum[:]),
+		}
+		// An expired key may be used again
+		database.DB.Where("scope = ? AND key = ? AND created_at < ?", record.Scope, key, time.Now().Add(-IdempotencyKeyTTL)).
+			Delete(&models.IdempotencyKey{})
+
+		result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process request"})
+			c.Abort()
+			return
+		}
+		if result.RowsAffected == 0 {
+			var existing models.IdempotencyKey
+			if err := database.DB.Where("scope = ? AND key = ?", record.Scope, key).First(&existing).Error; err != nil {
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+				c.Abort()
+				return
+			}
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "This Idempotency-Key was already used for a different request"})
+			case existing.StatusCode == 0:
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, "application/json; charset=utf-8", []byte(existing.ResponseBody))
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &idempotentWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			// A handler that panicked gave no response worth replaying; drop the key
+			// so a retry is processed instead of being told it is still running
+			if r := recover(); r != nil {
+				database.DB.Delete(&record)
+				panic(r)
+			}
+			// Server errors are not stored so the client can try again
+			if status := writer.Status(); status >= http.StatusInternalServerError {
+				database.DB.Delete(&record)
+			} else {
+				database.DB.Model(&record).Updates(map[string]interface{}{
+					"status_code":   status,
+					"response_body": writer.body.String(),
+				})
+			}
+		}()
+		c.Next()
+	}
+}
+
+// RegisterJobs adds the hourly purge of expired idempotency keys
+func RegisterJobs() {
+	scheduler.Register(scheduler.Job{
+		Name:  "idempotency-keys",
+		Every: time.Hour,
+		Run: func(ctx context.Context) error {
+			result := database.DB.WithContext(ctx).Where("created_at < ?", time.Now().Add(-IdempotencyKeyTTL)).
+				Delete(&models.IdempotencyKey{})
+			if result.Error == nil && result.RowsAffected > 0 {
+				log.Printf("[Idempotency] Purged %d expired keys", result.RowsAffected)
+			}
+			return result.Error
+		},
+	})
+}
@@ -1,20 +1,172 @@
 // internal/models/attendance.go
 package models
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+
+	"gorm.io/gorm"
+)
 
 type Attendance struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Date        time.Time `gorm:"not null" json:"date"`
-	ServiceType string    `gorm:"size:100;not null" json:"serviceType"`
-	Adults      int       `json:"adults"`
-	Children    int       `json:"children"`
-	Total       int       `json:"total"`
-	FirstTimers int       `json:"firstTimers"`
-	Visitors    int       `json:"visitors"`
-	Members     int       `json:"members"`
-	Notes       string    `gorm:"type:text" json:"notes"`
-	RecordedBy  string    `gorm:"size:100" json:"recordedBy"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	Date           localtime.Date    `gorm:"not null" json:"date"`
+	ServiceTypeID  uint              `gorm:"index" json:"serviceTypeId"`
+	ServiceType    string            `gorm:"size:100;not null" json:"serviceType"` // Name of the linked service type, kept in sync on rename
+	ServiceTypeRef ServiceType       `gorm:"foreignKey:ServiceTypeID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	Counts         []AttendanceCount `gorm:"foreignKey:AttendanceID;constraint:OnDelete:CASCADE" json:"counts"`
+	// The columns below mirror the built-in headcount categories for older clients and reports
+	Adults        int    `json:"adults"`
+	Children      int    `json:"children"`
+	Total         int    `json:"total"` // Sum of the categories that count toward the total, computed server-side
+	FirstTimers   int    `json:"firstTimers"`
+	Visitors      int    `json:"visitors"`
+	Members       int    `json:"members"`
+	Notes         string `gorm:"type:text" json:"notes"`
+	RecordedBy    string `gorm:"size:100" json:"recordedBy"`
+	ImportBatchID *uint  `gorm:"index" json:"importBatchId,omitempty"`
+	// The service this headcount belongs to, found or created whenever the record is saved
+	ServiceOccurrenceID *uint             `gorm:"index" json:"serviceOccurrenceId"`
+	ServiceOccurrence   ServiceOccurrence `gorm:"foreignKey:ServiceOccurrenceID;constraint:OnDelete:SET NULL" json:"-"`
+	// Set when the secretariat signs off the final figures; locked records cannot be edited
+	LockedAt  *time.Time   `json:"lockedAt"`
+	LockedBy  string       `gorm:"size:100" json:"lockedBy,omitempty"`
+	Sheets    []CountSheet `gorm:"foreignKey:AttendanceID;constraint:OnDelete:CASCADE" json:"-"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// DuplicateAttendance narrows a query to records that share their service and date
+// with another record, which older versions allowed
+func DuplicateAttendance(db *gorm.DB) *gorm.DB {
+	return db.Where(`(date, service_type_id) IN (
+		SELECT date, service_type_id FROM attendances GROUP BY date, service_type_id HAVING COUNT(*) > 1
+	)`)
+}
+
+// ErrInvalidAttendance wraps every validation failure from ApplyCounts
+var ErrInvalidAttendance = errors.New("invalid attendance")
+
+// CountsByKey returns the stored counts keyed by category key
+func (a *Attendance) CountsByKey(categories []HeadcountCategory) map[string]int {
+	keys := make(map[uint]string, len(categories))
+	for _, cat := range categories {
+		keys[cat.ID] = cat.Key
+	}
+
+	counts := make(map[string]int, len(a.Counts))
+	for _, c := range a.Counts {
+		if key, ok := keys[c.CategoryID]; ok {
+			counts[key] = c.Count
+		}
+	}
+	return counts
+}
+
+// ApplyCounts replaces the per-category counts, recomputes Total and mirrors the
+// built-in categories onto the legacy columns. Every group marked as reconciling
+// must add up to the total whenever any of its categories is recorded.
+func (a *Attendance) ApplyCounts(counts map[string]int, categories []HeadcountCategory) error {
+	total, err := tallyCounts(counts, categories)
+	if err != nil {
+		return err
+	}
+	if problems := UnreconciledGroups(counts, total, categories); len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidAttendance, problems[0])
+	}
+	return a.SetCounts(counts, categories)
+}
+
+// SetCounts is ApplyCounts without the reconciliation check. It is used when the
+// counts are rolled up from count sheets, where mismatches are reported instead.
+func (a *Attendance) SetCounts(counts map[string]int, categories []HeadcountCategory) error {
+	total, err := tallyCounts(counts, categories)
+	if err != nil {
+		return err
+	}
+
+	// Walk the categories rather than the map so counts are stored in display order
+	var stored []AttendanceCount
+	for _, cat := range categories {
+		if n := counts[cat.Key]; n != 0 {
+			stored = append(stored, AttendanceCount{AttendanceID: a.ID, CategoryID: cat.ID, Count: n})
+		}
+	}
+
+	a.Counts = stored
+	a.Total = total
+	a.Adults = counts[HeadcountAdults]
+	a.Children = counts[HeadcountChildren]
+	a.Members = counts[HeadcountMembers]
+	a.Visitors = counts[HeadcountVisitors]
+	a.FirstTimers = counts[HeadcountFirstTimers]
+	return nil
+}
+
+// UnreconciledGroups describes each reconciling group whose recorded counts do not add up to total
+func UnreconciledGroups(counts map[string]int, total int, categories []HeadcountCategory) []string {
+	sums := make(map[string]int)
+	var groups []string
+	for _, cat := range categories {
+		n := counts[cat.Key]
+		if !cat.ReconcilesWithTotal || n == 0 {
+			continue
+		}
+		if _, seen := sums[cat.GroupName]; !seen {
+			groups = append(groups, cat.GroupName)
+		}
+		sums[cat.GroupName] += n
+	}
+
+	var problems []string
+	for _, group := range groups {
+		if sums[group] != total {
+			problems = append(problems, fmt.Sprintf("%s counts add up to %d but the total is %d", group, sums[group], total))
+		}
+	}
+	return problems
+}
+
+// tallyCounts rejects unknown categories and negative counts and returns the total
+func tallyCounts(counts map[string]int, categories []HeadcountCategory) (int, error) {
+	byKey := make(map[string]HeadcountCategory, len(categories))
+	for _, cat := range categories {
+		byKey[cat.Key] = cat
+	}
+
+	total := 0
+	for key, n := range counts {
+		cat, ok := byKey[key]
+		if !ok {
+			return 0, fmt.Errorf("%w: unknown headcount category %q", ErrInvalidAttendance, key)
+		}
+		if n < 0 {
+			return 0, fmt.Errorf("%w: %s cannot be negative", ErrInvalidAttendance, cat.Name)
+		}
+		if cat.CountsTowardTotal {
+			total += n
+		}
+	}
+	return total, nil
+}
+
+// LegacyCounts maps the original per-column fields onto their built-in category keys,
+// skipping any field that was not supplied
+func LegacyCounts(adults, children, members, visitors, firstTimers *int) map[string]int {
+	counts := make(map[string]int)
+	for key, n := range map[string]*int{
+		HeadcountAdults:      adults,
+		HeadcountChildren:    children,
+		HeadcountMembers:     members,
+		HeadcountVisitors:    visitors,
+		HeadcountFirstTimers: firstTimers,
+	} {
+		if n != nil {
+			counts[key] = *n
+		}
+	}
+	return counts
 }
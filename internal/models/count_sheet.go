@@ -0,0 +1,82 @@
+// internal/models/count_sheet.go
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// AttendanceSection is an area ushers count separately, e.g. "Main Auditorium" or "Overflow Tent".
+// Expected sections must have a count sheet before a service can be signed off cleanly.
+type AttendanceSection struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Name         string    `gorm:"size:100;unique;not null" json:"name"`
+	Expected     bool      `gorm:"not null" json:"expected"`
+	DisplayOrder int       `gorm:"not null;default:0" json:"displayOrder"`
+	Active       bool      `gorm:"not null" json:"active"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// CountSheet is one usher's count of one section at a service. The attendance
+// record's counts are the sum of its sheets.
+type CountSheet struct {
+	ID           uint              `gorm:"primaryKey" json:"id"`
+	AttendanceID uint              `gorm:"not null;uniqueIndex:idx_count_sheet_section" json:"attendanceId"`
+	SectionID    uint              `gorm:"not null;uniqueIndex:idx_count_sheet_section;index" json:"sectionId"`
+	Section      AttendanceSection `gorm:"foreignKey:SectionID;constraint:OnDelete:RESTRICT" json:"section"`
+	Counts       []CountSheetCount `gorm:"foreignKey:CountSheetID;constraint:OnDelete:CASCADE" json:"counts"`
+	Total        int               `json:"total"`
+	CountedBy    string            `gorm:"size:100;not null" json:"countedBy"` // The usher who did the count
+	CountedAt    time.Time         `gorm:"not null" json:"countedAt"`
+	SubmittedBy  string            `gorm:"size:100" json:"submittedBy"` // Admin account that entered the sheet
+	Notes        string            `gorm:"type:text" json:"notes"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+}
+
+// CountSheetCount is the headcount of one category on one sheet
+type CountSheetCount struct {
+	ID           uint `gorm:"primaryKey" json:"-"`
+	CountSheetID uint `gorm:"not null;uniqueIndex:idx_count_sheet_category" json:"-"`
+	CategoryID   uint `gorm:"not null;uniqueIndex:idx_count_sheet_category" json:"categoryId"`
+	Count        int  `gorm:"not null" json:"count"`
+}
+
+// ApplyCounts validates and stores the sheet's counts. A sheet is one person's count,
+// so reconciling groups must add up to the sheet total.
+func (s *CountSheet) ApplyCounts(counts map[string]int, categories []HeadcountCategory) error {
+	total, err := tallyCounts(counts, categories)
+	if err != nil {
+		return err
+	}
+	if problems := UnreconciledGroups(counts, total, categories); len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidAttendance, problems[0])
+	}
+
+	var stored []CountSheetCount
+	for _, cat := range categories {
+		if n := counts[cat.Key]; n != 0 {
+			stored = append(stored, CountSheetCount{CountSheetID: s.ID, CategoryID: cat.ID, Count: n})
+		}
+	}
+	s.Counts = stored
+	s.Total = total
+	return nil
+}
+
+// CountsByKey returns the sheet's counts keyed by category key
+func (s *CountSheet) CountsByKey(categories []HeadcountCategory) map[string]int {
+	keys := make(map[uint]string, len(categories))
+	for _, cat := range categories {
+		keys[cat.ID] = cat.Key
+	}
+
+	counts := make(map[string]int, len(s.Counts))
+	for _, c := range s.Counts {
+		if key, ok := keys[c.CategoryID]; ok {
+			counts[key] = c.Count
+		}
+	}
+	return counts
+}
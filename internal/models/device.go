@@ -0,0 +1,47 @@
+// internal/models/device.go
+package models
+
+import "time"
+
+// What a device is used for, which limits the sync operations it may send
+const (
+	DevicePurposeUsher       = "usher"
+	DevicePurposeWelcomeDesk = "welcome_desk"
+)
+
+// Device is a phone or tablet that syncs queued work with a device key instead of an admin session
+type Device struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Purpose    string     `gorm:"size:50;not null" json:"purpose"`
+	KeyPrefix  string     `gorm:"size:12;not null" json:"keyPrefix"` // Shown to admins to tell keys apart
+	KeyHash    string     `gorm:"size:64;unique;not null" json:"-"`
+	CreatedBy  string     `gorm:"size:100" json:"createdBy"`
+	LastSeenAt *time.Time `json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// Outcomes of a sync operation
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict"
+	SyncStatusRejected = "rejected"
+)
+
+// SyncOperation records each operation a device has sent, keyed by the UUID the
+// device generated, so a replayed operation returns its original result
+type SyncOperation struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	ClientID        string     `gorm:"size:36;uniqueIndex;not null" json:"clientId"`
+	DeviceID        uint       `gorm:"not null;index" json:"deviceId"`
+	Type            string     `gorm:"size:50;not null" json:"type"`
+	PayloadHash     string     `gorm:"size:64;not null" json:"-"`
+	Status          string     `gorm:"size:20;not null;index" json:"status"`
+	ResultID        *uint      `json:"resultId,omitempty"`   // Record created or updated
+	ExistingID      *uint      `json:"existingId,omitempty"` // Record the operation clashed with
+	Message         string     `gorm:"type:text" json:"message,omitempty"`
+	ClientCreatedAt *time.Time `json:"clientCreatedAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
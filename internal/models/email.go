@@ -0,0 +1,43 @@
+// internal/models/email.go
+package models
+
+import "time"
+
+// EmailTemplate is the wording of one kind of email, edited by admins. Subject and
+// TextBody are Go text templates and HTMLBody an HTML template, filled in with the
+// fields listed for the type.
+type EmailTemplate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Type      string    `gorm:"size:100;uniqueIndex;not null" json:"type"` // e.g. "testimony.submitted"
+	Subject   string    `gorm:"size:255;not null" json:"subject"`
+	HTMLBody  string    `gorm:"type:text;not null" json:"htmlBody"`
+	TextBody  string    `gorm:"type:text;not null" json:"textBody"`
+	UpdatedBy string    `gorm:"size:100" json:"updatedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Email delivery states
+const (
+	EmailQueued = "queued"
+	EmailSent   = "sent"
+	EmailFailed = "failed" // Still retried until the queue gives up
+)
+
+// EmailDelivery is one email to one recipient, rendered when it was queued
+type EmailDelivery struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Type       string     `gorm:"size:100;not null;index" json:"type"`
+	Recipient  string     `gorm:"size:255;not null;index" json:"recipient"`
+	Subject    string     `gorm:"size:255;not null" json:"subject"`
+	HTMLBody   string     `gorm:"type:text" json:"-"`
+	TextBody   string     `gorm:"type:text" json:"textBody"`
+	EntityType string     `gorm:"size:50;index:idx_email_delivery_entity" json:"entityType"` // What it is about, e.g. "testimony"
+	EntityID   uint       `gorm:"index:idx_email_delivery_entity" json:"entityId"`
+	Status     string     `gorm:"size:20;not null;index" json:"status"`
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	LastError  string     `gorm:"type:text" json:"lastError"`
+	SentAt     *time.Time `json:"sentAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,58 @@
+// internal/models/event_registration.go
+package models
+
+import "time"
+
+// Kinds of custom registration field
+const (
+	FieldText     = "text"
+	FieldTextarea = "textarea"
+	FieldNumber   = "number"
+	FieldSelect   = "select"
+	FieldCheckbox = "checkbox"
+)
+
+// RegistrationField is an extra question on an event's registration form, such as
+// dietary needs for a dinner or T-shirt size for a camp
+type RegistrationField struct {
+	ID           uint     `gorm:"primaryKey" json:"id"`
+	EventID      uint     `gorm:"not null;uniqueIndex:idx_registration_field_key" json:"eventId"`
+	Key          string   `gorm:"size:100;not null;uniqueIndex:idx_registration_field_key" json:"key"` // Answers are stored under this
+	Label        string   `gorm:"size:255;not null" json:"label"`
+	Type         string   `gorm:"size:20;not null" json:"type"`
+	Options      []string `gorm:"serializer:json;type:text" json:"options,omitempty"` // Choices for a select field
+	Required     bool     `gorm:"not null;default:false" json:"required"`
+	DisplayOrder int      `gorm:"not null;default:0" json:"displayOrder"`
+}
+
+// Registration statuses
+const (
+	RegistrationConfirmed  = "confirmed"
+	RegistrationWaitlisted = "waitlisted"
+	RegistrationCancelled  = "cancelled"
+)
+
+// EventRegistration is one person's booking for a special event, for themselves and
+// any guests. Waitlisted registrations are confirmed in the order they were made as
+// places free up.
+type EventRegistration struct {
+	ID               uint              `gorm:"primaryKey" json:"id"`
+	EventID          uint              `gorm:"not null;index" json:"eventId"`
+	FirstName        string            `gorm:"size:100;not null" json:"firstName"`
+	LastName         string            `gorm:"size:100;not null" json:"lastName"`
+	Email            string            `gorm:"size:255;not null;index" json:"email"` // Lower case; may be empty for a walk-in
+	Phone            string            `gorm:"size:50" json:"phone"`
+	Places           int               `gorm:"not null;default:1" json:"places"` // The registrant and their guests
+	Answers          map[string]string `gorm:"serializer:json;type:text" json:"answers"`
+	Status           string            `gorm:"size:20;not null;index" json:"status"`
+	ConfirmationCode string            `gorm:"size:20;uniqueIndex;not null" json:"confirmationCode"`
+	CancelTokenHash  string            `gorm:"size:64;uniqueIndex;not null" json:"-"` // The token itself is only sent to the registrant
+	PromotedAt       *time.Time        `json:"promotedAt"`                            // Moved off the waitlist
+	CancelledAt      *time.Time        `json:"cancelledAt"`
+	CancelledBy      string            `gorm:"size:100" json:"cancelledBy,omitempty"` // Admin email, or empty when the registrant cancelled
+	WalkIn           bool              `gorm:"not null;default:false" json:"walkIn"`  // Registered at the door
+	CheckedInAt      *time.Time        `gorm:"index" json:"checkedInAt"`
+	CheckedInBy      string            `gorm:"size:255" json:"checkedInBy,omitempty"` // Admin email or device
+	CreatedAt        time.Time         `json:"createdAt"`
+	UpdatedAt        time.Time         `json:"updatedAt"`
+}
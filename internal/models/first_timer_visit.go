@@ -0,0 +1,20 @@
+// internal/models/first_timer_visit.go
+package models
+
+import (
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+)
+
+// FirstTimerVisit records a first-timer returning for a later service
+type FirstTimerVisit struct {
+	ID                  uint              `gorm:"primaryKey" json:"id"`
+	FirstTimerID        uint              `gorm:"not null;uniqueIndex:idx_first_timer_visit_date" json:"firstTimerId"`
+	VisitDate           localtime.Date    `gorm:"not null;uniqueIndex:idx_first_timer_visit_date" json:"visitDate"`
+	ServiceTypeID       *uint             `gorm:"index" json:"serviceTypeId,omitempty"`
+	ServiceOccurrenceID *uint             `gorm:"index" json:"serviceOccurrenceId,omitempty"`
+	ServiceOccurrence   ServiceOccurrence `gorm:"foreignKey:ServiceOccurrenceID;constraint:OnDelete:SET NULL" json:"-"`
+	RecordedBy          string            `gorm:"size:100" json:"recordedBy"` // Admin email or "device:<name>"
+	CreatedAt           time.Time         `json:"createdAt"`
+}
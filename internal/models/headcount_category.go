@@ -0,0 +1,46 @@
+// internal/models/headcount_category.go
+package models
+
+import "time"
+
+// Keys of the built-in categories that mirror the original Attendance columns
+const (
+	HeadcountAdults      = "adults"
+	HeadcountChildren    = "children"
+	HeadcountMembers     = "members"
+	HeadcountVisitors    = "visitors"
+	HeadcountFirstTimers = "first_timers"
+)
+
+// HeadcountCategory is something ushers count at a service, e.g. adults, women or online viewers.
+// Categories in the same group are alternative breakdowns of the same people.
+type HeadcountCategory struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	Key                 string    `gorm:"size:50;unique;not null" json:"key"` // Stable identifier, cannot be changed
+	Name                string    `gorm:"size:100;not null" json:"name"`
+	GroupName           string    `gorm:"size:50;not null" json:"group"`
+	CountsTowardTotal   bool      `gorm:"not null;default:false" json:"countsTowardTotal"`
+	ReconcilesWithTotal bool      `gorm:"not null;default:false" json:"reconcilesWithTotal"` // The group must add up to the total when recorded
+	DisplayOrder        int       `gorm:"not null;default:0" json:"displayOrder"`
+	Active              bool      `gorm:"not null;default:true" json:"active"`
+	BuiltIn             bool      `gorm:"not null;default:false" json:"builtIn"`
+	CreatedAt           time.Time `json:"createdAt"`
+	UpdatedAt           time.Time `json:"updatedAt"`
+}
+
+// AttendanceCount is the headcount of one category at one service
+type AttendanceCount struct {
+	ID           uint `gorm:"primaryKey" json:"-"`
+	AttendanceID uint `gorm:"not null;uniqueIndex:idx_attendance_count_category" json:"-"`
+	CategoryID   uint `gorm:"not null;uniqueIndex:idx_attendance_count_category;index" json:"categoryId"`
+	Count        int  `gorm:"not null" json:"count"`
+}
+
+// DefaultHeadcountCategories are created on first start and carry the original columns
+var DefaultHeadcountCategories = []HeadcountCategory{
+	{Key: HeadcountAdults, Name: "Adults", GroupName: "age", CountsTowardTotal: true, DisplayOrder: 1, Active: true, BuiltIn: true},
+	{Key: HeadcountChildren, Name: "Children", GroupName: "age", CountsTowardTotal: true, DisplayOrder: 2, Active: true, BuiltIn: true},
+	{Key: HeadcountMembers, Name: "Members", GroupName: "membership", ReconcilesWithTotal: true, DisplayOrder: 3, Active: true, BuiltIn: true},
+	{Key: HeadcountVisitors, Name: "Visitors", GroupName: "membership", ReconcilesWithTotal: true, DisplayOrder: 4, Active: true, BuiltIn: true},
+	{Key: HeadcountFirstTimers, Name: "First-Timers", GroupName: "membership", ReconcilesWithTotal: true, DisplayOrder: 5, Active: true, BuiltIn: true},
+}
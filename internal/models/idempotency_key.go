@@ -0,0 +1,16 @@
+// internal/models/idempotency_key.go
+package models
+
+import "time"
+
+// IdempotencyKey stores the response to a public POST sent with an Idempotency-Key
+// header, so a retried submission returns the first response instead of saving twice
+type IdempotencyKey struct {
+	ID           uint      `gorm:"primaryKey"`
+	Scope        string    `gorm:"size:512;not null;uniqueIndex:idx_idempotency_key"` // Method, path and who sent it
+	Key          string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_key"`
+	RequestHash  string    `gorm:"size:64;not null"`
+	StatusCode   int       `gorm:"not null;default:0"` // Zero while the first request is still running
+	ResponseBody string    `gorm:"type:text"`
+	CreatedAt    time.Time `gorm:"index"`
+}
@@ -0,0 +1,22 @@
+// internal/models/import_batch.go
+package models
+
+import "time"
+
+const (
+	ImportKindAttendance  = "attendance"
+	ImportKindFirstTimers = "first_timers"
+)
+
+// ImportBatch groups the rows created by one CSV import so the import can be rolled back
+type ImportBatch struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Kind         string     `gorm:"size:50;not null;index" json:"kind"`
+	Filename     string     `gorm:"size:255" json:"filename"`
+	RowCount     int        `json:"rowCount"`
+	SkippedRows  int        `json:"skippedRows"`
+	CreatedBy    string     `gorm:"size:100" json:"createdBy"`
+	RolledBackAt *time.Time `json:"rolledBackAt,omitempty"`
+	RolledBackBy string     `gorm:"size:100" json:"rolledBackBy,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,22 @@
+// internal/models/offering.go
+package models
+
+import "time"
+
+// Kinds of offering counted after a service
+var OfferingKinds = []string{"offering", "tithe", "thanksgiving", "seed", "first_fruit", "building_fund", "other"}
+
+// Offering is one counted collection at a service. Amounts are in minor units (kobo for NGN).
+type Offering struct {
+	ID                  uint              `gorm:"primaryKey" json:"id"`
+	ServiceOccurrenceID uint              `gorm:"not null;index" json:"serviceOccurrenceId"`
+	ServiceOccurrence   ServiceOccurrence `gorm:"foreignKey:ServiceOccurrenceID;constraint:OnDelete:RESTRICT" json:"-"`
+	Kind                string            `gorm:"size:50;not null" json:"kind"`
+	AmountMinor         int64             `gorm:"not null" json:"amountMinor"`
+	Currency            string            `gorm:"size:3;not null;default:'NGN'" json:"currency"`
+	CountedBy           string            `gorm:"size:100" json:"countedBy"`
+	RecordedBy          string            `gorm:"size:100" json:"recordedBy"`
+	Notes               string            `gorm:"type:text" json:"notes"`
+	CreatedAt           time.Time         `json:"createdAt"`
+	UpdatedAt           time.Time         `json:"updatedAt"`
+}
@@ -0,0 +1,34 @@
+// internal/models/program_exception.go
+package models
+
+import (
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+)
+
+// ProgramException changes one occurrence of a regular program without touching the
+// rest of the series: it can cancel it, move it, hold it elsewhere or add a note.
+// Reason is shown publicly alongside the occurrence.
+type ProgramException struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	ProgramID uint           `gorm:"not null;uniqueIndex:idx_program_exception_date" json:"programId"`
+	Date      localtime.Date `gorm:"not null;uniqueIndex:idx_program_exception_date" json:"date"` // The occurrence's original date
+
+	Cancelled    bool            `gorm:"not null;default:false" json:"cancelled"`
+	NewDate      *localtime.Date `json:"newDate,omitempty"` // Moved to another day
+	NewStartTime string          `gorm:"size:5" json:"newStartTime,omitempty"`
+	NewEndTime   string          `gorm:"size:5" json:"newEndTime,omitempty"`
+	Location     string          `gorm:"size:255" json:"location,omitempty"` // Replaces the program's location
+
+	Reason    string    `gorm:"size:500" json:"reason"`
+	Note      string    `gorm:"type:text" json:"note"`
+	CreatedBy string    `gorm:"size:100" json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Rescheduled reports whether the occurrence moves to another day or time
+func (e ProgramException) Rescheduled() bool {
+	return e.NewDate != nil || e.NewStartTime != ""
+}
@@ -0,0 +1,90 @@
+// internal/models/publishing.go
+package models
+
+import (
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+
+	"gorm.io/gorm"
+)
+
+// Publishing states, worked out from the current time
+const (
+	PublishDraft     = "draft"
+	PublishScheduled = "scheduled"
+	PublishLive      = "published"
+	PublishExpired   = "expired"
+)
+
+// Publishing is shared by content shown on the public site, which can be published
+// by hand or at set times. Published is flipped by the publishing job when PublishAt
+// or UnpublishAt passes, but public queries check the times too (see Visible), so
+// nothing waits on the job running on time.
+type Publishing struct {
+	Published   bool       `gorm:"default:false" json:"published"`
+	PublishAt   *time.Time `gorm:"index" json:"publishAt"`   // Goes live at this time; cleared once published
+	UnpublishAt *time.Time `gorm:"index" json:"unpublishAt"` // Taken down at this time
+
+	PublishState string `gorm:"-" json:"publishState"` // Worked out when loaded
+}
+
+// Visible narrows a query to rows the public may see at now
+func Visible(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(published = ? OR publish_at <= ?) AND (unpublish_at IS NULL OR unpublish_at > ?)", true, now, now)
+	}
+}
+
+// InPublishState narrows a query to rows in one of the publishing states at now
+func InPublishState(state string, now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		switch state {
+		case PublishLive:
+			return db.Scopes(Visible(now))
+		case PublishExpired:
+			return db.Where("unpublish_at <= ?", now)
+		case PublishScheduled:
+			return db.Where("published = ? AND publish_at > ? AND (unpublish_at IS NULL OR unpublish_at > ?)", false, now, now)
+		default:
+			return db.Where("published = ? AND publish_at IS NULL AND (unpublish_at IS NULL OR unpublish_at > ?)", false, now)
+		}
+	}
+}
+
+// VisibleAt reports whether the public may see the content at now
+func (p Publishing) VisibleAt(now time.Time) bool {
+	if p.UnpublishAt != nil && !now.Before(*p.UnpublishAt) {
+		return false
+	}
+	return p.Published || (p.PublishAt != nil && !now.Before(*p.PublishAt))
+}
+
+// StateAt reports the publishing state at now
+func (p Publishing) StateAt(now time.Time) string {
+	switch {
+	case p.VisibleAt(now):
+		return PublishLive
+	case p.UnpublishAt != nil && !now.Before(*p.UnpublishAt):
+		return PublishExpired
+	case p.PublishAt != nil:
+		return PublishScheduled
+	default:
+		return PublishDraft
+	}
+}
+
+// SetPublished publishes or unpublishes by hand, which replaces any pending
+// PublishAt. Publishing also drops an UnpublishAt that has already passed.
+func (p *Publishing) SetPublished(published bool, now time.Time) {
+	p.Published, p.PublishAt = published, nil
+	if published && p.UnpublishAt != nil && !now.Before(*p.UnpublishAt) {
+		p.UnpublishAt = nil
+	}
+}
+
+func (p *Publishing) setPublishState(now time.Time) {
+	p.PublishAt = localtime.In(p.PublishAt)
+	p.UnpublishAt = localtime.In(p.UnpublishAt)
+	p.PublishState = p.StateAt(now)
+}
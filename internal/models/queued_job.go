@@ -0,0 +1,34 @@
+// internal/models/queued_job.go
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Queued job states
+const (
+	QueuePending   = "pending" // Waiting for RunAt, including retries
+	QueueRunning   = "running"
+	QueueSucceeded = "succeeded"
+	QueueDead      = "dead" // Failed on every attempt; retried only by an admin
+)
+
+// QueuedJob is one piece of work on the background queue, e.g. an email to send.
+// It is added in the same transaction as the change that needs it, so it is never
+// lost or sent for a change that rolled back.
+type QueuedJob struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Type        string          `gorm:"size:100;not null;index" json:"type"`
+	Payload     json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Status      string          `gorm:"size:20;not null;index:idx_queued_jobs_due,priority:1" json:"status"`
+	RunAt       time.Time       `gorm:"not null;index:idx_queued_jobs_due,priority:2" json:"runAt"` // Not before this time
+	Attempts    int             `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int             `gorm:"not null" json:"maxAttempts"`
+	LastError   string          `gorm:"type:text" json:"lastError"`
+	LockedAt    *time.Time      `json:"lockedAt"`                 // When a worker claimed it
+	LockedBy    string          `gorm:"size:255" json:"lockedBy"` // Instance hostname
+	FinishedAt  *time.Time      `json:"finishedAt"`               // Succeeded or died
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
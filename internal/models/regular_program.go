@@ -1,17 +1,36 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+)
 
 type RegularProgram struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Title       string    `gorm:"size:255;not null" json:"title"`
-	Description string    `gorm:"type:text" json:"description"`
-	Day         string    `gorm:"size:50;not null" json:"day"`
-	Frequency   string    `gorm:"size:100;not null" json:"frequency"`
-	Time        string    `gorm:"size:50" json:"time"`
-	Location    string    `gorm:"size:255" json:"location"`
-	Type        string    `gorm:"size:100;not null" json:"type"`
-	Active      bool      `gorm:"default:true" json:"active"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Title       string `gorm:"size:255;not null" json:"title"`
+	Description string `gorm:"type:text" json:"description"`
+	Day         string `gorm:"size:50;not null" json:"day"`
+	Frequency   string `gorm:"size:100;not null" json:"frequency"`
+	Time        string `gorm:"size:50" json:"time"`
+	Location    string `gorm:"size:255" json:"location"`
+	Type        string `gorm:"size:100;not null" json:"type"`
+	Active      bool   `gorm:"default:true" json:"active"`
+
+	// Structured schedule. Day, Frequency and Time above are the display text.
+	RRule     string          `gorm:"size:255" json:"rrule"`                                   // e.g. FREQ=MONTHLY;BYDAY=1FR
+	StartTime string          `gorm:"size:5" json:"startTime"`                                 // HH:MM local time
+	EndTime   string          `gorm:"size:5" json:"endTime"`                                   // HH:MM, may be earlier than StartTime for overnight programs
+	Timezone  string          `gorm:"size:64;not null;default:'Africa/Lagos'" json:"timezone"` // IANA name; CHURCH_TIMEZONE when not set
+	StartsOn  *localtime.Date `json:"startsOn"`                                                // First possible date; anchors INTERVAL
+	EndsOn    *localtime.Date `json:"endsOn"`                                                  // Last possible date, if the program ends
+
+	// Set when the schedule was migrated from free text and could not be read with confidence
+	RecurrenceNeedsReview bool   `gorm:"not null;default:false" json:"recurrenceNeedsReview"`
+	RecurrenceNote        string `gorm:"type:text" json:"recurrenceNote,omitempty"`
+
+	Exceptions []ProgramException `gorm:"foreignKey:ProgramID;constraint:OnDelete:CASCADE" json:"exceptions,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
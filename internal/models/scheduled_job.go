@@ -0,0 +1,27 @@
+// internal/models/scheduled_job.go
+package models
+
+import "time"
+
+// Outcomes of a scheduled job run
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// ScheduledJob records when a background job last ran and when it is next due. It is
+// shared by every server instance, so a job runs once however many are up.
+type ScheduledJob struct {
+	Name           string     `gorm:"primaryKey;size:100" json:"name"`
+	NextRunAt      time.Time  `gorm:"not null" json:"nextRunAt"`
+	LastStartedAt  *time.Time `json:"lastStartedAt"`
+	LastFinishedAt *time.Time `json:"lastFinishedAt"`
+	LastDurationMs int64      `gorm:"not null;default:0" json:"lastDurationMs"`
+	LastStatus     string     `gorm:"size:20" json:"lastStatus"`
+	LastError      string     `gorm:"type:text" json:"lastError"`
+	LastRunBy      string     `gorm:"size:255" json:"lastRunBy"` // Instance hostname, or the admin who ran it by hand
+	RunCount       int        `gorm:"not null;default:0" json:"runCount"`
+	FailureCount   int        `gorm:"not null;default:0" json:"failureCount"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
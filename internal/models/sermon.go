@@ -1,19 +1,41 @@
 // internal/models/sermon.go
 package models
 
-import "time"
+import (
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+
+	"gorm.io/gorm"
+)
 
 // Sermon represents a church sermon (video message)
 type Sermon struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Title       string    `gorm:"not null" json:"title"`
-	Pastor      string    `gorm:"not null" json:"pastor"`
-	Service     string    `gorm:"not null" json:"service"`
-	Date        time.Time `gorm:"not null" json:"date"`
-	YoutubeID   string    `gorm:"not null;unique" json:"youtubeId"`
-	Duration    string    `json:"duration"`
-	Description string    `gorm:"type:text" json:"description"`
-	Published   bool      `gorm:"default:false" json:"published"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Title       string         `gorm:"not null" json:"title"`
+	Slug        string         `gorm:"size:255;uniqueIndex" json:"slug"`
+	Pastor      string         `gorm:"not null" json:"pastor"`
+	Service     string         `gorm:"not null" json:"service"`
+	Date        localtime.Date `gorm:"not null" json:"date"`
+	YoutubeID   string         `gorm:"not null;unique" json:"youtubeId"`
+	Duration    string         `json:"duration"`
+	Description string         `gorm:"type:text" json:"description"`
+	Publishing
+	// Linked when Service names a known service type
+	ServiceOccurrenceID *uint             `gorm:"index" json:"serviceOccurrenceId"`
+	ServiceOccurrence   ServiceOccurrence `gorm:"foreignKey:ServiceOccurrenceID;constraint:OnDelete:SET NULL" json:"-"`
+	CreatedAt           time.Time         `json:"createdAt"`
+	UpdatedAt           time.Time         `json:"updatedAt"`
+}
+
+// AfterFind fills in the publishing state
+func (s *Sermon) AfterFind(tx *gorm.DB) error {
+	s.setPublishState(time.Now())
+	return nil
+}
+
+// AfterSave keeps the publishing state right in responses to admin edits
+func (s *Sermon) AfterSave(tx *gorm.DB) error {
+	s.setPublishState(time.Now())
+	return nil
 }
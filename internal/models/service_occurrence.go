@@ -0,0 +1,25 @@
+// internal/models/service_occurrence.go
+package models
+
+import (
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+)
+
+// ServiceOccurrence is one service actually held, e.g. the first service on a given Sunday.
+// Attendance, sermons, first-timer visits and offerings of that service link to it.
+type ServiceOccurrence struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Date           localtime.Date `gorm:"not null;uniqueIndex:idx_service_occurrence" json:"date"`
+	ServiceTypeID  uint           `gorm:"not null;uniqueIndex:idx_service_occurrence" json:"serviceTypeId"`
+	ServiceType    string         `gorm:"size:100;not null" json:"serviceType"` // Name of the service type, kept in sync on rename
+	ServiceTypeRef ServiceType    `gorm:"foreignKey:ServiceTypeID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	StartTime      string         `gorm:"size:5" json:"startTime"`  // HH:MM
+	EndTime        string         `gorm:"size:5" json:"endTime"`    // HH:MM
+	Minister       string         `gorm:"size:100" json:"minister"` // Officiating minister
+	Theme          string         `gorm:"size:255" json:"theme"`
+	Notes          string         `gorm:"type:text" json:"notes"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+}
@@ -4,8 +4,10 @@ package models
 import "time"
 
 type ServiceType struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"unique;not null" json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Name         string    `gorm:"unique;not null" json:"name"`
+	DisplayOrder int       `gorm:"not null;default:0" json:"displayOrder"`
+	Active       bool      `gorm:"not null;default:true" json:"active"` // Archived types stay on old records but cannot be picked for new ones
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
 }
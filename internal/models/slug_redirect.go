@@ -0,0 +1,18 @@
+// internal/models/slug_redirect.go
+package models
+
+import "time"
+
+const (
+	SlugEntitySermon       = "sermon"
+	SlugEntitySpecialEvent = "special_event"
+)
+
+// SlugRedirect keeps old public URLs working after a title or date edit
+type SlugRedirect struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Entity    string    `gorm:"size:50;not null;uniqueIndex:idx_slug_redirect_entity_slug" json:"entity"`
+	OldSlug   string    `gorm:"size:255;not null;uniqueIndex:idx_slug_redirect_entity_slug" json:"oldSlug"`
+	TargetID  uint      `gorm:"not null;index" json:"targetId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
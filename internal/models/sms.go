@@ -0,0 +1,42 @@
+// internal/models/sms.go
+package models
+
+import "time"
+
+// SMS states
+const (
+	SMSQueued    = "queued"
+	SMSSent      = "sent"      // Accepted by the provider
+	SMSDelivered = "delivered" // Reported delivered to the phone
+	SMSFailed    = "failed"    // Retried until the queue gives up, or reported undelivered
+	SMSOptedOut  = "opted_out" // Not sent because the number replied STOP
+)
+
+// SMSMessage is one text message to one phone number, with what it cost
+type SMSMessage struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Type        string     `gorm:"size:100;not null;index" json:"type"`
+	Recipient   string     `gorm:"size:20;not null;index" json:"recipient"` // E.164, e.g. +2348012345678
+	Body        string     `gorm:"type:text;not null" json:"body"`
+	EntityType  string     `gorm:"size:50;index:idx_sms_message_entity" json:"entityType"` // What it is about, e.g. "first_timer"
+	EntityID    uint       `gorm:"index:idx_sms_message_entity" json:"entityId"`
+	Status      string     `gorm:"size:20;not null;index" json:"status"`
+	Provider    string     `gorm:"size:20" json:"provider"`
+	ProviderID  string     `gorm:"size:100;index" json:"providerId"` // The provider's message id, matched against delivery reports
+	Segments    int        `gorm:"not null;default:0" json:"segments"`
+	CostMicros  int64      `gorm:"not null;default:0" json:"costMicros"` // In millionths of Currency, as gateways price below a kobo or cent
+	Currency    string     `gorm:"size:3;not null;default:'NGN'" json:"currency"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	LastError   string     `gorm:"type:text" json:"lastError"`
+	SentAt      *time.Time `json:"sentAt"`
+	DeliveredAt *time.Time `json:"deliveredAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// SMSOptOut is a phone number that asked not to be sent text messages
+type SMSOptOut struct {
+	Phone     string    `gorm:"primaryKey;size:20" json:"phone"` // E.164
+	Source    string    `gorm:"size:100" json:"source"`          // "reply", or the admin who added it
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -1,18 +1,162 @@
 // internal/models/special_event.go
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+
+	"gorm.io/gorm"
+)
+
+// Special event statuses, worked out from the current time
+const (
+	EventUpcoming = "upcoming"
+	EventOngoing  = "ongoing"
+	EventPast     = "past"
+)
 
 type SpecialEvent struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Title       string    `gorm:"size:255;not null" json:"title"`
-	Type        string    `gorm:"size:100;not null" json:"type"`
-	Description string    `gorm:"type:text" json:"description"`
-	Date        time.Time `gorm:"not null" json:"date"`
-	StartTime   string    `gorm:"size:20" json:"startTime"`
-	EndTime     string    `gorm:"size:20" json:"endTime"`
-	Location    string    `gorm:"size:255" json:"location"`
-	Published   bool      `gorm:"default:false" json:"published"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Title       string         `gorm:"size:255;not null" json:"title"`
+	Slug        string         `gorm:"size:255;uniqueIndex" json:"slug"`
+	Type        string         `gorm:"size:100;not null" json:"type"`
+	Description string         `gorm:"type:text" json:"description"`
+	Date        localtime.Date `gorm:"not null" json:"date"` // Local day the event starts
+	EndDate     localtime.Date `gorm:"index" json:"endDate"` // Last day of the event; the same as Date for a one-day event
+	StartsAt    *time.Time     `json:"startsAt"`             // Nil for an all-day event
+	EndsAt      *time.Time     `json:"endsAt"`
+	StartTime   string         `gorm:"size:20" json:"startTime"` // Local "HH:MM" of StartsAt, kept for older clients
+	EndTime     string         `gorm:"size:20" json:"endTime"`   // Local "HH:MM" of EndsAt
+	Location    string         `gorm:"size:255" json:"location"`
+	Publishing
+
+	// Registration is taken between these times; nil RegistrationOpensAt means none is needed
+	RegistrationOpensAt  *time.Time          `json:"registrationOpensAt"`
+	RegistrationClosesAt *time.Time          `json:"registrationClosesAt"`                   // Defaults to when the event starts
+	Capacity             int                 `gorm:"not null;default:0" json:"capacity"`     // Places available; 0 for no limit
+	Waitlist             bool                `gorm:"not null;default:false" json:"waitlist"` // Take registrations beyond Capacity onto a waitlist
+	MaxPlaces            int                 `gorm:"not null;default:1" json:"maxPlaces"`    // Most places one registration may take, guests included
+	RegistrationFields   []RegistrationField `gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE" json:"registrationFields,omitempty"`
+	Registrations        []EventRegistration `gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE" json:"-"`
+
+	Sessions []SpecialEventSession `gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE" json:"sessions,omitempty"`
+
+	// Worked out when loaded
+	Status           string `gorm:"-" json:"status"`
+	RegistrationOpen bool   `gorm:"-" json:"registrationOpen"`
+	PlacesLeft       *int   `gorm:"-" json:"placesLeft,omitempty"` // Set where capacity matters; nil for no limit
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// AfterFind shows event times in the church's timezone whatever the database session
+// uses, and fills in the status fields
+func (e *SpecialEvent) AfterFind(tx *gorm.DB) error {
+	e.StartsAt = localtime.In(e.StartsAt)
+	e.EndsAt = localtime.In(e.EndsAt)
+	e.RegistrationOpensAt = localtime.In(e.RegistrationOpensAt)
+	e.RegistrationClosesAt = localtime.In(e.RegistrationClosesAt)
+	e.setStatus(time.Now())
+	return nil
+}
+
+// AfterSave keeps the status fields right in responses to admin edits
+func (e *SpecialEvent) AfterSave(tx *gorm.DB) error {
+	e.setStatus(time.Now())
+	return nil
+}
+
+func (e *SpecialEvent) setStatus(now time.Time) {
+	e.setPublishState(now)
+	e.Status = e.StatusAt(now)
+	e.RegistrationOpen = e.RegistrationOpenAt(now)
+}
+
+// LastDay is EndDate, or Date for events saved before EndDate existed
+func (e SpecialEvent) LastDay() localtime.Date {
+	if e.EndDate.Before(e.Date) {
+		return e.Date
+	}
+	return e.EndDate
+}
+
+// Span is when the event begins and finishes. All-day events, and timed events with
+// no end, run to the end of their last day.
+func (e SpecialEvent) Span() (start, end time.Time) {
+	start = e.Date.Start()
+	if e.StartsAt != nil {
+		start = *e.StartsAt
+	}
+	end = e.LastDay().AddDays(1).Start()
+	if e.EndsAt != nil {
+		end = *e.EndsAt
+	}
+	return start, end
+}
+
+// StatusAt reports whether the event is upcoming, ongoing or past at now
+func (e SpecialEvent) StatusAt(now time.Time) string {
+	start, end := e.Span()
+	switch {
+	case now.Before(start):
+		return EventUpcoming
+	case now.Before(end):
+		return EventOngoing
+	default:
+		return EventPast
+	}
+}
+
+// RegistrationCloses is when registration ends: RegistrationClosesAt, or the start of the event
+func (e SpecialEvent) RegistrationCloses() time.Time {
+	if e.RegistrationClosesAt != nil {
+		return *e.RegistrationClosesAt
+	}
+	start, _ := e.Span()
+	return start
+}
+
+// RegistrationOpenAt reports whether registration is being taken at now
+func (e SpecialEvent) RegistrationOpenAt(now time.Time) bool {
+	if e.RegistrationOpensAt == nil || now.Before(*e.RegistrationOpensAt) {
+		return false
+	}
+	return now.Before(e.RegistrationCloses())
+}
+
+// SetTimes sets StartsAt (on Date) and EndsAt (on the last day) from local "HH:MM"
+// times. No start time makes the event all-day. An end at or before the start runs
+// past midnight.
+func (e *SpecialEvent) SetTimes(start, end string) error {
+	e.StartTime, e.EndTime = start, end
+	e.StartsAt, e.EndsAt = nil, nil
+	if e.EndDate.Before(e.Date) {
+		e.EndDate = e.Date
+	}
+	if start == "" {
+		if end != "" {
+			return errors.New("endTime needs a startTime")
+		}
+		return nil
+	}
+
+	startsAt, err := e.Date.At(start)
+	if err != nil {
+		return err
+	}
+	e.StartsAt = &startsAt
+	if end != "" {
+		endsAt, err := e.EndDate.At(end)
+		if err != nil {
+			return err
+		}
+		if !endsAt.After(startsAt) {
+			endsAt = endsAt.AddDate(0, 0, 1)
+		}
+		e.EndsAt = &endsAt
+	}
+	return nil
 }
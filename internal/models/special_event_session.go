@@ -0,0 +1,32 @@
+// internal/models/special_event_session.go
+package models
+
+import (
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+
+	"gorm.io/gorm"
+)
+
+// SpecialEventSession is one part of a special event's programme, such as the
+// Friday evening service of a three-day convention
+type SpecialEventSession struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	EventID     uint       `gorm:"not null;index" json:"eventId"`
+	Title       string     `gorm:"size:255;not null" json:"title"`
+	Description string     `gorm:"type:text" json:"description"`
+	Speaker     string     `gorm:"size:255" json:"speaker"`
+	Venue       string     `gorm:"size:255" json:"venue"` // Empty when held at the event's location
+	StartsAt    time.Time  `gorm:"not null" json:"startsAt"`
+	EndsAt      *time.Time `json:"endsAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// AfterFind shows session times in the church's timezone
+func (s *SpecialEventSession) AfterFind(tx *gorm.DB) error {
+	s.StartsAt = s.StartsAt.In(localtime.Location())
+	s.EndsAt = localtime.In(s.EndsAt)
+	return nil
+}
@@ -0,0 +1,41 @@
+// internal/models/welcome.go
+package models
+
+import "time"
+
+// WelcomeStep holds an admin's settings for one step of the welcome sequence. Steps
+// without a row use their built-in settings.
+type WelcomeStep struct {
+	Key       string    `gorm:"primaryKey;size:50" json:"key"` // e.g. "follow_up"
+	Enabled   bool      `gorm:"not null" json:"enabled"`
+	Days      int       `gorm:"not null" json:"days"`
+	SendTime  string    `gorm:"size:5;not null" json:"sendTime"` // HH:MM church time
+	SMSText   string    `gorm:"type:text" json:"smsText"`        // Sent instead of the email to first-timers with only a phone number
+	UpdatedBy string    `gorm:"size:100" json:"updatedBy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Welcome message states
+const (
+	WelcomeScheduled = "scheduled"
+	WelcomeSent      = "sent"      // Handed to the email or SMS queue; see the delivery for the outcome
+	WelcomeCancelled = "cancelled" // Not sent, e.g. because the first-timer joined
+	WelcomeSkipped   = "skipped"   // Its time had passed when the first-timer was recorded
+)
+
+// WelcomeMessage is one message of the welcome sequence to a first-timer
+type WelcomeMessage struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	FirstTimerID    uint       `gorm:"not null;index" json:"firstTimerId"`
+	Step            string     `gorm:"size:50;not null" json:"step"`
+	Channel         string     `gorm:"size:20;not null" json:"channel"` // email, or sms when they left no email address
+	Recipient       string     `gorm:"size:255" json:"recipient"`
+	Status          string     `gorm:"size:20;not null;index" json:"status"`
+	Note            string     `gorm:"size:255" json:"note,omitempty"` // Why it was cancelled or skipped
+	ScheduledFor    time.Time  `gorm:"not null" json:"scheduledFor"`
+	SentAt          *time.Time `json:"sentAt"`
+	EmailDeliveryID *uint      `gorm:"index" json:"emailDeliveryId,omitempty"`
+	SMSMessageID    *uint      `gorm:"index" json:"smsMessageId,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
@@ -0,0 +1,61 @@
+// internal/occurrence/occurrence.go
+package occurrence
+
+import (
+	"errors"
+	"strings"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ensure returns the occurrence of a service type on date, creating it if needed
+func Ensure(tx *gorm.DB, date localtime.Date, serviceTypeID uint, serviceTypeName string) (models.ServiceOccurrence, error) {
+	occ := models.ServiceOccurrence{Date: date, ServiceTypeID: serviceTypeID, ServiceType: serviceTypeName}
+	// DO NOTHING keeps concurrent saves of the same service from failing on the unique index
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("ServiceTypeRef").Create(&occ).Error; err != nil {
+		return occ, err
+	}
+	err := tx.Where("date = ? AND service_type_id = ?", date, serviceTypeID).First(&occ).Error
+	return occ, err
+}
+
+// ForServiceName links a record that names its service in free text (as sermons do).
+// Returns nil when the name is not a known service type.
+func ForServiceName(tx *gorm.DB, date localtime.Date, name string) (*uint, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+
+	var st models.ServiceType
+	err := tx.Where("LOWER(name) = ?", strings.ToLower(name)).First(&st).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	occ, err := Ensure(tx, date, st.ID, st.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &occ.ID, nil
+}
+
+// OnlyOnDate returns the id of the single service held on date, or nil when there
+// were none or several and the service cannot be told from the date alone
+func OnlyOnDate(tx *gorm.DB, date localtime.Date) (*uint, error) {
+	var ids []uint
+	if err := tx.Model(&models.ServiceOccurrence{}).Where("date = ?", date).Limit(2).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) != 1 {
+		return nil, nil
+	}
+	return &ids[0], nil
+}
@@ -0,0 +1,91 @@
+// internal/publishing/publishing.go
+package publishing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/scheduler"
+
+	"gorm.io/gorm"
+)
+
+// Actor is the name scheduled changes are logged under
+const Actor = "scheduler"
+
+// content is a table whose rows are published on a schedule
+type content struct {
+	model interface{}
+	noun  string // Used in the activity log, e.g. "sermon"
+}
+
+var schedulable = []content{
+	{&models.Sermon{}, "sermon"},
+	{&models.SpecialEvent{}, "special event"},
+}
+
+type scheduledRow struct {
+	ID    uint
+	Title string
+}
+
+// Apply publishes content whose PublishAt has passed and unpublishes content whose
+// UnpublishAt has, logging each change. Public queries already hide or show rows by
+// these times, so this only brings Published into line.
+func Apply(db *gorm.DB, now time.Time) error {
+	for _, t := range schedulable {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var due []scheduledRow
+			if err := tx.Model(t.model).
+				Where("published = ? AND publish_at <= ? AND (unpublish_at IS NULL OR unpublish_at > ?)", false, now, now).
+				Select("id, title").Scan(&due).Error; err != nil {
+				return err
+			}
+			if err := update(tx, t, due, map[string]interface{}{"published": true, "publish_at": nil}, "Published "+t.noun+" (scheduled)", now); err != nil {
+				return err
+			}
+
+			var expired []scheduledRow
+			if err := tx.Model(t.model).
+				Where("published = ? AND unpublish_at <= ?", true, now).
+				Select("id, title").Scan(&expired).Error; err != nil {
+				return err
+			}
+			return update(tx, t, expired, map[string]interface{}{"published": false}, "Unpublished "+t.noun+" (scheduled)", now)
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.noun, err)
+		}
+	}
+	return nil
+}
+
+func update(tx *gorm.DB, t content, rows []scheduledRow, changes map[string]interface{}, action string, now time.Time) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(rows))
+	logs := make([]models.ActivityLog, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+		logs[i] = models.ActivityLog{AdminEmail: Actor, Action: action, Details: row.Title, CreatedAt: now}
+	}
+	if err := tx.Model(t.model).Where("id IN ?", ids).Updates(changes).Error; err != nil {
+		return err
+	}
+	return tx.Create(&logs).Error
+}
+
+// RegisterJobs adds the publishing job to the scheduler
+func RegisterJobs() {
+	scheduler.Register(scheduler.Job{
+		Name:  "publishing",
+		Every: time.Minute,
+		Run: func(ctx context.Context) error {
+			return Apply(database.DB.WithContext(ctx), time.Now())
+		},
+	})
+}
@@ -0,0 +1,349 @@
+// internal/queue/queue.go
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/scheduler"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Jobs run at once on one instance, across all types
+	defaultWorkers = 4
+	// How often an idle instance looks for work
+	pollInterval = 2 * time.Second
+	// Retry delays double from baseBackoff up to maxBackoff
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+	// Finished jobs are kept this long for admins to look at
+	retention = 14 * 24 * time.Hour
+)
+
+// Defaults for Options left at zero
+const (
+	defaultMaxAttempts = 5
+	defaultConcurrency = 2
+	defaultTimeout     = time.Minute
+)
+
+var (
+	ErrUnknownType = errors.New("unknown job type")
+	ErrNotDead     = errors.New("only dead jobs can be retried")
+)
+
+// Options tune how jobs of one type are run
+type Options struct {
+	MaxAttempts int           // Defaults to 5; then the job is dead
+	Concurrency int           // Most jobs of this type running at once on one instance; defaults to 2
+	Timeout     time.Duration // Defaults to a minute
+}
+
+type handler struct {
+	opts    Options
+	run     func(ctx context.Context, payload json.RawMessage) error
+	running int
+}
+
+var (
+	mu       sync.Mutex
+	handlers = map[string]*handler{}
+	workers  = make(chan struct{}, defaultWorkers)
+	inFlight sync.WaitGroup
+	stopping bool
+)
+
+// Register sets the function that runs jobs of a type. The payload is decoded into
+// T, so the job is typed end to end. Call it at startup; it panics on a type
+// registered twice.
+func Register[T any](jobType string, opts Options, run func(ctx context.Context, payload T) error) {
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := handlers[jobType]; exists {
+		panic(fmt.Sprintf("queue: job type %q registered twice", jobType))
+	}
+	handlers[jobType] = &handler{opts: opts, run: func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+		return run(ctx, payload)
+	}}
+}
+
+// Types lists the registered job types
+func Types() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	types := make([]string, 0, len(handlers))
+	for t := range handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Enqueue adds a job to run as soon as a worker is free. Pass the transaction
+// making the change the job follows from, so both commit or neither does.
+func Enqueue(tx *gorm.DB, jobType string, payload interface{}) error {
+	return EnqueueAt(tx, jobType, payload, time.Now())
+}
+
+// EnqueueAt adds a job that will not run before at
+func EnqueueAt(tx *gorm.DB, jobType string, payload interface{}, at time.Time) error {
+	mu.Lock()
+	h, ok := handlers[jobType]
+	mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.QueuedJob{
+		Type:        jobType,
+		Payload:     raw,
+		Status:      models.QueuePending,
+		RunAt:       at,
+		MaxAttempts: h.opts.MaxAttempts,
+	}).Error
+}
+
+// RegisterJobs adds queue upkeep to the scheduler
+func RegisterJobs() {
+	scheduler.Register(scheduler.Job{Name: "queue-maintenance", Every: time.Minute, Run: maintain})
+}
+
+// Start works through the queue until Shutdown is called. Every instance can
+// run it; a job is claimed with FOR UPDATE SKIP LOCKED so only one instance gets it.
+func Start() {
+	go func() {
+		for {
+			mu.Lock()
+			done := stopping
+			mu.Unlock()
+			if done {
+				return
+			}
+			if !claimAndRun() {
+				time.Sleep(pollInterval)
+			}
+		}
+	}()
+}
+
+// Shutdown stops claiming jobs and waits for running ones to finish, or for ctx
+// to end. Jobs cut off are picked up again once their timeout has passed.
+func Shutdown(ctx context.Context) error {
+	mu.Lock()
+	stopping = true
+	mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// claimAndRun starts the next due job in the background, reporting whether there was one
+func claimAndRun() bool {
+	select {
+	case workers <- struct{}{}:
+	default:
+		time.Sleep(pollInterval / 4) // Every worker is busy
+		return true
+	}
+
+	mu.Lock()
+	var free []string
+	for t, h := range handlers {
+		if h.running < h.opts.Concurrency {
+			free = append(free, t)
+		}
+	}
+	mu.Unlock()
+	if len(free) == 0 {
+		<-workers
+		return false
+	}
+
+	job, ok, err := claim(free)
+	if err != nil || !ok {
+		<-workers
+		if err != nil {
+			log.Printf("[QUEUE] Failed to claim a job: %v", err)
+		}
+		return false
+	}
+
+	mu.Lock()
+	h := handlers[job.Type]
+	h.running++
+	mu.Unlock()
+
+	inFlight.Add(1)
+	go func() {
+		defer func() {
+			mu.Lock()
+			h.running--
+			mu.Unlock()
+			<-workers
+			inFlight.Done()
+		}()
+		run(job, h)
+	}()
+	return true
+}
+
+// claim marks the oldest due job of one of the types as running on this instance
+func claim(types []string) (models.QueuedJob, bool, error) {
+	var job models.QueuedJob
+	result := database.DB.Raw(`
+		UPDATE queued_jobs SET status = ?, attempts = attempts + 1, locked_at = ?, locked_by = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM queued_jobs
+			WHERE status = ? AND run_at <= ? AND type IN ?
+			ORDER BY run_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`,
+		models.QueueRunning, time.Now(), hostname(), time.Now(),
+		models.QueuePending, time.Now(), types,
+	).Scan(&job)
+	if result.Error != nil {
+		return job, false, result.Error
+	}
+	return job, job.ID != 0, nil
+}
+
+// run runs a claimed job and records the outcome
+func run(job models.QueuedJob, h *handler) {
+	// Not tied to Shutdown, so a job in progress is allowed to finish
+	ctx, cancel := context.WithTimeout(context.Background(), h.opts.Timeout)
+	err := safeRun(ctx, h, job.Payload)
+	cancel()
+
+	now := time.Now()
+	changes := map[string]interface{}{"locked_at": nil, "locked_by": ""}
+	switch {
+	case err == nil:
+		changes["status"], changes["finished_at"], changes["last_error"] = models.QueueSucceeded, now, ""
+	case job.Attempts >= job.MaxAttempts:
+		changes["status"], changes["finished_at"], changes["last_error"] = models.QueueDead, now, err.Error()
+		log.Printf("[QUEUE] Job %d (%s) failed for good after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+	default:
+		changes["status"], changes["run_at"], changes["last_error"] = models.QueuePending, now.Add(backoff(job.Attempts)), err.Error()
+		log.Printf("[QUEUE] Job %d (%s) failed, attempt %d of %d: %v", job.ID, job.Type, job.Attempts, job.MaxAttempts, err)
+	}
+	if err := database.DB.Model(&models.QueuedJob{ID: job.ID}).Updates(changes).Error; err != nil {
+		log.Printf("[QUEUE] Failed to record the result of job %d: %v", job.ID, err)
+	}
+}
+
+// safeRun turns a panic in a job into an error
+func safeRun(ctx context.Context, h *handler, payload json.RawMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h.run(ctx, payload)
+}
+
+// backoff is the wait before retrying a job that has failed attempts times, with
+// some jitter so failures do not retry in lockstep
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff << (attempts - 1)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay/10)+1))
+}
+
+// maintain returns jobs left running by an instance that stopped to the queue, and
+// deletes finished jobs past the retention period
+func maintain(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+	now := time.Now()
+
+	mu.Lock()
+	timeouts := map[string]time.Duration{}
+	for t, h := range handlers {
+		timeouts[t] = h.opts.Timeout
+	}
+	mu.Unlock()
+	for t, timeout := range timeouts {
+		// An attempt cut off this way still counts, so a job that keeps crashing its worker dies
+		if err := db.Model(&models.QueuedJob{}).
+			Where("type = ? AND status = ? AND locked_at < ?", t, models.QueueRunning, now.Add(-timeout-time.Minute)).
+			Updates(map[string]interface{}{
+				"status":      gorm.Expr("CASE WHEN attempts >= max_attempts THEN ? ELSE ? END", models.QueueDead, models.QueuePending),
+				"finished_at": gorm.Expr("CASE WHEN attempts >= max_attempts THEN ?::timestamptz END", now),
+				"last_error":  "worker stopped before the job finished",
+				"locked_at":   nil,
+				"locked_by":   "",
+			}).Error; err != nil {
+			return err
+		}
+	}
+
+	return db.Where("status = ? AND finished_at < ?", models.QueueSucceeded, now.Add(-retention)).
+		Delete(&models.QueuedJob{}).Error
+}
+
+// Retry puts a dead job back on the queue with a fresh set of attempts
+func Retry(db *gorm.DB, id uint) (models.QueuedJob, error) {
+	var job models.QueuedJob
+	if err := db.First(&job, id).Error; err != nil {
+		return job, err
+	}
+	if job.Status != models.QueueDead {
+		return job, ErrNotDead
+	}
+	err := db.Model(&job).Updates(map[string]interface{}{
+		"status":      models.QueuePending,
+		"attempts":    0,
+		"run_at":      time.Now(),
+		"finished_at": nil,
+	}).Error
+	return job, err
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
@@ -0,0 +1,329 @@
+// internal/recurrence/rule.go
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the RRULE FREQ part. Only the frequencies church programs use are supported.
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxDays caps how far Between will walk, whatever range it is asked for
+const maxDays = 3 * 366
+
+// WeekdayNum is one BYDAY entry: a weekday, optionally the nth (1..5) or nth-from-last
+// (-1..-5) of the month. N is 0 for every such weekday.
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is the subset of an RFC 5545 RRULE used for regular programs. The series start
+// and end live on the program, so COUNT and UNTIL are not part of the rule.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+}
+
+var dayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var codeOfDay = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Parse reads an RRULE such as "FREQ=MONTHLY;BYDAY=1FR" (an "RRULE:" prefix is allowed)
+func Parse(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "RRULE:"), "rrule:")
+	if s == "" {
+		return Rule{}, errors.New("rrule is empty")
+	}
+
+	var r Rule
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Rule{}, fmt.Errorf("rrule part %q is not KEY=VALUE", part)
+		}
+		value = strings.ToUpper(value)
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = Frequency(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 52 {
+				return Rule{}, errors.New("INTERVAL must be a number from 1 to 52")
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(code)
+				if err != nil {
+					return Rule{}, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return Rule{}, fmt.Errorf("BYMONTHDAY value %q must be 1 to 31 or -1 to -31", v)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "WKST":
+			if value != "MO" {
+				return Rule{}, errors.New("only WKST=MO is supported")
+			}
+		case "UNTIL", "COUNT":
+			return Rule{}, fmt.Errorf("%s is not supported; set the program's end date instead", strings.ToUpper(key))
+		default:
+			return Rule{}, fmt.Errorf("rrule part %s is not supported", strings.ToUpper(key))
+		}
+	}
+
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+	return r, r.Validate()
+}
+
+func parseWeekdayNum(code string) (WeekdayNum, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < 2 {
+		return WeekdayNum{}, fmt.Errorf("BYDAY value %q is not a weekday", code)
+	}
+	day, ok := dayCodes[code[len(code)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("BYDAY value %q is not a weekday", code)
+	}
+	wd := WeekdayNum{Day: day}
+	if prefix := code[:len(code)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("BYDAY value %q has an invalid position", code)
+		}
+		wd.N = n
+	}
+	return wd, nil
+}
+
+// Validate checks the rule is one Between can expand
+func (r Rule) Validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly:
+	case "":
+		return errors.New("FREQ is required")
+	default:
+		return fmt.Errorf("FREQ=%s is not supported; use DAILY, WEEKLY or MONTHLY", r.Freq)
+	}
+	if r.Interval < 1 {
+		return errors.New("INTERVAL must be at least 1")
+	}
+	if r.Freq != Monthly {
+		if len(r.ByMonthDay) > 0 {
+			return errors.New("BYMONTHDAY is only allowed with FREQ=MONTHLY")
+		}
+		for _, wd := range r.ByDay {
+			if wd.N != 0 {
+				return errors.New("numbered BYDAY values such as 1FR need FREQ=MONTHLY")
+			}
+		}
+	}
+	if len(r.ByDay) > 0 && len(r.ByMonthDay) > 0 {
+		return errors.New("use either BYDAY or BYMONTHDAY, not both")
+	}
+	return nil
+}
+
+// String returns the rule in canonical RRULE form, without the "RRULE:" prefix
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			codes[i] = codeOfDay[wd.Day]
+			if wd.N != 0 {
+				codes[i] = strconv.Itoa(wd.N) + codes[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+var ordinalNames = map[int]string{1: "first", 2: "second", 3: "third", 4: "fourth", 5: "fifth", -1: "last", -2: "second to last"}
+
+// Days describes which days the rule falls on, e.g. "Sunday" or "First Friday"
+func (r Rule) Days() string {
+	if r.Freq == Daily && len(r.ByDay) == 0 {
+		return "Daily"
+	}
+	var names []string
+	for _, wd := range r.ByDay {
+		name := wd.Day.String()
+		if wd.N != 0 {
+			ord, ok := ordinalNames[wd.N]
+			if !ok {
+				ord = strconv.Itoa(wd.N)
+			}
+			name = ord + " " + name
+		}
+		names = append(names, name)
+	}
+	for _, d := range r.ByMonthDay {
+		if d < 0 {
+			names = append(names, "day "+strconv.Itoa(-d)+" from the end")
+		} else {
+			names = append(names, "day "+strconv.Itoa(d))
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	s := strings.Join(names, ", ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Describe gives a readable summary such as "Every 2 weeks" or "Monthly"
+func (r Rule) Describe() string {
+	unit := map[Frequency]string{Daily: "day", Weekly: "week", Monthly: "month"}[r.Freq]
+	if r.Interval > 1 {
+		return "Every " + strconv.Itoa(r.Interval) + " " + unit + "s"
+	}
+	return map[Frequency]string{Daily: "Daily", Weekly: "Weekly", Monthly: "Monthly"}[r.Freq]
+}
+
+// Between returns the occurrence start times of the series beginning at start that
+// fall in [from, to). Occurrences keep start's clock time and location; the series
+// is anchored on start for INTERVAL and for the default day when BYDAY is empty.
+func Between(r Rule, start, from, to time.Time, limit int) []time.Time {
+	loc := start.Location()
+	first := civil(start)
+	if f := civil(from.In(loc)); f.After(first) {
+		first = f
+	}
+	last := civil(to.In(loc))
+	if horizon := first.AddDate(0, 0, maxDays); last.After(horizon) {
+		last = horizon
+	}
+
+	var out []time.Time
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		if !r.matches(civil(start), d) {
+			continue
+		}
+		at := time.Date(d.Year(), d.Month(), d.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if at.Before(from) || !at.Before(to) || at.Before(start) {
+			continue
+		}
+		out = append(out, at)
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
+
+// civil strips the clock and location from t, keeping its calendar date
+func civil(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (r Rule) matches(anchor, d time.Time) bool {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch r.Freq {
+	case Daily:
+		days := int(d.Sub(anchor).Hours() / 24)
+		return days%interval == 0 && r.dayListed(d)
+	case Weekly:
+		weeks := int(weekStart(d).Sub(weekStart(anchor)).Hours() / 24 / 7)
+		if weeks%interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return d.Weekday() == anchor.Weekday()
+		}
+		return r.dayListed(d)
+	case Monthly:
+		months := (d.Year()-anchor.Year())*12 + int(d.Month()) - int(anchor.Month())
+		if months%interval != 0 {
+			return false
+		}
+		switch {
+		case len(r.ByDay) > 0:
+			return r.dayListed(d)
+		case len(r.ByMonthDay) > 0:
+			length := daysIn(d)
+			for _, md := range r.ByMonthDay {
+				if md == d.Day() || (md < 0 && length+md+1 == d.Day()) {
+					return true
+				}
+			}
+			return false
+		default:
+			return d.Day() == anchor.Day()
+		}
+	}
+	return false
+}
+
+// dayListed reports whether d matches a BYDAY entry (any day when there are none)
+func (r Rule) dayListed(d time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Day != d.Weekday() {
+			continue
+		}
+		switch {
+		case wd.N == 0:
+			return true
+		case wd.N > 0 && (d.Day()-1)/7+1 == wd.N:
+			return true
+		case wd.N < 0 && (daysIn(d)-d.Day())/7+1 == -wd.N:
+			return true
+		}
+	}
+	return false
+}
+
+func weekStart(d time.Time) time.Time {
+	return d.AddDate(0, 0, -((int(d.Weekday()) + 6) % 7))
+}
+
+func daysIn(d time.Time) int {
+	return time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
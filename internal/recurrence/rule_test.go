@@ -0,0 +1,197 @@
+// internal/recurrence/rule_test.go
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, s string) Rule {
+	t.Helper()
+	r, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return r
+}
+
+// dates formats times as "2006-01-02 15:04" for comparing against a want list
+func dates(times []time.Time) string {
+	out := make([]string, len(times))
+	for i, t := range times {
+		out[i] = t.Format("2006-01-02 15:04")
+	}
+	return strings.Join(out, ", ")
+}
+
+func TestBetween(t *testing.T) {
+	lagos, err := time.LoadLocation("Africa/Lagos")
+	if err != nil {
+		t.Skipf("timezone data is not available: %v", err)
+	}
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, lagos)
+	}
+
+	cases := []struct {
+		name     string
+		rule     string
+		start    time.Time
+		from, to time.Time
+		limit    int
+		want     string
+	}{
+		{
+			"first Friday", "FREQ=MONTHLY;BYDAY=1FR",
+			at(2025, time.January, 1, 18), at(2025, time.January, 1, 0), at(2025, time.May, 1, 0), 0,
+			"2025-01-03 18:00, 2025-02-07 18:00, 2025-03-07 18:00, 2025-04-04 18:00",
+		},
+		{
+			"last Sunday", "FREQ=MONTHLY;BYDAY=-1SU",
+			at(2025, time.January, 1, 9), at(2025, time.January, 1, 0), at(2025, time.May, 1, 0), 0,
+			"2025-01-26 09:00, 2025-02-23 09:00, 2025-03-30 09:00, 2025-04-27 09:00",
+		},
+		{
+			"fifth Sunday skips short months", "FREQ=MONTHLY;BYDAY=5SU",
+			at(2025, time.January, 1, 9), at(2025, time.January, 1, 0), at(2025, time.July, 1, 0), 0,
+			"2025-03-30 09:00, 2025-06-29 09:00",
+		},
+		{
+			"last day of the month", "FREQ=MONTHLY;BYMONTHDAY=-1",
+			at(2024, time.January, 1, 19), at(2024, time.January, 1, 0), at(2024, time.May, 1, 0), 0,
+			"2024-01-31 19:00, 2024-02-29 19:00, 2024-03-31 19:00, 2024-04-30 19:00",
+		},
+		{
+			"last day of February outside a leap year", "FREQ=MONTHLY;BYMONTHDAY=-1",
+			at(2025, time.January, 1, 19), at(2025, time.February, 1, 0), at(2025, time.March, 1, 0), 0,
+			"2025-02-28 19:00",
+		},
+		{
+			"31st skips shorter months", "FREQ=MONTHLY;BYMONTHDAY=31",
+			at(2025, time.January, 1, 19), at(2025, time.January, 1, 0), at(2025, time.June, 1, 0), 0,
+			"2025-01-31 19:00, 2025-03-31 19:00, 2025-05-31 19:00",
+		},
+		{
+			"every other Wednesday", "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE",
+			at(2025, time.January, 8, 18), at(2025, time.January, 1, 0), at(2025, time.March, 1, 0), 0,
+			"2025-01-08 18:00, 2025-01-22 18:00, 2025-02-05 18:00, 2025-02-19 18:00",
+		},
+		{
+			"every other week stays anchored on start", "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE",
+			at(2025, time.January, 8, 18), at(2025, time.January, 13, 0), at(2025, time.February, 10, 0), 0,
+			"2025-01-22 18:00, 2025-02-05 18:00",
+		},
+		{
+			"every other week on two days", "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,FR",
+			at(2025, time.January, 6, 18), at(2025, time.January, 1, 0), at(2025, time.January, 27, 0), 0,
+			"2025-01-08 18:00, 2025-01-10 18:00, 2025-01-22 18:00, 2025-01-24 18:00",
+		},
+		{
+			"weekly on the start's weekday", "FREQ=WEEKLY",
+			at(2025, time.January, 5, 9), at(2025, time.January, 1, 0), at(2025, time.January, 20, 0), 0,
+			"2025-01-05 09:00, 2025-01-12 09:00, 2025-01-19 09:00",
+		},
+		{
+			"every other month on the start's day", "FREQ=MONTHLY;INTERVAL=2",
+			at(2025, time.January, 10, 10), at(2025, time.February, 1, 0), at(2025, time.July, 1, 0), 0,
+			"2025-03-10 10:00, 2025-05-10 10:00",
+		},
+		{
+			"every third day", "FREQ=DAILY;INTERVAL=3",
+			at(2025, time.January, 1, 6), at(2025, time.January, 2, 0), at(2025, time.January, 11, 0), 0,
+			"2025-01-04 06:00, 2025-01-07 06:00, 2025-01-10 06:00",
+		},
+		{
+			"nothing before the start", "FREQ=WEEKLY;BYDAY=SU",
+			at(2025, time.January, 12, 9), at(2025, time.January, 1, 0), at(2025, time.January, 20, 0), 0,
+			"2025-01-12 09:00, 2025-01-19 09:00",
+		},
+		{
+			"from after the day's start time", "FREQ=WEEKLY;BYDAY=SU",
+			at(2025, time.January, 5, 9), at(2025, time.January, 5, 10), at(2025, time.January, 20, 0), 0,
+			"2025-01-12 09:00, 2025-01-19 09:00",
+		},
+		{
+			"limit", "FREQ=DAILY",
+			at(2025, time.January, 1, 6), at(2025, time.January, 1, 0), at(2025, time.February, 1, 0), 2,
+			"2025-01-01 06:00, 2025-01-02 06:00",
+		},
+	}
+	for _, tc := range cases {
+		got := dates(Between(mustParse(t, tc.rule), tc.start, tc.from, tc.to, tc.limit))
+		if got != tc.want {
+			t.Errorf("%s: Between = %s\nwant %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Occurrences keep their local clock time when daylight saving starts or ends
+func TestBetweenKeepsLocalTime(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("timezone data is not available: %v", err)
+	}
+	start := time.Date(2025, time.March, 23, 10, 0, 0, 0, london)
+	got := Between(mustParse(t, "FREQ=WEEKLY;BYDAY=SU"), start, start, start.AddDate(0, 0, 14), 0)
+	if want := "2025-03-23 10:00, 2025-03-30 10:00"; dates(got) != want {
+		t.Fatalf("Between = %s, want %s", dates(got), want)
+	}
+	if diff := got[1].Sub(got[0]); diff != 7*24*time.Hour-time.Hour {
+		t.Errorf("occurrences are %v apart, want an hour short of a week", diff)
+	}
+}
+
+func TestParse(t *testing.T) {
+	valid := []struct{ in, want string }{
+		{"FREQ=WEEKLY;BYDAY=SU", "FREQ=WEEKLY;BYDAY=SU"},
+		{"RRULE:freq=monthly;byday=1fr", "FREQ=MONTHLY;BYDAY=1FR"},
+		{"FREQ=MONTHLY;BYDAY=-1SU", "FREQ=MONTHLY;BYDAY=-1SU"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,FR;WKST=MO", "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,FR"},
+		{"FREQ=DAILY;INTERVAL=1", "FREQ=DAILY"},
+	}
+	for _, tc := range valid {
+		r, err := Parse(tc.in)
+		if err != nil || r.String() != tc.want {
+			t.Errorf("Parse(%q) = %q, %v; want %q", tc.in, r.String(), err, tc.want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"FREQ=YEARLY",
+		"BYDAY=SU",
+		"FREQ=WEEKLY;BYDAY=1SU",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYDAY=6SU",
+		"FREQ=MONTHLY;BYDAY=1FR;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;COUNT=4",
+		"FREQ=WEEKLY;WKST=SU",
+		"FREQ=WEEKLY;BYDAY=XX",
+	}
+	for _, in := range invalid {
+		if r, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %q, want an error", in, r.String())
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	cases := []struct{ rule, days, describe string }{
+		{"FREQ=WEEKLY;BYDAY=SU", "Sunday", "Weekly"},
+		{"FREQ=MONTHLY;BYDAY=1FR", "First Friday", "Monthly"},
+		{"FREQ=MONTHLY;BYDAY=-1SU", "Last Sunday", "Monthly"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "Day 1 from the end", "Monthly"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,FR", "Wednesday, Friday", "Every 2 weeks"},
+		{"FREQ=DAILY", "Daily", "Daily"},
+	}
+	for _, tc := range cases {
+		r := mustParse(t, tc.rule)
+		if r.Days() != tc.days || r.Describe() != tc.describe {
+			t.Errorf("%s: Days = %q, Describe = %q; want %q, %q", tc.rule, r.Days(), r.Describe(), tc.days, tc.describe)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// internal/recurrence/text.go
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var dayWords = map[string]time.Weekday{
+	"sunday": time.Sunday, "sundays": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mondays": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tuesdays": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wednesdays": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thursdays": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fridays": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "saturdays": time.Saturday, "sat": time.Saturday,
+}
+
+var ordinalWords = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "last": -1,
+}
+
+// Words that mean the schedule cannot be written as a repeating rule
+var vagueWords = []string{
+	"quarterly", "yearly", "annually", "annual", "occasionally", "occasional", "periodically",
+	"announced", "tba", "tbd", "varies", "various", "seasonal",
+}
+
+var numberedDay = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)$`)
+
+// ParseText makes a best-effort rule from free-text schedule fields such as
+// ("Friday", "every first Friday") or ("Wednesday & Friday", "Weekly"). Any notes
+// returned explain why the result is uncertain; Freq is empty when no rule could be built.
+func ParseText(parts ...string) (Rule, []string) {
+	text := strings.ToLower(strings.Join(parts, " "))
+	text = strings.NewReplacer("-", " - ", "&", " and ", ",", " ", "/", " ", ".", " ", "(", " ", ")", " ").Replace(text)
+	words := strings.Fields(text)
+
+	var notes []string
+	for _, w := range words {
+		for _, vague := range vagueWords {
+			if w == vague {
+				notes = append(notes, fmt.Sprintf("%q cannot be written as a repeating weekly or monthly rule", w))
+			}
+		}
+	}
+	if len(notes) > 0 {
+		return Rule{}, notes
+	}
+
+	r := Rule{Interval: 1}
+	daily, monthly := false, false
+	plain := map[time.Weekday]bool{}
+	var plainOrder []time.Weekday
+	numbered := map[WeekdayNum]bool{}
+	var pending []int
+	var monthDays []int
+	lastDay, rangeFrom := -1, -1
+
+	addPlain := func(d time.Weekday) {
+		if !plain[d] {
+			plain[d] = true
+			plainOrder = append(plainOrder, d)
+		}
+	}
+
+	for i, w := range words {
+		if day, ok := dayWords[w]; ok {
+			if len(pending) > 0 {
+				for _, n := range pending {
+					wd := WeekdayNum{N: n, Day: day}
+					if !numbered[wd] {
+						numbered[wd] = true
+						r.ByDay = append(r.ByDay, wd)
+					}
+				}
+				pending = nil
+			} else if rangeFrom >= 0 {
+				for d := rangeFrom; ; d = (d + 1) % 7 {
+					addPlain(time.Weekday(d))
+					if d == int(day) {
+						break
+					}
+				}
+			} else {
+				addPlain(day)
+			}
+			lastDay, rangeFrom = int(day), -1
+			continue
+		}
+
+		switch {
+		case w == "-" || w == "to" || w == "through" || w == "thru":
+			if lastDay >= 0 && i+1 < len(words) {
+				if _, ok := dayWords[words[i+1]]; ok {
+					rangeFrom = lastDay
+				}
+			}
+		case w == "daily" || w == "everyday" || (w == "day" && i > 0 && words[i-1] == "every"):
+			daily = true
+		case w == "weekdays":
+			for d := time.Monday; d <= time.Friday; d++ {
+				addPlain(d)
+			}
+		case w == "weekends":
+			addPlain(time.Saturday)
+			addPlain(time.Sunday)
+		case w == "monthly" || w == "month" || w == "months":
+			monthly = true
+		case w == "fortnightly" || w == "biweekly" || w == "fortnight" ||
+			(w == "weekly" && i > 1 && words[i-1] == "-" && words[i-2] == "bi") ||
+			(w == "other" && i > 0 && (words[i-1] == "every" || words[i-1] == "each")) || w == "alternate":
+			r.Interval = 2
+		case ordinalWords[w] != 0:
+			pending = append(pending, ordinalWords[w])
+		case numberedDay.MatchString(w):
+			n, _ := strconv.Atoi(numberedDay.FindStringSubmatch(w)[1])
+			if n >= 1 && n <= 5 {
+				pending = append(pending, n)
+			} else if n <= 31 {
+				monthDays = append(monthDays, n)
+			}
+		}
+		if w != "-" && w != "to" && w != "through" && w != "thru" {
+			lastDay = -1
+		}
+	}
+
+	// A leftover "1st" or "15th" with no weekday after it is a day of the month
+	if len(pending) > 0 && len(r.ByDay) == 0 {
+		for _, n := range pending {
+			if n > 0 {
+				monthDays = append(monthDays, n)
+			} else {
+				monthDays = append(monthDays, -1)
+			}
+		}
+	}
+
+	switch {
+	case len(r.ByDay) > 0:
+		// "Friday" alongside "first Friday" is the same day written twice
+		for _, d := range plainOrder {
+			found := false
+			for _, wd := range r.ByDay {
+				found = found || wd.Day == d
+			}
+			if !found {
+				notes = append(notes, "mixes every "+d.String()+" with numbered weekdays; only the numbered ones were kept")
+			}
+		}
+		r.Freq = Monthly
+	case len(monthDays) > 0:
+		if len(plainOrder) > 0 {
+			notes = append(notes, "mixes days of the month with weekdays; only the days of the month were kept")
+		}
+		r.Freq = Monthly
+		r.ByMonthDay = monthDays
+	case len(plainOrder) > 0:
+		for _, d := range plainOrder {
+			r.ByDay = append(r.ByDay, WeekdayNum{Day: d})
+		}
+		r.Freq = Weekly
+		if monthly {
+			notes = append(notes, "says monthly without saying which week; assumed the first")
+			for i := range r.ByDay {
+				r.ByDay[i].N = 1
+			}
+			r.Freq = Monthly
+		}
+		if daily {
+			notes = append(notes, "says daily but also names weekdays; used the weekdays")
+		}
+	case daily:
+		r.Freq = Daily
+	default:
+		return Rule{}, []string{"no day of the week or day of the month was found"}
+	}
+
+	if r.Interval > 1 && r.Freq == Monthly {
+		notes = append(notes, "every other month was read as INTERVAL=2")
+	}
+	if err := r.Validate(); err != nil {
+		return Rule{}, append(notes, err.Error())
+	}
+	return r, notes
+}
+
+var clockPattern = regexp.MustCompile(`(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm|a\.m\.?|p\.m\.?)?`)
+
+// ParseTimeRange reads free-text times such as "9:00 AM", "6pm - 8pm" or "18:00-20:00"
+// into 24-hour "HH:MM" start and end (end is empty when only one time is given)
+func ParseTimeRange(s string) (start, end string, err error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+	text = strings.ReplaceAll(text, "noon", "12:00pm")
+	text = strings.ReplaceAll(text, "midnight", "12:00am")
+
+	type clock struct {
+		hour, minute int
+		meridiem     string
+	}
+	var clocks []clock
+	for _, m := range clockPattern.FindAllStringSubmatch(text, 2) {
+		hour, _ := strconv.Atoi(m[1])
+		minute := 0
+		if m[2] != "" {
+			minute, _ = strconv.Atoi(m[2])
+		}
+		if hour > 23 || minute > 59 {
+			return "", "", fmt.Errorf("%q is not a valid time", m[0])
+		}
+		clocks = append(clocks, clock{hour, minute, strings.TrimRight(strings.ReplaceAll(m[3], ".", ""), " ")})
+	}
+	if len(clocks) == 0 {
+		return "", "", errors.New("no time found")
+	}
+
+	// "6 - 8pm": the first time takes the second's am/pm when that keeps it earlier
+	if len(clocks) == 2 && clocks[0].meridiem == "" && clocks[1].meridiem != "" && clocks[0].hour <= 12 {
+		clocks[0].meridiem = clocks[1].meridiem
+		if to24(clocks[0].hour, clocks[0].meridiem) > to24(clocks[1].hour, clocks[1].meridiem) {
+			clocks[0].meridiem = "am"
+		}
+	}
+
+	format := func(c clock) (string, error) {
+		hour := c.hour
+		if c.meridiem != "" {
+			if hour < 1 || hour > 12 {
+				return "", fmt.Errorf("%d%s is not a valid time", c.hour, c.meridiem)
+			}
+			hour = to24(hour, c.meridiem)
+		}
+		return fmt.Sprintf("%02d:%02d", hour, c.minute), nil
+	}
+
+	if start, err = format(clocks[0]); err != nil {
+		return "", "", err
+	}
+	if len(clocks) > 1 {
+		if end, err = format(clocks[1]); err != nil {
+			return "", "", err
+		}
+	}
+	return start, end, nil
+}
+
+func to24(hour int, meridiem string) int {
+	hour %= 12
+	if meridiem == "pm" {
+		hour += 12
+	}
+	return hour
+}
@@ -0,0 +1,78 @@
+// internal/recurrence/text_test.go
+package recurrence
+
+import "testing"
+
+func TestParseText(t *testing.T) {
+	cases := []struct {
+		day, frequency string
+		want           string // Empty when no rule can be built
+		notes          int
+	}{
+		// Schedules as they were written before programs had structured rules
+		{"Friday", "every first Friday", "FREQ=MONTHLY;BYDAY=1FR", 0},
+		{"Wednesday & Friday", "Weekly", "FREQ=WEEKLY;BYDAY=WE,FR", 0},
+		{"Sunday", "Weekly", "FREQ=WEEKLY;BYDAY=SU", 0},
+		{"Sundays", "", "FREQ=WEEKLY;BYDAY=SU", 0},
+		{"Last Sunday", "Monthly", "FREQ=MONTHLY;BYDAY=-1SU", 0},
+		{"1st & 3rd Saturday", "Monthly", "FREQ=MONTHLY;BYDAY=1SA,3SA", 0},
+		{"Monday - Friday", "Weekly", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", 0},
+		{"Weekdays", "", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", 0},
+		{"Tuesday", "Every other week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", 0},
+		{"Thursday", "Fortnightly", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TH", 0},
+		{"", "Daily", "FREQ=DAILY", 0},
+		{"15th", "Monthly", "FREQ=MONTHLY;BYMONTHDAY=15", 0},
+		{"Last day", "Monthly", "FREQ=MONTHLY;BYMONTHDAY=-1", 0},
+
+		// Readable, but an admin should check the guess
+		{"Saturday", "Monthly", "FREQ=MONTHLY;BYDAY=1SA", 1},
+		{"First Friday", "Every other month", "FREQ=MONTHLY;INTERVAL=2;BYDAY=1FR", 1},
+		{"Sunday & first Friday", "", "FREQ=MONTHLY;BYDAY=1FR", 1},
+
+		// Not a repeating rule
+		{"Sunday", "Quarterly", "", 1},
+		{"TBA", "", "", 1},
+		{"Various", "As announced", "", 2},
+		{"", "Weekly", "", 1},
+	}
+	for _, tc := range cases {
+		r, notes := ParseText(tc.day, tc.frequency)
+		got := ""
+		if r.Freq != "" {
+			got = r.String()
+		}
+		if got != tc.want || len(notes) != tc.notes {
+			t.Errorf("ParseText(%q, %q) = %q with notes %q; want %q with %d notes",
+				tc.day, tc.frequency, got, notes, tc.want, tc.notes)
+		}
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	valid := []struct{ in, start, end string }{
+		{"6 - 8pm", "18:00", "20:00"},
+		{"6pm - 8pm", "18:00", "20:00"},
+		{"9:00 AM", "09:00", ""},
+		{"9.30am", "09:30", ""},
+		{"10 - 12pm", "10:00", "12:00"},
+		{"11 - 1pm", "11:00", "13:00"},
+		{"10pm - 5am", "22:00", "05:00"},
+		{"18:00-20:00", "18:00", "20:00"},
+		{"noon", "12:00", ""},
+		{"10pm - midnight", "22:00", "00:00"},
+		{" 7:30 p.m. ", "19:30", ""},
+	}
+	for _, tc := range valid {
+		start, end, err := ParseTimeRange(tc.in)
+		if err != nil || start != tc.start || end != tc.end {
+			t.Errorf("ParseTimeRange(%q) = %q, %q, %v; want %q, %q", tc.in, start, end, err, tc.start, tc.end)
+		}
+	}
+
+	invalid := []string{"", "after service", "25:00", "9:75", "13pm"}
+	for _, in := range invalid {
+		if start, end, err := ParseTimeRange(in); err == nil {
+			t.Errorf("ParseTimeRange(%q) = %q, %q; want an error", in, start, end)
+		}
+	}
+}
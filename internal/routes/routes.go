@@ -43,6 +43,7 @@ func SetupRoutes(r *gin.Engine) {
 		api.GET("/sermons", handlers.GetSermons)
 		api.GET("/sermons/latest", handlers.GetLatestSermon)
 		api.GET("/sermons/search", handlers.SearchSermons)
+		api.GET("/sermons/:slug", handlers.GetSermonBySlug)
 
 		// PUBLIC: Service Types
 		api.GET("/service-types", handlers.GetServiceTypes)
@@ -53,24 +54,64 @@ func SetupRoutes(r *gin.Engine) {
 		// PUBLIC: Submit testimony (moderate rate limit - 10 per hour)
 		api.POST("/testimonies",
 			middleware.CustomRateLimiter(10, 1*time.Hour),
+			middleware.Idempotent(),
 			handlers.CreateTestimony,
 		)
 
 		// PUBLIC: Submit first-timer (moderate rate limit - 5 per hour)
 		api.POST("/first-timers",
 			middleware.CustomRateLimiter(5, 1*time.Hour),
+			middleware.Idempotent(),
 			handlers.CreateFirstTimer,
 		)
 
 		// PUBLIC: Submit prayer request (moderate rate limit - 10 per hour)
 		api.POST("/prayer-requests",
 			middleware.CustomRateLimiter(10, 1*time.Hour),
+			middleware.Idempotent(),
 			handlers.CreatePrayerRequest,
 		)
 
 		// PUBLIC: Special Events & Regular Programs
+		api.GET("/calendar", handlers.GetCalendar)
 		api.GET("/special-events", handlers.GetSpecialEvents)
+		api.GET("/special-events/archive", handlers.GetSpecialEventsArchive)
+		api.GET("/special-events/:slug", handlers.GetSpecialEventBySlug)
+		api.GET("/special-events/:slug/calendar.ics", handlers.GetSpecialEventICS)
+
+		// PUBLIC: Event registration (moderate rate limit - 10 per hour). The code and
+		// token returned on registering let the registrant view or cancel it.
+		api.POST("/special-events/:slug/registrations",
+			middleware.CustomRateLimiter(10, 1*time.Hour),
+			middleware.Idempotent(),
+			handlers.CreateEventRegistration,
+		)
+		api.GET("/registrations/:code", handlers.GetOwnEventRegistration)
+		api.GET("/registrations/:code/qr.png", handlers.GetOwnRegistrationQR)
+		api.POST("/registrations/:code/cancel",
+			middleware.CustomRateLimiter(10, 1*time.Hour),
+			middleware.Idempotent(),
+			handlers.CancelOwnEventRegistration,
+		)
+
 		api.GET("/regular-programs", handlers.GetRegularPrograms)
+		api.GET("/regular-programs/upcoming", handlers.GetUpcomingProgramOccurrences)
+		api.GET("/regular-programs/:id/calendar.ics", handlers.GetRegularProgramICS)
+
+		// PUBLIC: iCalendar feeds for phone and desktop calendars
+		api.GET("/feeds/church.ics", handlers.GetChurchCalendarFeed)
+		api.GET("/feeds/types/:type", handlers.GetTypeCalendarFeed)
+
+		// DEVICE: Offline sync for usher and welcome-desk devices (X-Device-Key header)
+		api.POST("/sync",
+			middleware.RequestSizeLimiter(2<<20),
+			middleware.DeviceAuthRequired(),
+			handlers.SyncDeviceOperations,
+		)
+
+		// GATEWAY: SMS delivery reports and replies (?token=SMS_WEBHOOK_SECRET)
+		api.POST("/sms/status", handlers.SMSStatusWebhook)
+		api.POST("/sms/inbound", handlers.SMSInboundWebhook)
 
 		// ADMIN PROTECTED ROUTES - Higher rate limits for authenticated users
 		admin := api.Group("/admin")
@@ -81,7 +122,9 @@ func SetupRoutes(r *gin.Engine) {
 			sermons := admin.Group("/sermons")
 			{
 				sermons.GET("", handlers.AdminGetSermons)
+				sermons.GET("/:id", handlers.AdminGetSermon)
 				sermons.POST("", middleware.RequireRoles("superadmin", "media_team"), handlers.CreateSermon)
+				sermons.POST("/bulk", middleware.RequireRoles("superadmin", "media_team"), handlers.BulkUpdateSermons)
 				sermons.PUT("/:id", middleware.RequireRoles("superadmin", "media_team"), handlers.UpdateSermon)
 				sermons.DELETE("/:id", middleware.RequireRoles("superadmin"), handlers.DeleteSermon)
 			}
@@ -90,7 +133,10 @@ func SetupRoutes(r *gin.Engine) {
 			testimonies := admin.Group("/testimonies")
 			{
 				testimonies.GET("", handlers.AdminGetTestimonies)
+				testimonies.GET("/export", handlers.ExportTestimonies)
+				testimonies.GET("/:id", handlers.AdminGetTestimony)
 				testimonies.PUT("/:id", middleware.RequireRoles("superadmin", "secretariat"), handlers.UpdateTestimony)
+				testimonies.POST("/bulk", middleware.RequireRoles("superadmin", "secretariat"), handlers.BulkUpdateTestimonies)
 				testimonies.DELETE("/:id", middleware.RequireRoles("superadmin"), handlers.DeleteTestimony)
 			}
 
@@ -98,46 +144,202 @@ func SetupRoutes(r *gin.Engine) {
 			firstTimers := admin.Group("/first-timers")
 			{
 				firstTimers.GET("", handlers.AdminGetFirstTimers)
+				firstTimers.GET("/export", handlers.ExportFirstTimers)
+				firstTimers.POST("/import", middleware.RequireRoles("superadmin", "visitors_welfare"), handlers.ImportFirstTimers)
+				firstTimers.GET("/:id", handlers.AdminGetFirstTimer)
 				firstTimers.PUT("/:id", middleware.RequireRoles("superadmin", "visitors_welfare"), handlers.UpdateFirstTimer)
+				firstTimers.POST("/bulk", middleware.RequireRoles("superadmin", "visitors_welfare"), handlers.BulkUpdateFirstTimers)
 				firstTimers.DELETE("/:id", middleware.RequireRoles("superadmin"), handlers.DeleteFirstTimer)
 			}
 
+			// Welcome messages sent to first-timers
+			welcomeSequence := admin.Group("/welcome-sequence")
+			welcomeSequence.Use(middleware.RequireRoles("superadmin", "visitors_welfare"))
+			{
+				welcomeSequence.GET("", handlers.AdminGetWelcomeSequence)
+				welcomeSequence.PUT("/:step", handlers.UpdateWelcomeStep)
+				welcomeSequence.DELETE("/:step", handlers.ResetWelcomeStep)
+			}
+
 			// Attendance Management
 			attendance := admin.Group("/attendance")
 			{
 				attendance.GET("", handlers.AdminGetAttendance)
+				attendance.GET("/export", handlers.ExportAttendance)
+				attendance.GET("/duplicates", handlers.AdminGetDuplicateAttendance)
+				attendance.POST("/import", middleware.RequireRoles("superadmin", "secretariat"), handlers.ImportAttendance)
+				attendance.POST("/count-sheets", middleware.RequireRoles("superadmin", "secretariat"), handlers.SubmitCountSheet)
+				attendance.DELETE("/count-sheets/:sheetId", middleware.RequireRoles("superadmin", "secretariat"), handlers.DeleteCountSheet)
+				attendance.GET("/:id", handlers.AdminGetAttendanceRecord)
+				attendance.GET("/:id/count-sheets", handlers.AdminGetCountSheets)
+				attendance.POST("/:id/sign-off", middleware.RequireRoles("superadmin", "secretariat"), handlers.SignOffAttendance)
+				attendance.POST("/:id/unlock", middleware.RequireSuperAdmin(), handlers.UnlockAttendance)
 				attendance.POST("", middleware.RequireRoles("superadmin", "secretariat"), handlers.CreateAttendance)
 				attendance.PUT("/:id", middleware.RequireRoles("superadmin", "secretariat"), handlers.UpdateAttendance)
 				attendance.DELETE("/:id", middleware.RequireRoles("superadmin"), handlers.DeleteAttendance)
 			}
 
+			// Attendance Sections (areas counted on separate count sheets)
+			attendanceSections := admin.Group("/attendance-sections")
+			{
+				attendanceSections.GET("", handlers.AdminGetAttendanceSections)
+				attendanceSections.POST("", middleware.RequireRoles("superadmin", "secretariat"), handlers.CreateAttendanceSection)
+				attendanceSections.PUT("/:id", middleware.RequireRoles("superadmin", "secretariat"), handlers.UpdateAttendanceSection)
+				attendanceSections.DELETE("/:id", middleware.RequireRoles("superadmin"), handlers.DeleteAttendanceSection)
+			}
+
+			// Headcount Categories
+			headcountCategories := admin.Group("/headcount-categories")
+			{
+				headcountCategories.GET("", handlers.AdminGetHeadcountCategories)
+				headcountCategories.POST("", middleware.RequireRoles("superadmin", "secretariat"), handlers.CreateHeadcountCategory)
+				headcountCategories.PUT("/:id", middleware.RequireRoles("superadmin", "secretariat"), handlers.UpdateHeadcountCategory)
+				headcountCategories.DELETE("/:id", middleware.RequireRoles("superadmin"), handlers.DeleteHeadcountCategory)
+			}
+
+			// Service types (Sunday Service, Midweek Service, ...)
+			serviceTypes := admin.Group("/service-types")
+			{
+				serviceTypes.GET("", handlers.AdminGetServiceTypes)
+				serviceTypes.POST("", middleware.RequireRoles("superadmin", "secretariat"), handlers.CreateServiceType)
+				serviceTypes.PUT("/reorder", middleware.RequireRoles("superadmin", "secretariat"), handlers.ReorderServiceTypes)
+				serviceTypes.PUT("/:id", middleware.RequireRoles("superadmin", "secretariat"), handlers.UpdateServiceType)
+				serviceTypes.DELETE("/:id", middleware.RequireRoles("superadmin"), handlers.DeleteServiceType)
+			}
+
+			// Services (one occurrence of a service type on a date)
+			services := admin.Group("/services")
+			{
+				services.GET("", handlers.AdminGetServiceOccurrences)
+				services.GET("/:id", handlers.AdminGetServiceOccurrence)
+				services.POST("", middleware.RequireRoles("superadmin", "secretariat", "admin"), handlers.CreateServiceOccurrence)
+				services.PUT("/:id", middleware.RequireRoles("superadmin", "secretariat", "admin"), handlers.UpdateServiceOccurrence)
+				services.DELETE("/:id", middleware.RequireRoles("superadmin"), handlers.DeleteServiceOccurrence)
+				services.POST("/:id/offerings", middleware.RequireRoles("superadmin", "secretariat"), handlers.CreateOffering)
+				services.DELETE("/:id/offerings/:offeringId", middleware.RequireRoles("superadmin", "secretariat"), handlers.DeleteOffering)
+			}
+
 			// Prayer Requests Management
 			prayerRequests := admin.Group("/prayer-requests")
 			{
 				prayerRequests.GET("", handlers.AdminGetPrayerRequests)
+				prayerRequests.GET("/export", handlers.ExportPrayerRequests)
+				prayerRequests.GET("/:id", handlers.AdminGetPrayerRequest)
 				prayerRequests.PUT("/:id", middleware.RequireRoles("superadmin", "secretariat"), handlers.UpdatePrayerRequest)
+				prayerRequests.POST("/bulk", middleware.RequireRoles("superadmin", "secretariat"), handlers.BulkUpdatePrayerRequests)
 				prayerRequests.DELETE("/:id", middleware.RequireRoles("superadmin", "secretariat"), handlers.DeletePrayerRequest)
 			}
 
+			// Sync Devices (superadmin only)
+			devices := admin.Group("/devices")
+			devices.Use(middleware.RequireSuperAdmin())
+			{
+				devices.GET("", handlers.AdminGetDevices)
+				devices.POST("", handlers.RegisterDevice)
+				devices.GET("/:id/operations", handlers.AdminGetDeviceOperations)
+				devices.DELETE("/:id", handlers.RevokeDevice)
+			}
+
+			// CSV Import Batches
+			imports := admin.Group("/imports")
+			{
+				imports.GET("", middleware.RequireRoles("superadmin", "secretariat", "visitors_welfare"), handlers.AdminGetImportBatches)
+				imports.DELETE("/:id", middleware.RequireSuperAdmin(), handlers.RollbackImportBatch)
+			}
+
 			// Dashboard
 			admin.GET("/dashboard", handlers.AdminGetDashboard)
 
+			// Analytics
+			admin.GET("/analytics/attendance", handlers.AdminGetAttendanceAnalytics)
+
+			// Audit trail (superadmin only)
+			activityLog := admin.Group("/activity-log")
+			activityLog.Use(middleware.RequireSuperAdmin())
+			{
+				activityLog.GET("", handlers.AdminGetActivityLogs)
+				activityLog.GET("/export", handlers.ExportActivityLogs)
+			}
+
+			// Background jobs (superadmin only)
+			jobs := admin.Group("/jobs")
+			jobs.Use(middleware.RequireSuperAdmin())
+			{
+				jobs.GET("", handlers.AdminGetJobs)
+				jobs.POST("/:name/run", handlers.RunJob)
+			}
+
+			// Background work queue (superadmin only)
+			queue := admin.Group("/queue")
+			queue.Use(middleware.RequireSuperAdmin())
+			{
+				queue.GET("", handlers.AdminGetQueuedJobs)
+				queue.POST("/:id/retry", handlers.RetryQueuedJob)
+			}
+
+			// Email templates and delivery log (superadmin only)
+			emailTemplates := admin.Group("/email-templates")
+			emailTemplates.Use(middleware.RequireSuperAdmin())
+			{
+				emailTemplates.GET("", handlers.AdminGetEmailTemplates)
+				emailTemplates.PUT("/:type", handlers.UpdateEmailTemplate)
+				emailTemplates.DELETE("/:type", handlers.ResetEmailTemplate)
+			}
+			emailDeliveries := admin.Group("/email-deliveries")
+			emailDeliveries.Use(middleware.RequireSuperAdmin())
+			{
+				emailDeliveries.GET("", handlers.AdminGetEmailDeliveries)
+				emailDeliveries.GET("/:id", handlers.AdminGetEmailDelivery)
+			}
+
+			// Text messages, their costs and opted-out numbers (superadmin only)
+			smsMessages := admin.Group("/sms-messages")
+			smsMessages.Use(middleware.RequireSuperAdmin())
+			{
+				smsMessages.GET("", handlers.AdminGetSMSMessages)
+			}
+			smsOptOuts := admin.Group("/sms-opt-outs")
+			smsOptOuts.Use(middleware.RequireSuperAdmin())
+			{
+				smsOptOuts.GET("", handlers.AdminGetSMSOptOuts)
+				smsOptOuts.POST("", handlers.CreateSMSOptOut)
+				smsOptOuts.DELETE("/:phone", handlers.DeleteSMSOptOut)
+			}
+
 			// ADMIN: Special Events Management
 			specialEvents := admin.Group("/special-events")
 			{
 				specialEvents.GET("", handlers.AdminGetSpecialEvents)
+				specialEvents.GET("/:id", handlers.AdminGetSpecialEvent)
 				specialEvents.POST("", middleware.RequireRoles("superadmin", "admin"), handlers.CreateSpecialEvent)
+				specialEvents.POST("/bulk", middleware.RequireRoles("superadmin", "admin"), handlers.BulkUpdateSpecialEvents)
 				specialEvents.PUT("/:id", middleware.RequireRoles("superadmin", "admin"), handlers.UpdateSpecialEvent)
 				specialEvents.DELETE("/:id", middleware.RequireRoles("superadmin", "admin"), handlers.DeleteSpecialEvent)
+				specialEvents.GET("/:id/sessions", handlers.AdminGetSpecialEventSessions)
+				specialEvents.POST("/:id/sessions", middleware.RequireRoles("superadmin", "admin"), handlers.CreateSpecialEventSession)
+				specialEvents.PUT("/:id/sessions/:sessionId", middleware.RequireRoles("superadmin", "admin"), handlers.UpdateSpecialEventSession)
+				specialEvents.DELETE("/:id/sessions/:sessionId", middleware.RequireRoles("superadmin", "admin"), handlers.DeleteSpecialEventSession)
+				specialEvents.GET("/:id/registrations", handlers.AdminGetEventRegistrations)
+				specialEvents.GET("/:id/registrations/export", handlers.ExportEventRegistrations)
+				specialEvents.POST("/:id/registrations/:registrationId/cancel", middleware.RequireRoles("superadmin", "admin"), handlers.AdminCancelEventRegistration)
+				specialEvents.GET("/:id/registrations/:registrationId/qr.png", middleware.RequireRoles("superadmin", "admin", "usher"), handlers.AdminGetRegistrationQR)
+				specialEvents.GET("/:id/check-ins/summary", handlers.GetEventCheckInSummary)
+				specialEvents.POST("/:id/check-ins", middleware.RequireRoles("superadmin", "admin", "usher"), handlers.CheckInEventRegistration)
+				specialEvents.POST("/:id/walk-ins", middleware.RequireRoles("superadmin", "admin", "usher"), handlers.CreateEventWalkIn)
 			}
 
 			// ADMIN: Regular Programs Management
 			regularPrograms := admin.Group("/regular-programs")
 			{
 				regularPrograms.GET("", handlers.AdminGetRegularPrograms)
+				regularPrograms.GET("/:id", handlers.AdminGetRegularProgram)
 				regularPrograms.POST("", middleware.RequireRoles("superadmin", "admin"), handlers.CreateRegularProgram)
 				regularPrograms.PUT("/:id", middleware.RequireRoles("superadmin", "admin"), handlers.UpdateRegularProgram)
 				regularPrograms.DELETE("/:id", middleware.RequireRoles("superadmin", "admin"), handlers.DeleteRegularProgram)
+				regularPrograms.GET("/:id/exceptions", handlers.AdminGetProgramExceptions)
+				regularPrograms.POST("/:id/exceptions", middleware.RequireRoles("superadmin", "admin"), handlers.CreateProgramException)
+				regularPrograms.PUT("/:id/exceptions/:exceptionId", middleware.RequireRoles("superadmin", "admin"), handlers.UpdateProgramException)
+				regularPrograms.DELETE("/:id/exceptions/:exceptionId", middleware.RequireRoles("superadmin", "admin"), handlers.DeleteProgramException)
 			}
 		}
 	}
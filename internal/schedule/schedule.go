@@ -0,0 +1,275 @@
+// internal/schedule/schedule.go
+package schedule
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/recurrence"
+)
+
+// Occurrence is one dated instance of a regular program
+type Occurrence struct {
+	ProgramID uint       `json:"programId"`
+	Title     string     `json:"title"`
+	Type      string     `json:"type"`
+	Location  string     `json:"location"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end,omitempty"`
+	AllDay    bool       `json:"allDay"` // No start time is set; Start is midnight local time
+	Timezone  string     `json:"timezone"`
+
+	// Set from a ProgramException
+	Cancelled     bool       `json:"cancelled"`
+	OriginalStart *time.Time `json:"originalStart,omitempty"` // When the occurrence was moved
+	Reason        string     `json:"reason,omitempty"`
+	Note          string     `json:"note,omitempty"`
+}
+
+const dateLayout = "2006-01-02"
+
+// Series is a program's schedule in a form that can be expanded into occurrences
+type Series struct {
+	Rule     recurrence.Rule
+	Start    time.Time     // First possible occurrence, in the program's timezone
+	Until    time.Time     // Zero when the program has no end date
+	Duration time.Duration // Zero when no end time is set
+	AllDay   bool
+}
+
+// ForProgram reads a program's structured schedule
+func ForProgram(p models.RegularProgram) (Series, error) {
+	var s Series
+	if p.RRule == "" {
+		return s, errors.New("program has no recurrence rule")
+	}
+	rule, err := recurrence.Parse(p.RRule)
+	if err != nil {
+		return s, err
+	}
+	s.Rule = rule
+
+	loc := localtime.Location()
+	if p.Timezone != "" {
+		if loc, err = time.LoadLocation(p.Timezone); err != nil {
+			return s, errors.New("unknown timezone " + p.Timezone)
+		}
+	}
+
+	if p.StartsOn != nil {
+		s.Start = p.StartsOn.In(loc)
+	} else {
+		first := p.CreatedAt
+		if first.IsZero() {
+			first = time.Now()
+		}
+		first = first.In(loc)
+		s.Start = time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	}
+
+	if p.StartTime == "" {
+		s.AllDay = true
+	} else {
+		startClock, err := time.Parse("15:04", p.StartTime)
+		if err != nil {
+			return s, errors.New("startTime must be HH:MM")
+		}
+		s.Start = s.Start.Add(time.Duration(startClock.Hour())*time.Hour + time.Duration(startClock.Minute())*time.Minute)
+
+		if p.EndTime != "" {
+			endClock, err := time.Parse("15:04", p.EndTime)
+			if err != nil {
+				return s, errors.New("endTime must be HH:MM")
+			}
+			s.Duration = endClock.Sub(startClock)
+			if s.Duration <= 0 {
+				// Ends after midnight, e.g. a night vigil from 22:00 to 05:00
+				s.Duration += 24 * time.Hour
+			}
+		}
+	}
+
+	if p.EndsOn != nil {
+		s.Until = p.EndsOn.AddDays(1).In(loc)
+		if !s.Until.After(s.Start) {
+			return s, errors.New("endsOn must not be before startsOn")
+		}
+	}
+	return s, nil
+}
+
+// Between expands the series into start times in [from, to)
+func (s Series) Between(from, to time.Time, limit int) []time.Time {
+	if !s.Until.IsZero() && to.After(s.Until) {
+		to = s.Until
+	}
+	if !to.After(from) {
+		return nil
+	}
+	return recurrence.Between(s.Rule, s.Start, from, to, limit)
+}
+
+// Occurs reports whether the series has an occurrence on date and when it starts
+func (s Series) Occurs(date localtime.Date) (time.Time, bool) {
+	day := date.In(s.Start.Location())
+	starts := s.Between(day, day.AddDate(0, 0, 1), 1)
+	if len(starts) == 0 {
+		return time.Time{}, false
+	}
+	return starts[0], true
+}
+
+// Moved works out where a rescheduled occurrence now starts and how long it runs
+func (s Series) Moved(e models.ProgramException, original time.Time) (time.Time, time.Duration, error) {
+	day := original
+	if e.NewDate != nil {
+		day = time.Date(e.NewDate.Year(), e.NewDate.Month(), e.NewDate.Day(), original.Hour(), original.Minute(), 0, 0, original.Location())
+	}
+	start, duration := day, s.Duration
+	if e.NewStartTime != "" {
+		clock, err := time.Parse("15:04", e.NewStartTime)
+		if err != nil {
+			return start, 0, errors.New("newStartTime must be HH:MM")
+		}
+		start = time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, day.Location())
+	}
+	if e.NewEndTime != "" {
+		clock, err := time.Parse("15:04", e.NewEndTime)
+		if err != nil {
+			return start, 0, errors.New("newEndTime must be HH:MM")
+		}
+		end := time.Date(start.Year(), start.Month(), start.Day(), clock.Hour(), clock.Minute(), 0, 0, start.Location())
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		duration = end.Sub(start)
+	}
+	return start, duration, nil
+}
+
+func occurrenceOf(p models.RegularProgram, start time.Time, duration time.Duration, allDay bool) Occurrence {
+	occ := Occurrence{
+		ProgramID: p.ID,
+		Title:     p.Title,
+		Type:      p.Type,
+		Location:  p.Location,
+		Start:     start,
+		AllDay:    allDay,
+		Timezone:  start.Location().String(),
+	}
+	if duration > 0 {
+		end := start.Add(duration)
+		occ.End = &end
+	}
+	return occ
+}
+
+func (occ *Occurrence) apply(e models.ProgramException) {
+	occ.Cancelled = e.Cancelled
+	occ.Reason = e.Reason
+	occ.Note = e.Note
+	if e.Location != "" {
+		occ.Location = e.Location
+	}
+}
+
+// runningIn reports whether the occurrence has not finished by from and starts before to
+func (occ Occurrence) runningIn(from, to time.Time) bool {
+	if !occ.Start.Before(to) {
+		return false
+	}
+	if occ.End != nil {
+		return occ.End.After(from)
+	}
+	return !occ.Start.Before(from)
+}
+
+// Program lists a program's occurrences that are still running at or start after from,
+// and start before to. p.Exceptions must be loaded for cancellations and changes to show.
+func Program(p models.RegularProgram, from, to time.Time, limit int) ([]Occurrence, error) {
+	s, err := ForProgram(p)
+	if err != nil {
+		return nil, err
+	}
+
+	exceptions := make(map[string]models.ProgramException, len(p.Exceptions))
+	for _, e := range p.Exceptions {
+		exceptions[e.Date.Format(dateLayout)] = e
+	}
+
+	var out []Occurrence
+	// Start early enough to include an occurrence that has started but not yet finished
+	for _, start := range s.Between(from.Add(-s.Duration), to, 0) {
+		e, changed := exceptions[start.Format(dateLayout)]
+		if changed && e.Rescheduled() {
+			continue // Listed below at its new time
+		}
+		occ := occurrenceOf(p, start, s.Duration, s.AllDay)
+		if changed {
+			occ.apply(e)
+		}
+		if occ.runningIn(from, to) {
+			out = append(out, occ)
+		}
+	}
+
+	for _, e := range p.Exceptions {
+		if !e.Rescheduled() {
+			continue
+		}
+		original, ok := s.Occurs(e.Date)
+		if !ok {
+			continue
+		}
+		start, duration, err := s.Moved(e, original)
+		if err != nil {
+			continue
+		}
+		occ := occurrenceOf(p, start, duration, s.AllDay && e.NewStartTime == "")
+		occ.OriginalStart = &original
+		occ.apply(e)
+		if occ.runningIn(from, to) {
+			out = append(out, occ)
+		}
+	}
+
+	sortOccurrences(out)
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func sortOccurrences(occs []Occurrence) {
+	sort.SliceStable(occs, func(i, j int) bool {
+		if !occs[i].Start.Equal(occs[j].Start) {
+			return occs[i].Start.Before(occs[j].Start)
+		}
+		return occs[i].Title < occs[j].Title
+	})
+}
+
+// Upcoming merges the occurrences of several programs in start order. Programs without
+// a usable schedule are skipped.
+func Upcoming(programs []models.RegularProgram, from, to time.Time, limit int) []Occurrence {
+	var all []Occurrence
+	for _, p := range programs {
+		if p.RecurrenceNeedsReview {
+			continue
+		}
+		occs, err := Program(p, from, to, limit)
+		if err != nil {
+			continue
+		}
+		all = append(all, occs...)
+	}
+
+	sortOccurrences(all)
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all
+}
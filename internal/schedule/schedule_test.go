@@ -0,0 +1,185 @@
+// internal/schedule/schedule_test.go
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+)
+
+func day(year int, month time.Month, d int) *localtime.Date {
+	date := localtime.NewDate(year, month, d)
+	return &date
+}
+
+// describe writes each occurrence on one line, e.g.
+// "2025-01-11 17:00-19:00 moved from 2025-01-12 09:00", to compare against a want list
+func describe(occs []Occurrence) string {
+	lines := make([]string, len(occs))
+	for i, occ := range occs {
+		line := occ.Start.Format("2006-01-02 15:04")
+		if occ.AllDay {
+			line = occ.Start.Format("2006-01-02") + " all day"
+		}
+		if occ.End != nil {
+			line += occ.End.Format("-15:04")
+			if occ.End.YearDay() != occ.Start.YearDay() {
+				line += " next day"
+			}
+		}
+		if occ.Cancelled {
+			line += " cancelled"
+		}
+		if occ.OriginalStart != nil {
+			line += " moved from " + occ.OriginalStart.Format("2006-01-02 15:04")
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestProgram(t *testing.T) {
+	lagos, err := time.LoadLocation("Africa/Lagos")
+	if err != nil {
+		t.Skipf("timezone data is not available: %v", err)
+	}
+	at := func(month time.Month, d, hour int) time.Time {
+		return time.Date(2025, month, d, hour, 0, 0, 0, lagos)
+	}
+	sunday := models.RegularProgram{
+		Title: "Sunday Service", RRule: "FREQ=WEEKLY;BYDAY=SU", Timezone: "Africa/Lagos",
+		StartTime: "09:00", EndTime: "11:00", StartsOn: day(2025, time.January, 1),
+	}
+	vigil := models.RegularProgram{
+		Title: "Holy Ghost Vigil", RRule: "FREQ=MONTHLY;BYDAY=-1FR", Timezone: "Africa/Lagos",
+		StartTime: "22:00", EndTime: "05:00", StartsOn: day(2025, time.January, 1),
+	}
+	with := func(p models.RegularProgram, change func(*models.RegularProgram)) models.RegularProgram {
+		change(&p)
+		return p
+	}
+
+	cases := []struct {
+		name     string
+		program  models.RegularProgram
+		from, to time.Time
+		want     string
+	}{
+		{
+			"weekly", sunday, at(time.January, 1, 0), at(time.January, 20, 0),
+			"2025-01-05 09:00-11:00\n2025-01-12 09:00-11:00\n2025-01-19 09:00-11:00",
+		},
+		{
+			"running at from", sunday, at(time.January, 5, 10), at(time.January, 13, 0),
+			"2025-01-05 09:00-11:00\n2025-01-12 09:00-11:00",
+		},
+		{
+			"overnight", vigil, at(time.January, 1, 0), at(time.March, 1, 0),
+			"2025-01-31 22:00-05:00 next day\n2025-02-28 22:00-05:00 next day",
+		},
+		{
+			"overnight still running after midnight", vigil, at(time.February, 1, 2), at(time.February, 2, 0),
+			"2025-01-31 22:00-05:00 next day",
+		},
+		{
+			"overnight finished", vigil, at(time.February, 1, 5), at(time.February, 2, 0),
+			"",
+		},
+		{
+			"ends on", with(sunday, func(p *models.RegularProgram) { p.EndsOn = day(2025, time.January, 12) }),
+			at(time.January, 1, 0), at(time.February, 1, 0),
+			"2025-01-05 09:00-11:00\n2025-01-12 09:00-11:00",
+		},
+		{
+			"all day", with(sunday, func(p *models.RegularProgram) { p.StartTime, p.EndTime = "", "" }),
+			at(time.January, 1, 0), at(time.January, 13, 0),
+			"2025-01-05 all day\n2025-01-12 all day",
+		},
+		{
+			"every other week from its start", with(sunday, func(p *models.RegularProgram) {
+				p.RRule = "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU"
+				p.StartsOn = day(2025, time.January, 12)
+			}),
+			at(time.January, 1, 0), at(time.February, 10, 0),
+			"2025-01-12 09:00-11:00\n2025-01-26 09:00-11:00\n2025-02-09 09:00-11:00",
+		},
+		{
+			"cancelled", with(sunday, func(p *models.RegularProgram) {
+				p.Exceptions = []models.ProgramException{{Date: *day(2025, time.January, 12), Cancelled: true}}
+			}),
+			at(time.January, 1, 0), at(time.January, 20, 0),
+			"2025-01-05 09:00-11:00\n2025-01-12 09:00-11:00 cancelled\n2025-01-19 09:00-11:00",
+		},
+		{
+			"moved to another day and time", with(sunday, func(p *models.RegularProgram) {
+				p.Exceptions = []models.ProgramException{{
+					Date: *day(2025, time.January, 12), NewDate: day(2025, time.January, 11), NewStartTime: "17:00",
+				}}
+			}),
+			at(time.January, 1, 0), at(time.January, 20, 0),
+			"2025-01-05 09:00-11:00\n2025-01-11 17:00-19:00 moved from 2025-01-12 09:00\n2025-01-19 09:00-11:00",
+		},
+		{
+			"moved into the range", with(sunday, func(p *models.RegularProgram) {
+				p.Exceptions = []models.ProgramException{{
+					Date: *day(2025, time.January, 19), NewDate: day(2025, time.January, 18),
+				}}
+			}),
+			at(time.January, 18, 0), at(time.January, 19, 0),
+			"2025-01-18 09:00-11:00 moved from 2025-01-19 09:00",
+		},
+		{
+			"moved to run overnight", with(vigil, func(p *models.RegularProgram) {
+				p.Exceptions = []models.ProgramException{{
+					Date: *day(2025, time.January, 31), NewStartTime: "21:00", NewEndTime: "03:00",
+				}}
+			}),
+			at(time.January, 1, 0), at(time.February, 1, 0),
+			"2025-01-31 21:00-03:00 next day moved from 2025-01-31 22:00",
+		},
+		{
+			"exception on a day the program does not run", with(sunday, func(p *models.RegularProgram) {
+				p.Exceptions = []models.ProgramException{
+					{Date: *day(2025, time.January, 13), Cancelled: true},
+					{Date: *day(2025, time.January, 14), NewStartTime: "18:00"},
+				}
+			}),
+			at(time.January, 12, 0), at(time.January, 20, 0),
+			"2025-01-12 09:00-11:00\n2025-01-19 09:00-11:00",
+		},
+	}
+	for _, tc := range cases {
+		occs, err := Program(tc.program, tc.from, tc.to, 0)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := describe(occs); got != tc.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestForProgramErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		program models.RegularProgram
+	}{
+		{"no rule", models.RegularProgram{StartTime: "09:00"}},
+		{"invalid rule", models.RegularProgram{RRule: "FREQ=YEARLY"}},
+		{"unknown timezone", models.RegularProgram{RRule: "FREQ=DAILY", Timezone: "Lagos"}},
+		{"invalid start time", models.RegularProgram{RRule: "FREQ=DAILY", StartTime: "9am"}},
+		{"invalid end time", models.RegularProgram{RRule: "FREQ=DAILY", StartTime: "09:00", EndTime: "25:00"}},
+		{"ends before it starts", models.RegularProgram{
+			RRule: "FREQ=DAILY", StartsOn: day(2025, time.February, 1), EndsOn: day(2025, time.January, 1),
+		}},
+	}
+	for _, tc := range cases {
+		if _, err := ForProgram(tc.program); err == nil {
+			t.Errorf("%s: ForProgram succeeded, want an error", tc.name)
+		}
+	}
+}
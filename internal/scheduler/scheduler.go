@@ -0,0 +1,357 @@
+// internal/scheduler/scheduler.go
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// How often each instance looks for jobs that are due
+const tickInterval = 15 * time.Second
+
+// Longest a job may run when it does not set its own Timeout
+const defaultTimeout = 5 * time.Minute
+
+var (
+	ErrUnknownJob = errors.New("no job with this name")
+	ErrJobRunning = errors.New("this job is already running")
+)
+
+// Job is background work run on a schedule. Set either Every or Cron.
+type Job struct {
+	Name    string
+	Every   time.Duration // Run at this interval
+	Cron    string        // Or at these times in church-local time, e.g. "0 6 * * 0" for 6am on Sundays
+	Timeout time.Duration // Defaults to 5 minutes
+	Run     func(ctx context.Context) error
+}
+
+// JobStatus is a registered job and what is recorded about its runs
+type JobStatus struct {
+	models.ScheduledJob
+	Schedule string `json:"schedule"`
+	Running  bool   `json:"running"` // On any instance
+}
+
+type entry struct {
+	job      Job
+	cron     cron.Schedule // Nil for an interval job
+	notUntil time.Time     // Not due before this, as last read from the database
+	running  bool          // On this instance
+}
+
+var (
+	mu      sync.Mutex
+	jobs    = map[string]*entry{}
+	wg      sync.WaitGroup
+	baseCtx = context.Background()
+)
+
+// Register adds a job. It is meant to be called at startup and panics on a bad
+// definition, as that is a programming error.
+func Register(job Job) {
+	if job.Name == "" || job.Run == nil || (job.Every > 0) == (job.Cron != "") {
+		panic(fmt.Sprintf("scheduler: job %q needs a name, a Run func and either Every or Cron", job.Name))
+	}
+	e := &entry{job: job}
+	if job.Cron != "" {
+		schedule, err := cron.ParseStandard(job.Cron)
+		if err != nil {
+			panic(fmt.Sprintf("scheduler: job %q: %v", job.Name, err))
+		}
+		e.cron = schedule
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := jobs[job.Name]; exists {
+		panic(fmt.Sprintf("scheduler: job %q registered twice", job.Name))
+	}
+	jobs[job.Name] = e
+}
+
+// next is when the job is due after from
+func (e *entry) next(from time.Time) time.Time {
+	if e.cron != nil {
+		return e.cron.Next(from.In(localtime.Location()))
+	}
+	return from.Add(e.job.Every)
+}
+
+func (e *entry) describe() string {
+	if e.cron != nil {
+		return "cron " + e.job.Cron
+	}
+	return "every " + e.job.Every.String()
+}
+
+// Start runs due jobs until ctx is cancelled. Every instance can call it; a
+// Postgres advisory lock makes sure only one of them runs a given job at a time.
+func Start(ctx context.Context) {
+	mu.Lock()
+	baseCtx = ctx
+	mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+		for {
+			runDue(ctx, time.Now())
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Wait blocks until jobs already started have finished, or ctx is done
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func runDue(ctx context.Context, now time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, e := range jobs {
+		if e.running || now.Before(e.notUntil) {
+			continue
+		}
+		e.running = true
+		wg.Add(1)
+		go func(e *entry) {
+			defer wg.Done()
+			runScheduled(ctx, e)
+			mu.Lock()
+			e.running = false
+			mu.Unlock()
+		}(e)
+	}
+}
+
+// runScheduled runs the job if no other instance holds it and it is still due
+func runScheduled(ctx context.Context, e *entry) {
+	conn, ok, err := tryLock(ctx, e.job.Name)
+	if err != nil {
+		log.Printf("[SCHEDULER] %s: could not take lock: %v", e.job.Name, err)
+		return
+	}
+	if !ok {
+		return // Running on another instance
+	}
+	defer unlock(conn, e.job.Name)
+
+	now := time.Now()
+	// A job that has never run starts straight away if it runs at an interval, or
+	// at its next time if it runs on a cron schedule
+	first := now
+	if e.cron != nil {
+		first = e.next(now)
+	}
+	database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ScheduledJob{Name: e.job.Name, NextRunAt: first})
+
+	var record models.ScheduledJob
+	if err := database.DB.First(&record, "name = ?", e.job.Name).Error; err != nil {
+		log.Printf("[SCHEDULER] %s: could not load job record: %v", e.job.Name, err)
+		return
+	}
+	if record.NextRunAt.After(now) {
+		setNotUntil(e, record.NextRunAt)
+		return
+	}
+	execute(ctx, e, hostname(), true)
+}
+
+// execute runs the job and records the outcome. The caller holds the job's lock.
+func execute(ctx context.Context, e *entry, by string, reschedule bool) {
+	started := time.Now()
+	database.DB.Model(&models.ScheduledJob{Name: e.job.Name}).Updates(map[string]interface{}{
+		"last_started_at": started,
+		"last_status":     models.JobRunning,
+		"last_run_by":     by,
+	})
+
+	timeout := e.job.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := safeRun(runCtx, e.job)
+	cancel()
+
+	finished := time.Now()
+	changes := map[string]interface{}{
+		"last_finished_at": finished,
+		"last_duration_ms": finished.Sub(started).Milliseconds(),
+		"last_status":      models.JobSucceeded,
+		"last_error":       "",
+		"run_count":        gorm.Expr("run_count + 1"),
+	}
+	if err != nil {
+		changes["last_status"] = models.JobFailed
+		changes["last_error"] = err.Error()
+		changes["failure_count"] = gorm.Expr("failure_count + 1")
+		log.Printf("[SCHEDULER] %s failed after %v: %v", e.job.Name, finished.Sub(started).Round(time.Millisecond), err)
+	}
+	if reschedule {
+		next := e.next(finished)
+		changes["next_run_at"] = next
+		setNotUntil(e, next)
+	}
+	database.DB.Model(&models.ScheduledJob{Name: e.job.Name}).Updates(changes)
+}
+
+// safeRun turns a panic in a job into an error so it cannot take the server down
+func safeRun(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func setNotUntil(e *entry, t time.Time) {
+	mu.Lock()
+	e.notUntil = t
+	mu.Unlock()
+}
+
+// RunNow starts a job straight away in the background, leaving its schedule as it
+// is. by is recorded as who ran it.
+func RunNow(name, by string) error {
+	mu.Lock()
+	e, ok := jobs[name]
+	ctx := baseCtx
+	mu.Unlock()
+	if !ok {
+		return ErrUnknownJob
+	}
+
+	conn, locked, err := tryLock(ctx, name)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return ErrJobRunning
+	}
+	database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ScheduledJob{Name: name, NextRunAt: e.next(time.Now())})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer unlock(conn, name)
+		execute(ctx, e, by, false)
+	}()
+	return nil
+}
+
+// Status lists the registered jobs by name with their last run
+func Status(ctx context.Context) ([]JobStatus, error) {
+	var records []models.ScheduledJob
+	if err := database.DB.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	byName := map[string]models.ScheduledJob{}
+	for _, r := range records {
+		byName[r.Name] = r
+	}
+
+	mu.Lock()
+	entries := make([]*entry, 0, len(jobs))
+	for _, e := range jobs {
+		entries = append(entries, e)
+	}
+	mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].job.Name < entries[j].job.Name })
+
+	statuses := make([]JobStatus, 0, len(entries))
+	for _, e := range entries {
+		status := JobStatus{ScheduledJob: byName[e.job.Name], Schedule: e.describe()}
+		status.Name = e.job.Name
+		// The lock is held for as long as the job runs, on whichever instance
+		conn, free, err := tryLock(ctx, e.job.Name)
+		if err != nil {
+			return nil, err
+		}
+		if free {
+			unlock(conn, e.job.Name)
+		}
+		status.Running = !free
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// lockKey is the advisory lock id for a job
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
+
+// tryLock takes the job's advisory lock on a connection of its own, as the lock
+// belongs to the session that took it. The connection must be passed to unlock.
+func tryLock(ctx context.Context, name string) (*sql.Conn, bool, error) {
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey(name)).Scan(&locked); err != nil || !locked {
+		conn.Close()
+		return nil, false, err
+	}
+	return conn, true, nil
+}
+
+func unlock(conn *sql.Conn, name string) {
+	// Not the job's context, which may be cancelled by now
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey(name)); err != nil {
+		log.Printf("[SCHEDULER] %s: could not release lock: %v", name, err)
+		// The session may still hold the lock, so it must not go back to the pool where
+		// it would keep the job locked. ErrBadConn makes the pool close it instead.
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
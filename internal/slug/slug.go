@@ -0,0 +1,137 @@
+// internal/slug/slug.go
+package slug
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"rccg-salvation-centre-backend/internal/models"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+const maxLength = 120
+
+// Make turns free text into a lowercase, hyphen-separated URL segment
+func Make(text string) string {
+	var b strings.Builder
+	lastHyphen := true
+
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Drop combining accents left over from decomposition
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+			lastHyphen = false
+		case !lastHyphen:
+			b.WriteByte('-')
+			lastHyphen = true
+		}
+	}
+
+	s := strings.Trim(b.String(), "-")
+	if len(s) > maxLength {
+		s = strings.TrimRight(s[:maxLength], "-")
+	}
+	return s
+}
+
+// ForDated builds the base slug for dated content, e.g. "2025-03-02-walking-in-faith"
+func ForDated(title string, date time.Time) string {
+	base := Make(title)
+	if base == "" {
+		base = "untitled"
+	}
+	return date.Format("2006-01-02") + "-" + base
+}
+
+// Unique returns base, or base with a numeric suffix, so that no other row of
+// model (other than excludeID) already uses it and no redirect claims it
+func Unique(tx *gorm.DB, model interface{}, entity string, base string, excludeID uint) (string, error) {
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Model(model).Where("slug = ? AND id <> ?", candidate, excludeID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			var redirects int64
+			if err := tx.Model(&models.SlugRedirect{}).
+				Where("entity = ? AND old_slug = ? AND target_id <> ?", entity, candidate, excludeID).
+				Count(&redirects).Error; err != nil {
+				return "", err
+			}
+			if redirects == 0 {
+				return candidate, nil
+			}
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// Assign works out the slug a row should carry for its current title and date.
+// When the slug changes, the previous one is kept as a redirect to the row.
+func Assign(tx *gorm.DB, model interface{}, entity string, id uint, current string, title string, date time.Time) (string, error) {
+	base := ForDated(title, date)
+
+	// Keep the existing slug while the title and date still produce it
+	if current == base || (current != "" && strings.HasPrefix(current, base+"-") && isNumeric(current[len(base)+1:])) {
+		return current, nil
+	}
+
+	next, err := Unique(tx, model, entity, base, id)
+	if err != nil {
+		return "", err
+	}
+
+	// A title reverted to an earlier value reclaims its old slug
+	if err := tx.Where("entity = ? AND old_slug = ? AND target_id = ?", entity, next, id).
+		Delete(&models.SlugRedirect{}).Error; err != nil {
+		return "", err
+	}
+
+	if current != "" && id != 0 {
+		redirect := models.SlugRedirect{Entity: entity, OldSlug: current, TargetID: id}
+		if err := tx.Where(models.SlugRedirect{Entity: entity, OldSlug: current}).
+			Assign(models.SlugRedirect{TargetID: id}).
+			FirstOrCreate(&redirect).Error; err != nil {
+			return "", err
+		}
+	}
+
+	return next, nil
+}
+
+// Resolve finds the current slug a retired slug redirects to
+func Resolve(tx *gorm.DB, model interface{}, entity string, old string) (string, error) {
+	var redirect models.SlugRedirect
+	if err := tx.Where("entity = ? AND old_slug = ?", entity, old).First(&redirect).Error; err != nil {
+		return "", err
+	}
+
+	var current []string
+	if err := tx.Model(model).Where("id = ?", redirect.TargetID).Pluck("slug", &current).Error; err != nil {
+		return "", err
+	}
+	if len(current) == 0 || current[0] == "" {
+		return "", gorm.ErrRecordNotFound
+	}
+	return current[0], nil
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
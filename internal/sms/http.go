@@ -0,0 +1,121 @@
+// internal/sms/http.go
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request formats the HTTP gateway understands
+const (
+	FormatTermii = "termii" // JSON body with api_key, to, from and sms
+	FormatTwilio = "twilio" // Form body with To, From and Body, and basic auth
+)
+
+var httpClient = &http.Client{Timeout: 20 * time.Second}
+
+// HTTPSender sends through an SMS gateway's HTTP API. URL is the full endpoint,
+// e.g. https://api.ng.termii.com/api/sms/send, or Twilio's
+// https://api.twilio.com/2010-04-01/Accounts/<sid>/Messages.json, so any gateway
+// that speaks either format can be used.
+type HTTPSender struct {
+	Format            string
+	URL               string
+	APIKey            string
+	AccountID         string // The username for basic auth, e.g. Twilio's account SID
+	From              string // Sender ID or number
+	StatusCallbackURL string // Where Twilio-style gateways post delivery reports
+}
+
+func (s HTTPSender) Name() string { return s.Format }
+
+func (s HTTPSender) Send(ctx context.Context, m Message) (Result, error) {
+	if s.URL == "" {
+		return Result{}, errors.New("SMS_API_URL is not set")
+	}
+
+	var req *http.Request
+	var err error
+	switch s.Format {
+	case FormatTwilio:
+		form := url.Values{"To": {m.To}, "From": {s.From}, "Body": {m.Body}}
+		if s.StatusCallbackURL != "" {
+			form.Set("StatusCallback", s.StatusCallbackURL)
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, s.URL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return Result{}, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(s.AccountID, s.APIKey)
+	default:
+		body, err := json.Marshal(map[string]string{
+			"api_key": s.APIKey,
+			"to":      strings.TrimPrefix(m.To, "+"),
+			"from":    s.From,
+			"sms":     m.Body,
+			"type":    "plain",
+			"channel": "generic",
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+		if err != nil {
+			return Result{}, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return Result{}, fmt.Errorf("gateway returned %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+
+	// Termii answers with message_id; Twilio with sid, and price once it is known
+	var reply struct {
+		MessageID json.RawMessage `json:"message_id"`
+		SID       string          `json:"sid"`
+		Price     *string         `json:"price"`
+		PriceUnit string          `json:"price_unit"`
+	}
+	if err := json.Unmarshal(raw, &reply); err != nil {
+		return Result{}, fmt.Errorf("gateway reply was not JSON: %w", err)
+	}
+	result := Result{ProviderID: reply.SID}
+	if len(reply.MessageID) > 0 {
+		result.ProviderID = strings.Trim(string(reply.MessageID), `"`)
+	}
+	if reply.Price != nil {
+		if cost, ok := ParseCost(*reply.Price); ok {
+			result.CostMicros, result.Currency = &cost, costCurrency(reply.PriceUnit)
+		}
+	}
+	return result, nil
+}
+
+// ParseCost reads an amount such as "3.50", or Twilio's "-0.0075", in millionths,
+// so prices below the smallest coin are kept
+func ParseCost(amount string) (int64, bool) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return int64(math.Round(math.Abs(value) * 1e6)), true
+}
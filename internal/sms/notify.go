@@ -0,0 +1,205 @@
+// internal/sms/notify.go
+package sms
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/queue"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Queue job type that sends one message
+const sendJob = "sms.send"
+
+// Notification is a text message about something, e.g. a welcome to a first-timer
+type Notification struct {
+	Type       string // What kind of message it is, e.g. "welcome.thank_you"
+	EntityType string // What it is about, e.g. "first_timer"
+	EntityID   uint
+	Body       string
+}
+
+type sendPayload struct {
+	MessageID uint `json:"messageId"`
+}
+
+// RegisterJobs adds text message sending to the queue
+func RegisterJobs() {
+	queue.Register(sendJob, queue.Options{MaxAttempts: 4, Concurrency: 2, Timeout: 30 * time.Second}, send)
+}
+
+// Send queues n for one phone number in tx and returns the message. A number that
+// opted out is recorded as opted_out and not sent to.
+func Send(tx *gorm.DB, phone string, n Notification) (models.SMSMessage, error) {
+	to, err := Normalize(phone)
+	if err != nil {
+		return models.SMSMessage{}, err
+	}
+	msg := models.SMSMessage{
+		Type:       n.Type,
+		Recipient:  to,
+		Body:       n.Body,
+		EntityType: n.EntityType,
+		EntityID:   n.EntityID,
+		Status:     models.SMSQueued,
+		Segments:   Segments(n.Body),
+		Currency:   currency(),
+	}
+	optedOut, err := OptedOut(tx, to)
+	if err != nil {
+		return msg, err
+	}
+	if optedOut {
+		msg.Status = models.SMSOptedOut
+	}
+	if err := tx.Create(&msg).Error; err != nil {
+		return msg, err
+	}
+	if optedOut {
+		return msg, nil
+	}
+	return msg, queue.Enqueue(tx, sendJob, sendPayload{MessageID: msg.ID})
+}
+
+// send hands one queued message to the provider and records the attempt and its cost
+func send(ctx context.Context, p sendPayload) error {
+	var msg models.SMSMessage
+	err := database.DB.WithContext(ctx).First(&msg, p.MessageID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if msg.Status != models.SMSQueued && msg.Status != models.SMSFailed {
+		return nil
+	}
+
+	// They may have replied STOP to an earlier message since this one was queued
+	optedOut, err := OptedOut(database.DB.WithContext(ctx), msg.Recipient)
+	if err != nil {
+		return err
+	}
+	if optedOut {
+		return database.DB.Model(&msg).Update("status", models.SMSOptedOut).Error
+	}
+
+	s := current()
+	result, err := s.Send(ctx, Message{To: msg.Recipient, Body: msg.Body})
+
+	changes := map[string]interface{}{"attempts": gorm.Expr("attempts + 1"), "provider": s.Name()}
+	if err != nil {
+		changes["status"], changes["last_error"] = models.SMSFailed, err.Error()
+	} else {
+		now := time.Now()
+		changes["status"], changes["last_error"], changes["sent_at"] = models.SMSSent, "", now
+		changes["provider_id"] = result.ProviderID
+		if result.Delivered {
+			changes["status"], changes["delivered_at"] = models.SMSDelivered, now
+		}
+		if result.CostMicros != nil {
+			changes["cost_micros"], changes["currency"] = *result.CostMicros, costCurrency(result.Currency)
+		} else {
+			changes["cost_micros"], changes["currency"] = int64(msg.Segments)*costPerSegment(), currency()
+		}
+	}
+	if updateErr := database.DB.Model(&models.SMSMessage{ID: msg.ID}).Updates(changes).Error; updateErr != nil {
+		log.Printf("[SMS] Failed to record message %d: %v", msg.ID, updateErr)
+	}
+	return err
+}
+
+// Report is a delivery report from the provider
+type Report struct {
+	ProviderID string
+	Status     string  // The provider's word for it, e.g. "delivered" or "undelivered"
+	Cost       *string // What the message cost, when the report says
+	Currency   string  // Of Cost; SMS_CURRENCY when the report doesn't say
+}
+
+// ApplyReport records a delivery report on the message it is about. It returns
+// gorm.ErrRecordNotFound for a message that was not sent from here.
+func ApplyReport(db *gorm.DB, r Report) error {
+	var msg models.SMSMessage
+	if err := db.Where("provider_id = ?", r.ProviderID).First(&msg).Error; err != nil {
+		return err
+	}
+
+	changes := map[string]interface{}{}
+	switch status := strings.ToLower(r.Status); {
+	case strings.Contains(status, "undeliver"), strings.Contains(status, "fail"),
+		strings.Contains(status, "reject"), strings.Contains(status, "expire"), strings.Contains(status, "dnd"):
+		changes["status"], changes["last_error"] = models.SMSFailed, r.Status
+	case strings.Contains(status, "deliver"):
+		changes["status"], changes["delivered_at"] = models.SMSDelivered, time.Now()
+	}
+	if r.Cost != nil {
+		if cost, ok := ParseCost(*r.Cost); ok {
+			changes["cost_micros"], changes["currency"] = cost, costCurrency(r.Currency)
+		}
+	}
+	if len(changes) == 0 {
+		return nil // Still on its way
+	}
+	return db.Model(&msg).Updates(changes).Error
+}
+
+// Replies that opt a number out of, or back in to, text messages
+var (
+	stopWords  = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	startWords = []string{"START", "UNSTOP", "SUBSCRIBE"}
+)
+
+// HandleReply acts on a text sent back to us: STOP and the like opt the number out,
+// START opts it back in. It reports whether the reply changed anything.
+func HandleReply(db *gorm.DB, from, body string) (bool, error) {
+	phone, err := Normalize(from)
+	if err != nil {
+		return false, err
+	}
+	word := strings.ToUpper(strings.Trim(strings.TrimSpace(body), ".!"))
+	for _, w := range stopWords {
+		if word == w {
+			return true, OptOut(db, phone, "reply")
+		}
+	}
+	for _, w := range startWords {
+		if word == w {
+			return true, OptIn(db, phone)
+		}
+	}
+	return false, nil
+}
+
+// OptOut stops text messages to a number
+func OptOut(db *gorm.DB, phone, source string) error {
+	phone, err := Normalize(phone)
+	if err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SMSOptOut{Phone: phone, Source: source}).Error
+}
+
+// OptIn allows text messages to a number again
+func OptIn(db *gorm.DB, phone string) error {
+	phone, err := Normalize(phone)
+	if err != nil {
+		return err
+	}
+	return db.Delete(&models.SMSOptOut{Phone: phone}).Error
+}
+
+// OptedOut reports whether a number asked not to be sent text messages
+func OptedOut(db *gorm.DB, phone string) (bool, error) {
+	var count int64
+	err := db.Model(&models.SMSOptOut{}).Where("phone = ?", phone).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,205 @@
+// internal/sms/notify_test.go
+package sms
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Numbers the tests below use, removed before and after each test
+const testPhonePrefix = "+234999"
+
+// testDB connects to the Postgres database at TEST_DATABASE_URL and makes it
+// database.DB for the test. Tests that need it are skipped when it is not set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connecting to TEST_DATABASE_URL: %v", err)
+	}
+	if err := db.AutoMigrate(&models.SMSMessage{}, &models.SMSOptOut{}); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+
+	removeTestRows := func() {
+		db.Where("recipient LIKE ?", testPhonePrefix+"%").Delete(&models.SMSMessage{})
+		db.Where("phone LIKE ?", testPhonePrefix+"%").Delete(&models.SMSOptOut{})
+	}
+	removeTestRows()
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		removeTestRows()
+		database.DB = previous
+	})
+	return db
+}
+
+// useSender swaps in s for the rest of the test
+func useSender(t *testing.T, s Sender) {
+	previous := current()
+	Use(s)
+	t.Cleanup(func() { Use(previous) })
+}
+
+// pricedSender accepts every message and says what it cost, as Twilio does
+type pricedSender struct{}
+
+func (pricedSender) Name() string { return "priced" }
+
+func (pricedSender) Send(ctx context.Context, m Message) (Result, error) {
+	cost := int64(7500)
+	return Result{ProviderID: "priced-" + m.To, CostMicros: &cost, Currency: "usd"}, nil
+}
+
+func queueTestMessage(t *testing.T, db *gorm.DB, phone, body string) models.SMSMessage {
+	t.Helper()
+	msg := models.SMSMessage{
+		Type:      "test",
+		Recipient: phone,
+		Body:      body,
+		Status:    models.SMSQueued,
+		Segments:  Segments(body),
+		Currency:  "NGN",
+	}
+	if err := db.Create(&msg).Error; err != nil {
+		t.Fatalf("creating message: %v", err)
+	}
+	return msg
+}
+
+func reload(t *testing.T, db *gorm.DB, msg models.SMSMessage) models.SMSMessage {
+	t.Helper()
+	var got models.SMSMessage
+	if err := db.First(&got, msg.ID).Error; err != nil {
+		t.Fatalf("loading message %d: %v", msg.ID, err)
+	}
+	return got
+}
+
+func TestHandleReply(t *testing.T) {
+	db := testDB(t)
+	phone := testPhonePrefix + "0000001"
+
+	steps := []struct {
+		body         string
+		changed      bool
+		wantOptedOut bool
+	}{
+		{"Thanks, see you Sunday", false, false},
+		{"STOP", true, true},
+		{" stop. ", true, true}, // Again, which is not an error
+		{"START", true, false},
+		{"start!", true, false},
+	}
+	for _, step := range steps {
+		changed, err := HandleReply(db, "0999 000 0001", step.body)
+		if err != nil {
+			t.Fatalf("HandleReply(%q): %v", step.body, err)
+		}
+		optedOut, err := OptedOut(db, phone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != step.changed || optedOut != step.wantOptedOut {
+			t.Errorf("after %q: changed = %v, opted out = %v; want %v, %v",
+				step.body, changed, optedOut, step.changed, step.wantOptedOut)
+		}
+	}
+
+	if _, err := HandleReply(db, "not a number", "STOP"); !errors.Is(err, ErrInvalidPhone) {
+		t.Errorf("HandleReply from an invalid number = %v, want ErrInvalidPhone", err)
+	}
+}
+
+func TestSendWithFake(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("SMS_COST_PER_SEGMENT", "4.5")
+	t.Setenv("SMS_CURRENCY", "ngn")
+	fake := &Fake{}
+	useSender(t, fake)
+
+	welcome := queueTestMessage(t, db, testPhonePrefix+"0000001", "Welcome to RCCG Salvation Centre")
+	long := queueTestMessage(t, db, testPhonePrefix+"0000002", "We were glad to have you 🙏 "+
+		"and hope to see you again on Sunday at 9am. Reply STOP to stop these messages.")
+	stopped := queueTestMessage(t, db, testPhonePrefix+"0000003", "Welcome to RCCG Salvation Centre")
+	// Replied STOP after the message was queued
+	if err := OptOut(db, stopped.Recipient, "reply"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []models.SMSMessage{welcome, long, stopped} {
+		if err := send(context.Background(), sendPayload{MessageID: msg.ID}); err != nil {
+			t.Fatalf("sending message %d: %v", msg.ID, err)
+		}
+	}
+
+	sent := fake.Sent()
+	if len(sent) != 2 || sent[0].To != welcome.Recipient || sent[1].To != long.Recipient {
+		t.Fatalf("fake sent %+v, want the first two messages only", sent)
+	}
+
+	for _, msg := range []models.SMSMessage{welcome, long} {
+		got := reload(t, db, msg)
+		want := int64(got.Segments) * 4500000
+		if got.Status != models.SMSDelivered || got.Provider != "fake" || got.Attempts != 1 {
+			t.Errorf("message %d is %s by %q after %d attempts, want delivered by fake after 1",
+				got.ID, got.Status, got.Provider, got.Attempts)
+		}
+		if got.CostMicros != want || got.Currency != "NGN" {
+			t.Errorf("message %d cost %d %s, want %d NGN", got.ID, got.CostMicros, got.Currency, want)
+		}
+	}
+	if got := reload(t, db, long); got.Segments != 2 {
+		t.Errorf("long message has %d segments, want 2", got.Segments)
+	}
+
+	got := reload(t, db, stopped)
+	if got.Status != models.SMSOptedOut || got.Attempts != 0 || got.CostMicros != 0 || got.SentAt != nil {
+		t.Errorf("opted-out message is %s after %d attempts costing %d, want opted_out and never sent",
+			got.Status, got.Attempts, got.CostMicros)
+	}
+}
+
+func TestSendRecordsProviderCost(t *testing.T) {
+	db := testDB(t)
+	t.Setenv("SMS_CURRENCY", "NGN")
+	useSender(t, pricedSender{})
+
+	msg := queueTestMessage(t, db, testPhonePrefix+"0000001", "Welcome to RCCG Salvation Centre")
+	if err := send(context.Background(), sendPayload{MessageID: msg.ID}); err != nil {
+		t.Fatal(err)
+	}
+	got := reload(t, db, msg)
+	if got.Status != models.SMSSent || got.CostMicros != 7500 || got.Currency != "USD" {
+		t.Fatalf("message is %s costing %d %s, want sent costing 7500 USD", got.Status, got.CostMicros, got.Currency)
+	}
+
+	// The delivery report brings the final price, which replaces the estimate
+	cost := "-0.0081"
+	if err := ApplyReport(db, Report{ProviderID: got.ProviderID, Status: "delivered", Cost: &cost, Currency: "usd"}); err != nil {
+		t.Fatal(err)
+	}
+	got = reload(t, db, msg)
+	if got.Status != models.SMSDelivered || got.DeliveredAt == nil || got.CostMicros != 8100 || got.Currency != "USD" {
+		t.Errorf("after the report the message is %s costing %d %s, want delivered costing 8100 USD",
+			got.Status, got.CostMicros, got.Currency)
+	}
+
+	if err := ApplyReport(db, Report{ProviderID: "unknown", Status: "delivered"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("report for an unknown message = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
@@ -0,0 +1,64 @@
+// internal/sms/phone.go
+package sms
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// Used for numbers written without a country code unless SMS_DEFAULT_COUNTRY_CODE is set
+const defaultCountryCode = "234"
+
+var ErrInvalidPhone = errors.New("not a valid phone number")
+
+// Normalize writes a phone number in E.164 form, e.g. "0801 234 5678" as
+// "+2348012345678". Numbers without a country code are taken to be local. A +
+// is only allowed at the start.
+func Normalize(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	international := strings.HasPrefix(phone, "+")
+
+	var digits strings.Builder
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+	number := digits.String()
+
+	code := countryCode()
+	switch {
+	case international:
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	case strings.HasPrefix(number, "0"):
+		number = code + number[1:]
+	case strings.HasPrefix(number, code) && len(number) > len(code)+8:
+		// Already has the country code, without the +
+	default:
+		number = code + number
+	}
+	// The local trunk 0 is sometimes kept after the country code, as in "+234 0801..."
+	if strings.HasPrefix(number, code+"0") {
+		number = code + number[len(code)+1:]
+	}
+
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	return "+" + number, nil
+}
+
+// countryCode is SMS_DEFAULT_COUNTRY_CODE without its +, or Nigeria's
+func countryCode() string {
+	if code := strings.TrimPrefix(strings.TrimSpace(os.Getenv("SMS_DEFAULT_COUNTRY_CODE")), "+"); code != "" {
+		return code
+	}
+	return defaultCountryCode
+}
@@ -0,0 +1,180 @@
+// internal/sms/sms.go
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Message is one text message to one phone number in E.164 form
+type Message struct {
+	To   string
+	Body string
+}
+
+// Result is what the provider said about a message it accepted
+type Result struct {
+	ProviderID string // Its id for the message, used in delivery reports
+	Delivered  bool   // Already delivered, as the fake reports
+	CostMicros *int64 // What it cost in millionths, when the provider says; otherwise SMS_COST_PER_SEGMENT is used
+	Currency   string // Of CostMicros; SMS_CURRENCY when empty
+}
+
+// Sender sends text messages. An HTTP gateway is used in production; the fake
+// stands in for it during development and in tests.
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, m Message) (Result, error)
+}
+
+var (
+	senderOnce sync.Once
+	sender     Sender
+	senderMu   sync.Mutex
+)
+
+// current is the sender chosen by SMS_PROVIDER: "termii" or "twilio" for the HTTP
+// gateway at SMS_API_URL, or the fake (the default)
+func current() Sender {
+	senderOnce.Do(func() {
+		switch provider := os.Getenv("SMS_PROVIDER"); provider {
+		case FormatTermii, FormatTwilio:
+			sender = HTTPSender{
+				Format:            provider,
+				URL:               os.Getenv("SMS_API_URL"),
+				APIKey:            os.Getenv("SMS_API_KEY"),
+				AccountID:         os.Getenv("SMS_ACCOUNT_ID"),
+				From:              os.Getenv("SMS_SENDER_ID"),
+				StatusCallbackURL: os.Getenv("SMS_STATUS_CALLBACK_URL"),
+			}
+		default:
+			sender = &Fake{}
+		}
+	})
+	senderMu.Lock()
+	defer senderMu.Unlock()
+	return sender
+}
+
+// CheckConfig refuses a production server on the fake sender, where every text
+// would be written to the log and reported as delivered without reaching anyone
+func CheckConfig() error {
+	if os.Getenv("ENVIRONMENT") != "production" {
+		return nil
+	}
+	if _, ok := current().(*Fake); ok {
+		return errors.New("SMS_PROVIDER must be termii or twilio in production; the fake sender sends nothing")
+	}
+	return nil
+}
+
+// Use replaces the sender, e.g. with a Fake in a test
+func Use(s Sender) {
+	current()
+	senderMu.Lock()
+	defer senderMu.Unlock()
+	sender = s
+}
+
+// Fake records messages instead of sending them and writes each to the server log.
+// Every message counts as delivered at once.
+type Fake struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+func (f *Fake) Send(ctx context.Context, m Message) (Result, error) {
+	f.mu.Lock()
+	f.sent = append(f.sent, m)
+	id := fmt.Sprintf("fake-%d", len(f.sent))
+	f.mu.Unlock()
+	log.Printf("[SMS] To: %s\n%s", m.To, m.Body)
+	return Result{ProviderID: id, Delivered: true}, nil
+}
+
+// Sent lists the messages recorded so far
+func (f *Fake) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent...)
+}
+
+// Reset forgets the recorded messages
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = nil
+}
+
+// gsm7 are the characters sent as one 7-bit unit; gsm7Extended take two
+const (
+	gsm7 = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extended = "^{}\\[~]|€\f"
+)
+
+// Segments is how many parts a message is sent and charged as: 160 characters
+// fit in one, or 70 if it uses any character outside the GSM alphabet, such as
+// an emoji. Longer messages are split into parts of 153 or 67.
+func Segments(body string) int {
+	units, unicode := 0, false
+	for _, r := range body {
+		switch {
+		case strings.ContainsRune(gsm7, r):
+			units++
+		case strings.ContainsRune(gsm7Extended, r):
+			units += 2
+		default:
+			unicode = true
+		}
+	}
+	if unicode {
+		// Sent as UTF-16, where an emoji takes two units
+		units = len(utf16.Encode([]rune(body)))
+	}
+
+	single, part := 160, 153
+	if unicode {
+		single, part = 70, 67
+	}
+	switch {
+	case units == 0:
+		return 0
+	case units <= single:
+		return 1
+	default:
+		return (units + part - 1) / part
+	}
+}
+
+// costPerSegment is SMS_COST_PER_SEGMENT, an amount in SMS_CURRENCY such as
+// "4.50", in millionths. It is used when the provider does not say what a message
+// cost.
+func costPerSegment() int64 {
+	cost, _ := ParseCost(os.Getenv("SMS_COST_PER_SEGMENT"))
+	return cost
+}
+
+// currency is SMS_CURRENCY, the currency costs are counted in
+func currency() string {
+	if c := os.Getenv("SMS_CURRENCY"); c != "" {
+		return strings.ToUpper(c)
+	}
+	return "NGN"
+}
+
+// costCurrency is the currency a provider gave for a cost, or SMS_CURRENCY
+func costCurrency(unit string) string {
+	if unit = strings.TrimSpace(unit); unit != "" {
+		return strings.ToUpper(unit)
+	}
+	return currency()
+}
@@ -0,0 +1,117 @@
+// internal/sms/sms_test.go
+package sms
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Setenv("SMS_DEFAULT_COUNTRY_CODE", "")
+
+	valid := []struct{ in, want string }{
+		{"08012345678", "+2348012345678"},
+		{"0801 234 5678", "+2348012345678"},
+		{"(0801) 234-5678", "+2348012345678"},
+		{"8012345678", "+2348012345678"},
+		{"2348012345678", "+2348012345678"},
+		{"+2348012345678", "+2348012345678"},
+		{" +234 801 234 5678 ", "+2348012345678"},
+		{"+234 0801 234 5678", "+2348012345678"},
+		{"002348012345678", "+2348012345678"},
+		{"00 234 0801 234 5678", "+2348012345678"},
+		{"00 44 20 7946 0958", "+442079460958"},
+		{"+1 (415) 555-0100", "+14155550100"},
+	}
+	for _, tc := range valid {
+		got, err := Normalize(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"   ",
+		"not a number",
+		"080+1234567",
+		"+234+8012345678",
+		"0801234567x",
+		"123",
+		"+0801234567",
+		"+1234567890123456",
+	}
+	for _, in := range invalid {
+		if got, err := Normalize(in); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("Normalize(%q) = %q, %v; want ErrInvalidPhone", in, got, err)
+		}
+	}
+}
+
+func TestNormalizeCountryCode(t *testing.T) {
+	t.Setenv("SMS_DEFAULT_COUNTRY_CODE", "+44")
+
+	for in, want := range map[string]string{
+		"020 7946 0958":     "+442079460958",
+		"+44 020 7946 0958": "+442079460958",
+		"+2348012345678":    "+2348012345678",
+	} {
+		if got, err := Normalize(in); err != nil || got != want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty", "", 0},
+		{"short", "Welcome to RCCG Salvation Centre!", 1},
+		{"160 GSM characters", strings.Repeat("a", 160), 1},
+		{"161 GSM characters", strings.Repeat("a", 161), 2},
+		{"306 GSM characters", strings.Repeat("a", 306), 2},
+		{"307 GSM characters", strings.Repeat("a", 307), 3},
+		{"GSM accents", strings.Repeat("é", 160), 1},
+		{"80 extended characters", strings.Repeat("€", 80), 1},
+		{"81 extended characters", strings.Repeat("€", 81), 2},
+		{"extended characters mixed in", strings.Repeat("a", 159) + "[", 2},
+		{"70 unicode characters", strings.Repeat("ọ", 70), 1},
+		{"71 unicode characters", strings.Repeat("ọ", 71), 2},
+		{"one emoji", "See you on Sunday 🙏", 1},
+		{"emoji takes two units", strings.Repeat("a", 69) + "🙏", 2},
+		{"emoji switches the whole message", strings.Repeat("a", 100) + "🙏", 2},
+		{"135 emoji", strings.Repeat("🙏", 135), 5},
+	}
+	for _, tc := range cases {
+		if got := Segments(tc.body); got != tc.want {
+			t.Errorf("%s: Segments = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseCost(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"3.50", 3500000, true},
+		{"-0.0075", 7500, true},
+		{"-0.004", 4000, true},
+		{" 12 ", 12000000, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"free", 0, false},
+		{"NaN", 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := ParseCost(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ParseCost(%q) = %d, %v; want %d, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
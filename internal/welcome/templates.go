@@ -0,0 +1,66 @@
+// internal/welcome/templates.go
+package welcome
+
+import "rccg-salvation-centre-backend/internal/email"
+
+// Email types of the welcome steps
+const (
+	ThankYouEmail     = "welcome.thank_you"
+	FollowUpEmail     = "welcome.follow_up"
+	SundayInviteEmail = "welcome.sunday_invite"
+)
+
+var exampleFields = map[string]string{
+	"FirstName": "Ada", "LastName": "Eze", "VisitDate": "Sunday 2 March", "NextSunday": "Sunday 9 March",
+}
+
+func init() {
+	email.RegisterDefault(ThankYouEmail, email.Default{
+		Description: "Welcome sequence: sent to a first-timer as soon as their card is recorded",
+		Subject:     "Thank you for worshipping with us, {{.FirstName}}",
+		HTML: `<p>Dear {{.FirstName}},</p>
+<p>Thank you for worshipping with us at RCCG Salvation Centre on {{.VisitDate}}. It was a joy to have you, and we hope you felt at home.</p>
+<p>If there is anything we can pray with you about, simply reply to this email.</p>
+<p>God bless you,<br>The Visitors Welfare Team</p>`,
+		Text: `Dear {{.FirstName}},
+
+Thank you for worshipping with us at RCCG Salvation Centre on {{.VisitDate}}. It was a joy to have you, and we hope you felt at home.
+
+If there is anything we can pray with you about, simply reply to this email.
+
+God bless you,
+The Visitors Welfare Team`,
+		Fields: exampleFields,
+	})
+	email.RegisterDefault(FollowUpEmail, email.Default{
+		Description: "Welcome sequence: a follow-up a few days after a first-timer's visit",
+		Subject:     "How are you, {{.FirstName}}?",
+		HTML: `<p>Dear {{.FirstName}},</p>
+<p>We have been thinking of you since your visit on {{.VisitDate}} and wanted to check in. How has your week been?</p>
+<p>We would love to get to know you better. Reply to this email if you would like someone from the church to call you, or to learn about our house fellowships and departments.</p>
+<p>God bless you,<br>The Visitors Welfare Team</p>`,
+		Text: `Dear {{.FirstName}},
+
+We have been thinking of you since your visit on {{.VisitDate}} and wanted to check in. How has your week been?
+
+We would love to get to know you better. Reply to this email if you would like someone from the church to call you, or to learn about our house fellowships and departments.
+
+God bless you,
+The Visitors Welfare Team`,
+		Fields: exampleFields,
+	})
+	email.RegisterDefault(SundayInviteEmail, email.Default{
+		Description: "Welcome sequence: invites a first-timer to the coming Sunday service",
+		Subject:     "Join us again on {{.NextSunday}}",
+		HTML: `<p>Dear {{.FirstName}},</p>
+<p>We would be glad to see you again this {{.NextSunday}}. Come a little early and one of our ushers will welcome you.</p>
+<p>God bless you,<br>The Visitors Welfare Team</p>`,
+		Text: `Dear {{.FirstName}},
+
+We would be glad to see you again this {{.NextSunday}}. Come a little early and one of our ushers will welcome you.
+
+God bless you,
+The Visitors Welfare Team`,
+		Fields: exampleFields,
+	})
+}
@@ -0,0 +1,378 @@
+// internal/welcome/welcome.go
+package welcome
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"text/template"
+	"time"
+
+	"rccg-salvation-centre-backend/internal/database"
+	"rccg-salvation-centre-backend/internal/email"
+	"rccg-salvation-centre-backend/internal/localtime"
+	"rccg-salvation-centre-backend/internal/models"
+	"rccg-salvation-centre-backend/internal/queue"
+	"rccg-salvation-centre-backend/internal/sms"
+
+	"gorm.io/gorm"
+)
+
+// Steps of the welcome sequence
+const (
+	ThankYou     = "thank_you"
+	FollowUp     = "follow_up"
+	SundayInvite = "sunday_invite"
+)
+
+// When a step is sent
+const (
+	TimingImmediate    = "immediate"     // As soon as the first-timer is recorded
+	TimingAfterVisit   = "after_visit"   // Days after the visit, at SendTime
+	TimingBeforeSunday = "before_sunday" // Days before the first Sunday after the visit, at SendTime
+)
+
+// Queue job type that sends one welcome message
+const sendJob = "welcome.send"
+
+// A first-timer recorded later than this after their visit gets no thank-you
+const staleAfter = 7
+
+var (
+	ErrUnknownStep = errors.New("unknown welcome step")
+	ErrInvalidStep = errors.New("days must be between 0 and 30 and sendTime must be HH:MM")
+	ErrInvalidSMS  = errors.New("smsText has a mistake")
+)
+
+// definition is a step as built in; admins change its settings but not its timing
+type definition struct {
+	name      string
+	timing    string
+	emailType string
+	settings  models.WelcomeStep
+}
+
+var sequence = []definition{
+	{"Thank-you for visiting", TimingImmediate, ThankYouEmail, models.WelcomeStep{
+		Key: ThankYou, Enabled: true,
+		SMSText: "Hi {{.FirstName}}, thank you for worshipping with us at RCCG Salvation Centre. We were glad to have you! Reply STOP to opt out.",
+	}},
+	{"Follow-up", TimingAfterVisit, FollowUpEmail, models.WelcomeStep{
+		Key: FollowUp, Enabled: true, Days: 3, SendTime: "10:00",
+		SMSText: "Hi {{.FirstName}}, we have been thinking of you since your visit. Reply if you would like someone from RCCG Salvation Centre to call you. Reply STOP to opt out.",
+	}},
+	{"Invitation to next Sunday", TimingBeforeSunday, SundayInviteEmail, models.WelcomeStep{
+		Key: SundayInvite, Enabled: true, Days: 2, SendTime: "17:00",
+		SMSText: "Hi {{.FirstName}}, we would be glad to see you again at RCCG Salvation Centre on {{.NextSunday}}. Reply STOP to opt out.",
+	}},
+}
+
+// Step is one step of the welcome sequence with its current settings
+type Step struct {
+	models.WelcomeStep
+	Name       string `json:"name"`
+	Timing     string `json:"timing"`
+	EmailType  string `json:"emailType"`  // Edited under email templates
+	Customized bool   `json:"customized"` // False while the built-in settings are used
+}
+
+// Steps lists the welcome sequence in order, with any settings admins have saved
+func Steps(db *gorm.DB) ([]Step, error) {
+	var saved []models.WelcomeStep
+	if err := db.Find(&saved).Error; err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]models.WelcomeStep, len(saved))
+	for _, s := range saved {
+		byKey[s.Key] = s
+	}
+
+	steps := make([]Step, 0, len(sequence))
+	for _, d := range sequence {
+		step := Step{WelcomeStep: d.settings, Name: d.name, Timing: d.timing, EmailType: d.emailType}
+		if s, ok := byKey[d.settings.Key]; ok {
+			step.WelcomeStep, step.Customized = s, true
+			if s.SMSText == "" {
+				step.SMSText = d.settings.SMSText
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// Configure saves the settings of one step. Messages already scheduled keep their time.
+func Configure(db *gorm.DB, settings models.WelcomeStep) (Step, error) {
+	d, ok := find(settings.Key)
+	if !ok {
+		return Step{}, ErrUnknownStep
+	}
+	if d.timing == TimingImmediate {
+		settings.Days, settings.SendTime = 0, ""
+	} else if _, _, err := localtime.ParseClock(settings.SendTime); err != nil || settings.Days < 0 || settings.Days > 30 {
+		return Step{}, ErrInvalidStep
+	}
+	if settings.SMSText == "" {
+		settings.SMSText = d.settings.SMSText
+	}
+	if _, err := renderSMS(settings.SMSText, exampleFields); err != nil {
+		return Step{}, ErrInvalidSMS
+	}
+	if err := db.Save(&settings).Error; err != nil {
+		return Step{}, err
+	}
+	return Step{WelcomeStep: settings, Name: d.name, Timing: d.timing, EmailType: d.emailType, Customized: true}, nil
+}
+
+// Reset goes back to the built-in settings of one step
+func Reset(db *gorm.DB, key string) error {
+	if _, ok := find(key); !ok {
+		return ErrUnknownStep
+	}
+	return db.Delete(&models.WelcomeStep{Key: key}).Error
+}
+
+func find(key string) (definition, bool) {
+	for _, d := range sequence {
+		if d.settings.Key == key {
+			return d, true
+		}
+	}
+	return definition{}, false
+}
+
+// Eligible reports whether ft should get welcome messages: they agreed to be
+// contacted, left an email address or phone number and have not joined yet
+func Eligible(ft models.FirstTimer) bool {
+	_, _, ok := contact(ft)
+	return ft.ContactConsent && ok && !Joined(ft)
+}
+
+// contact is how to reach ft: by email when they left an address, otherwise by
+// text message
+func contact(ft models.FirstTimer) (channel, recipient string, ok bool) {
+	if address := strings.ToLower(strings.TrimSpace(ft.Email)); address != "" {
+		return "email", address, true
+	}
+	if phone, err := sms.Normalize(ft.Phone); err == nil {
+		return "sms", phone, true
+	}
+	return "", "", false
+}
+
+// Joined reports whether ft has become part of the church, which ends the sequence
+func Joined(ft models.FirstTimer) bool {
+	return ft.FollowUpStatus == "joined" || ft.Status == "member"
+}
+
+// Start schedules the welcome sequence for a first-timer just saved in tx. Steps
+// whose time has already passed, e.g. for a card entered late, are recorded as
+// skipped. It does nothing for first-timers who are not Eligible.
+func Start(tx *gorm.DB, ft models.FirstTimer) error {
+	if !Eligible(ft) {
+		return nil
+	}
+	steps, err := Steps(tx)
+	if err != nil {
+		return err
+	}
+
+	channel, recipient, _ := contact(ft)
+	now := time.Now()
+	for _, step := range steps {
+		if !step.Enabled {
+			continue
+		}
+		msg := models.WelcomeMessage{
+			FirstTimerID: ft.ID,
+			Step:         step.Key,
+			Channel:      channel,
+			Recipient:    recipient,
+			Status:       models.WelcomeScheduled,
+		}
+		var note string
+		msg.ScheduledFor, note = dueAt(step, ft.VisitDate, now)
+		if note != "" {
+			msg.Status, msg.Note = models.WelcomeSkipped, note
+		}
+		if err := tx.Create(&msg).Error; err != nil {
+			return err
+		}
+		if msg.Status == models.WelcomeScheduled {
+			if err := queue.EnqueueAt(tx, sendJob, sendPayload{MessageID: msg.ID}, msg.ScheduledFor); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dueAt is when step should be sent for a visit, or why it will not be
+func dueAt(step Step, visit localtime.Date, now time.Time) (time.Time, string) {
+	switch step.Timing {
+	case TimingAfterVisit:
+		at, _ := visit.AddDays(step.Days).At(step.SendTime)
+		if at.Before(now) {
+			return at, "its time had passed when the first-timer was recorded"
+		}
+		return at, ""
+	case TimingBeforeSunday:
+		sunday := nextSunday(visit)
+		at, _ := sunday.AddDays(-step.Days).At(step.SendTime)
+		if at.Before(now) {
+			// Too late to invite them to that Sunday, so invite them to the one after
+			at = at.AddDate(0, 0, 7)
+		}
+		if at.Before(now) {
+			return at, "its time had passed when the first-timer was recorded"
+		}
+		return at, ""
+	default:
+		if visit.Before(localtime.DateOf(now).AddDays(-staleAfter)) {
+			return now, "the visit was more than a week before the first-timer was recorded"
+		}
+		return now, ""
+	}
+}
+
+// nextSunday is the first Sunday after day
+func nextSunday(day localtime.Date) localtime.Date {
+	days := 7 - int(day.Weekday())
+	if days == 0 {
+		days = 7
+	}
+	return day.AddDays(days)
+}
+
+// Stop cancels the messages still scheduled for a first-timer
+func Stop(tx *gorm.DB, firstTimerID uint, reason string) error {
+	return tx.Model(&models.WelcomeMessage{}).
+		Where("first_timer_id = ? AND status = ?", firstTimerID, models.WelcomeScheduled).
+		Updates(map[string]interface{}{"status": models.WelcomeCancelled, "note": reason}).Error
+}
+
+type sendPayload struct {
+	MessageID uint `json:"messageId"`
+}
+
+// RegisterJobs adds welcome messages to the queue
+func RegisterJobs() {
+	queue.Register(sendJob, queue.Options{MaxAttempts: 3, Concurrency: 1, Timeout: 30 * time.Second}, send)
+}
+
+// send hands one scheduled message to the email or SMS queue, unless the first-timer
+// has joined, withdrawn consent or the step was turned off since it was scheduled
+func send(ctx context.Context, p sendPayload) error {
+	db := database.DB.WithContext(ctx)
+
+	var msg models.WelcomeMessage
+	err := db.First(&msg, p.MessageID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // The first-timer was deleted
+	}
+	if err != nil {
+		return err
+	}
+	if msg.Status != models.WelcomeScheduled {
+		return nil
+	}
+
+	var ft models.FirstTimer
+	if err := db.First(&ft, msg.FirstTimerID).Error; err != nil {
+		return err
+	}
+	steps, err := Steps(db)
+	if err != nil {
+		return err
+	}
+	var step Step
+	for _, s := range steps {
+		if s.Key == msg.Step {
+			step = s
+		}
+	}
+
+	var reason string
+	switch {
+	case Joined(ft):
+		reason = "the first-timer joined"
+	case !Eligible(ft):
+		reason = "the first-timer can no longer be contacted"
+	case !step.Enabled:
+		reason = "the step was turned off"
+	}
+	if reason != "" {
+		return db.Model(&msg).Updates(map[string]interface{}{"status": models.WelcomeCancelled, "note": reason}).Error
+	}
+
+	channel, recipient, _ := contact(ft)
+	data := fields(ft, msg.ScheduledFor)
+	return db.Transaction(func(tx *gorm.DB) error {
+		changes := map[string]interface{}{
+			"status":    models.WelcomeSent,
+			"channel":   channel,
+			"recipient": recipient,
+			"sent_at":   time.Now(),
+		}
+		if channel == "sms" {
+			body, err := renderSMS(step.SMSText, data)
+			if err != nil {
+				return err
+			}
+			text, err := sms.Send(tx, recipient, sms.Notification{
+				Type:       step.EmailType,
+				EntityType: "first_timer",
+				EntityID:   ft.ID,
+				Body:       body,
+			})
+			if err != nil {
+				return err
+			}
+			changes["sms_message_id"] = text.ID
+			if text.Status == models.SMSOptedOut {
+				changes["status"], changes["note"], changes["sent_at"] = models.WelcomeCancelled, "the number opted out of text messages", nil
+			}
+			return tx.Model(&msg).Updates(changes).Error
+		}
+
+		delivery, err := email.Deliver(tx, recipient, email.Notification{
+			Type:       step.EmailType,
+			EntityType: "first_timer",
+			EntityID:   ft.ID,
+			Data:       data,
+		})
+		if err != nil {
+			return err
+		}
+		changes["email_delivery_id"] = delivery.ID
+		return tx.Model(&msg).Updates(changes).Error
+	})
+}
+
+// renderSMS fills in a step's text message. Fields not given are left blank.
+func renderSMS(text string, data map[string]string) (string, error) {
+	tmpl, err := template.New("sms").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// fields are what the welcome templates can use. NextSunday is the first Sunday on
+// or after the day the message is sent.
+func fields(ft models.FirstTimer, sendAt time.Time) map[string]string {
+	return map[string]string{
+		"FirstName":  ft.FirstName,
+		"LastName":   ft.LastName,
+		"VisitDate":  ft.VisitDate.Format(dayLayout),
+		"NextSunday": nextSunday(localtime.DateOf(sendAt).AddDays(-1)).Format(dayLayout),
+	}
+}
+
+// How days are written in messages, e.g. "Sunday 2 March"
+const dayLayout = "Monday 2 January"
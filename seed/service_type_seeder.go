@@ -5,8 +5,6 @@ import (
 	"log"
 	"rccg-salvation-centre-backend/internal/database"
 	"rccg-salvation-centre-backend/internal/models"
-
-	"gorm.io/gorm"
 )
 
 var serviceTypes = []string{
@@ -24,16 +22,19 @@ var serviceTypes = []string{
 	"Anointing Service",
 }
 
+// SeedServiceTypes fills an empty table with the default list. Once any type exists
+// admins manage the list, so renamed or deleted defaults are not recreated.
 func SeedServiceTypes() {
-	for _, name := range serviceTypes {
-		var existing models.ServiceType
-		if err := database.DB.Where("name = ?", name).First(&existing).Error; err == gorm.ErrRecordNotFound {
-			st := models.ServiceType{Name: name}
-			if err := database.DB.Create(&st).Error; err != nil {
-				log.Printf("Failed to seed service type %s: %v", name, err)
-			} else {
-				log.Printf("Seeded service type: %s", name)
-			}
+	var count int64
+	if err := database.DB.Model(&models.ServiceType{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	for i, name := range serviceTypes {
+		st := models.ServiceType{Name: name, DisplayOrder: i + 1, Active: true}
+		if err := database.DB.Create(&st).Error; err != nil {
+			log.Printf("Failed to seed service type %s: %v", name, err)
+		} else {
+			log.Printf("Seeded service type: %s", name)
 		}
 	}
 }